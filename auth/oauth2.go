@@ -0,0 +1,213 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/leaderwolfpipi/doris"
+	"golang.org/x/oauth2"
+)
+
+type (
+	// Config defines the config of the OAuth2 login handlers.
+	Config struct {
+		// Provider is the identity provider.
+		// Required.
+		Provider *Provider
+
+		// OnSuccess is called with the identity after a successful login, e.g. to
+		// start a session and redirect to the application.
+		// Required.
+		OnSuccess func(c *doris.Context, identity *Identity) error
+
+		// OnError is called when the login fails.
+		// Optional. Default renders a 401 json response.
+		OnError func(c *doris.Context, err error) error
+
+		// ContextKey is the context key to store the identity.
+		// Optional. Default value "identity".
+		ContextKey string
+
+		// StateCookie is the name of the cookie holding the state and the PKCE
+		// verifier between the login redirect and the callback.
+		// Optional. Default value "oauth_state".
+		StateCookie string
+
+		// StateTTL is the lifetime of the state cookie.
+		// Optional. Default value 10 minutes.
+		StateTTL time.Duration
+
+		// CookieInsecure disables the Secure flag of the state cookie, for local
+		// development over plain http only.
+		// Optional. Default value false.
+		CookieInsecure bool
+
+		// DisablePKCE disables PKCE (RFC 7636) for providers not supporting it.
+		// Optional. Default value false.
+		DisablePKCE bool
+	}
+
+	// OAuth2 provides the handlers of the authorization code flow.
+	OAuth2 struct {
+		config Config
+	}
+)
+
+// Errors of the authorization code flow
+var (
+	StateMismatchErr error = errors.New("auth: invalid oauth2 state")
+	MissingCodeErr   error = errors.New("auth: missing oauth2 code")
+)
+
+var (
+	// DefaultConfig is the default OAuth2 config.
+	DefaultConfig = Config{
+		ContextKey:  "identity",
+		StateCookie: "oauth_state",
+		StateTTL:    10 * time.Minute,
+	}
+)
+
+// New returns the OAuth2 handlers with config.
+//
+// Usage:
+//
+//	github := auth.New(auth.Config{
+//		Provider:  auth.GitHub(id, secret, "https://example.com/auth/github/callback"),
+//		OnSuccess: func(c *doris.Context, identity *auth.Identity) error { ... },
+//	})
+//	d.GET("/auth/github", github.LoginHandler())
+//	d.GET("/auth/github/callback", github.CallbackHandler())
+func New(config Config) *OAuth2 {
+	if config.Provider == nil || config.Provider.Config == nil {
+		panic("doris: oauth2 requires a provider")
+	}
+	if config.OnSuccess == nil {
+		panic("doris: oauth2 requires a success handler")
+	}
+	if config.OnError == nil {
+		config.OnError = defaultOnError
+	}
+	if config.ContextKey == "" {
+		config.ContextKey = DefaultConfig.ContextKey
+	}
+	if config.StateCookie == "" {
+		config.StateCookie = DefaultConfig.StateCookie
+	}
+	if config.StateTTL == 0 {
+		config.StateTTL = DefaultConfig.StateTTL
+	}
+	return &OAuth2{config: config}
+}
+
+// LoginHandler redirects to the authorization page of the provider.
+func (o *OAuth2) LoginHandler() doris.HandlerFunc {
+	return func(c *doris.Context) error {
+		state, err := randomString()
+		if err != nil {
+			return o.config.OnError(c, err)
+		}
+		var opts []oauth2.AuthCodeOption
+		verifier := ""
+		if !o.config.DisablePKCE {
+			if verifier, err = randomString(); err != nil {
+				return o.config.OnError(c, err)
+			}
+			opts = append(opts,
+				oauth2.SetAuthURLParam("code_challenge", codeChallenge(verifier)),
+				oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+			)
+		}
+		c.SetCookie(o.stateCookie(state+"."+verifier, int(o.config.StateTTL/time.Second)))
+		http.Redirect(c.Response, c.Request, o.config.Provider.Config.AuthCodeURL(state, opts...), http.StatusFound)
+		return nil
+	}
+}
+
+// CallbackHandler checks the state, exchanges the code for a token, fetches
+// the identity, stores it in the context and calls OnSuccess.
+func (o *OAuth2) CallbackHandler() doris.HandlerFunc {
+	return func(c *doris.Context) error {
+		identity, err := o.callback(c)
+		if err != nil {
+			return o.config.OnError(c, err)
+		}
+		c.SetParam(o.config.ContextKey, identity)
+		return o.config.OnSuccess(c, identity)
+	}
+}
+
+// callback runs the callback steps of the flow.
+func (o *OAuth2) callback(c *doris.Context) (*Identity, error) {
+	stored, _ := c.Cookie(o.config.StateCookie)
+	// The state cookie is single use
+	c.SetCookie(o.stateCookie("", -1))
+
+	if e := c.QueryParam("error"); e != "" {
+		return nil, errors.New("auth: provider error " + e)
+	}
+	parts := strings.SplitN(stored, ".", 2)
+	state := c.QueryParam("state")
+	if len(parts) != 2 || state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(parts[0])) != 1 {
+		return nil, StateMismatchErr
+	}
+	code := c.QueryParam("code")
+	if code == "" {
+		return nil, MissingCodeErr
+	}
+
+	var opts []oauth2.AuthCodeOption
+	if !o.config.DisablePKCE {
+		opts = append(opts, oauth2.SetAuthURLParam("code_verifier", parts[1]))
+	}
+	ctx := c.Request.Context()
+	token, err := o.config.Provider.Config.Exchange(ctx, code, opts...)
+	if err != nil {
+		return nil, err
+	}
+	identity, err := o.config.Provider.fetchIdentity(o.config.Provider.Config.Client(ctx, token))
+	if err != nil {
+		return nil, err
+	}
+	identity.Token = token
+	return identity, nil
+}
+
+// stateCookie returns the state cookie with the value.
+func (o *OAuth2) stateCookie(value string, maxAge int) *http.Cookie {
+	return doris.NewCookie(o.config.StateCookie, value,
+		doris.CookieMaxAge(maxAge),
+		doris.CookieSecure(!o.config.CookieInsecure),
+		doris.CookieHttpOnly(true),
+		// Lax so the cookie is sent on the top-level redirect back from the provider
+		doris.CookieSameSite(http.SameSiteLaxMode),
+	)
+}
+
+// defaultOnError renders the error as json.
+func defaultOnError(c *doris.Context, err error) error {
+	c.ErrorJson(http.StatusUnauthorized, http.StatusUnauthorized, err.Error())
+	c.Abort()
+	return err
+}
+
+// randomString returns a random url safe string.
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// codeChallenge returns the S256 PKCE challenge of the verifier.
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
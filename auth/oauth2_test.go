@@ -0,0 +1,95 @@
+// oauth2 test file
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/leaderwolfpipi/doris"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+)
+
+func TestOAuth2(t *testing.T) {
+	var verifier string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		verifier = r.Form.Get("code_verifier")
+		if r.Form.Get("code") != "good-code" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token":"access","token_type":"Bearer"}`)
+	})
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"id":42,"login":"joe","email":"joe@example.com"}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	provider := GitHub("client", "secret", "http://localhost/callback")
+	provider.Config.Endpoint = oauth2.Endpoint{AuthURL: server.URL + "/authorize", TokenURL: server.URL + "/token"}
+	provider.UserInfoURL = server.URL + "/user"
+
+	var identity *Identity
+	o := New(Config{Provider: provider, OnSuccess: func(c *doris.Context, i *Identity) error {
+		identity = c.Param("identity").(*Identity)
+		c.String(http.StatusOK, "welcome")
+		return nil
+	}})
+	d := doris.New()
+	d.GET("/login", o.LoginHandler())
+	d.GET("/callback", o.CallbackHandler())
+	serve := func(path string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		res := httptest.NewRecorder()
+		d.ServeHTTP(res, req)
+		return res
+	}
+
+	res := serve("/login", nil)
+	assert.Equal(t, http.StatusFound, res.Code)
+	location, err := url.Parse(res.Header().Get("Location"))
+	assert.NoError(t, err)
+	query := location.Query()
+	state := query.Get("state")
+	assert.NotEmpty(t, state)
+	assert.Equal(t, "S256", query.Get("code_challenge_method"))
+	cookies := res.Result().Cookies()
+	if !assert.Len(t, cookies, 1) {
+		return
+	}
+	stateCookie := cookies[0]
+	assert.True(t, stateCookie.HttpOnly)
+
+	// state mismatch and missing cookie are rejected
+	res = serve("/callback?state=forged&code=good-code", stateCookie)
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+	res = serve("/callback?state="+state+"&code=good-code", nil)
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+	res = serve("/callback?state="+state+"&code=bad-code", stateCookie)
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+
+	res = serve("/callback?state="+state+"&code=good-code", stateCookie)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, codeChallenge(verifier), query.Get("code_challenge"))
+	if assert.NotNil(t, identity) {
+		assert.Equal(t, "github", identity.Provider)
+		assert.Equal(t, "42", identity.ID)
+		assert.Equal(t, "joe", identity.Name)
+		assert.Equal(t, "joe@example.com", identity.Email)
+		assert.Equal(t, "access", identity.Token.AccessToken)
+	}
+}
@@ -0,0 +1,140 @@
+// Package auth provides handlers for the OAuth2 authorization code flow
+// with identity providers such as Google or GitHub.
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/endpoints"
+)
+
+type (
+	// Identity is the user identity returned by a provider.
+	Identity struct {
+		Provider  string                 `json:"provider"`
+		ID        string                 `json:"id"`
+		Email     string                 `json:"email,omitempty"`
+		Name      string                 `json:"name,omitempty"`
+		AvatarURL string                 `json:"avatar_url,omitempty"`
+		Raw       map[string]interface{} `json:"raw,omitempty"`
+		Token     *oauth2.Token          `json:"-"`
+	}
+
+	// Provider describes an OAuth2 identity provider.
+	Provider struct {
+		// Name of the provider, e.g. "github".
+		Name string
+
+		// Config is the OAuth2 client config of the provider.
+		Config *oauth2.Config
+
+		// UserInfoURL is fetched with the access token to read the identity.
+		UserInfoURL string
+
+		// Identity maps the user info response to the identity.
+		// Optional. Default reads the "id"/"sub", "email", "name" and "picture" fields.
+		Identity func(raw map[string]interface{}) *Identity
+	}
+)
+
+// GitHub returns the GitHub provider.
+// Scope "read:user" is requested when no scopes are given.
+func GitHub(clientID, clientSecret, redirectURL string, scopes ...string) *Provider {
+	if len(scopes) == 0 {
+		scopes = []string{"read:user", "user:email"}
+	}
+	return &Provider{
+		Name: "github",
+		Config: &oauth2.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			RedirectURL:  redirectURL,
+			Scopes:       scopes,
+			Endpoint:     endpoints.GitHub,
+		},
+		UserInfoURL: "https://api.github.com/user",
+		Identity: func(raw map[string]interface{}) *Identity {
+			identity := defaultIdentity(raw)
+			identity.AvatarURL = stringField(raw, "avatar_url")
+			if identity.Name == "" {
+				identity.Name = stringField(raw, "login")
+			}
+			return identity
+		},
+	}
+}
+
+// Google returns the Google provider.
+// Scopes "openid", "email" and "profile" are requested when no scopes are given.
+func Google(clientID, clientSecret, redirectURL string, scopes ...string) *Provider {
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "email", "profile"}
+	}
+	return &Provider{
+		Name: "google",
+		Config: &oauth2.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			RedirectURL:  redirectURL,
+			Scopes:       scopes,
+			Endpoint:     endpoints.Google,
+		},
+		UserInfoURL: "https://openidconnect.googleapis.com/v1/userinfo",
+	}
+}
+
+// fetchIdentity reads the identity of the token owner from the user info endpoint.
+func (p *Provider) fetchIdentity(client *http.Client) (*Identity, error) {
+	resp, err := client.Get(p.UserInfoURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("auth: %s user info returned status %d", p.Name, resp.StatusCode)
+	}
+	raw := map[string]interface{}{}
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, err
+	}
+	mapIdentity := p.Identity
+	if mapIdentity == nil {
+		mapIdentity = defaultIdentity
+	}
+	identity := mapIdentity(raw)
+	identity.Provider = p.Name
+	identity.Raw = raw
+	if identity.ID == "" {
+		return nil, fmt.Errorf("auth: %s user info has no id", p.Name)
+	}
+	return identity, nil
+}
+
+// defaultIdentity maps the common OpenID Connect user info fields.
+func defaultIdentity(raw map[string]interface{}) *Identity {
+	id := stringField(raw, "sub")
+	if id == "" {
+		id = stringField(raw, "id")
+	}
+	return &Identity{
+		ID:        id,
+		Email:     stringField(raw, "email"),
+		Name:      stringField(raw, "name"),
+		AvatarURL: stringField(raw, "picture"),
+	}
+}
+
+// stringField returns a string or numeric field as string.
+func stringField(raw map[string]interface{}, key string) string {
+	switch v := raw[key].(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
@@ -0,0 +1,92 @@
+package doris
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+)
+
+// 路由信息
+type RouteInfo struct {
+	Method  string // 请求方法
+	Path    string // 路由模板，如/users/:id
+	Handler string // 主处理函数名称
+}
+
+// 启动信息使用的终端颜色
+const (
+	colorGreen  = "\033[0;32m"
+	colorBlue   = "\033[0;34m"
+	colorYellow = "\033[0;33m"
+	colorRed    = "\033[0;31m"
+	colorCyan   = "\033[0;36m"
+	colorReset  = "\033[0m"
+)
+
+// 获取已注册的路由列表，按注册顺序排列
+func (doris *Doris) Routes() []RouteInfo {
+	routes := make([]RouteInfo, len(doris.routes))
+	copy(routes, doris.routes)
+	return routes
+}
+
+// 获取启动信息的输出位置
+func (doris *Doris) startupOutput() io.Writer {
+	if doris.StartupOutput == nil {
+		return os.Stdout
+	}
+	return doris.StartupOutput
+}
+
+// 输出启动信息
+func (doris *Doris) startupf(format string, a ...interface{}) {
+	fmt.Fprintf(doris.startupOutput(), format, a...)
+}
+
+// 给启动信息着色，DisableColor时原样返回
+func (doris *Doris) colorize(color, s string) string {
+	if doris.DisableColor {
+		return s
+	}
+	return color + s + colorReset
+}
+
+// 打印banner，设置了Banner时替换默认banner
+func (doris *Doris) printBanner() {
+	if doris.Banner != "" {
+		io.WriteString(doris.startupOutput(), doris.Banner)
+		return
+	}
+	doris.startupf(banner, Version, website)
+}
+
+// 以表格形式打印已注册的路由
+func (doris *Doris) printRoutes() {
+	if len(doris.routes) == 0 {
+		return
+	}
+	w := tabwriter.NewWriter(doris.startupOutput(), 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "METHOD\tPATH\tHANDLER")
+	for _, r := range doris.routes {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", doris.colorize(methodColor(r.Method), r.Method), r.Path, r.Handler)
+	}
+	w.Flush()
+	fmt.Fprintln(doris.startupOutput())
+}
+
+// 获取请求方法对应的颜色
+func methodColor(method string) string {
+	switch method {
+	case "GET", "HEAD":
+		return colorBlue
+	case "POST":
+		return colorGreen
+	case "PUT", "PATCH":
+		return colorYellow
+	case "DELETE":
+		return colorRed
+	default:
+		return colorCyan
+	}
+}
@@ -0,0 +1,50 @@
+package doris
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func listUsers(c *Context) error { return nil }
+
+func TestRoutes(t *testing.T) {
+	d := New()
+	d.GET("/users", listUsers)
+	d.POST("/users/:id", listUsers)
+
+	routes := d.Routes()
+	assert.Equal(t, 2, len(routes))
+	assert.Equal(t, RouteInfo{Method: "GET", Path: "/users", Handler: "github.com/leaderwolfpipi/doris.listUsers"}, routes[0])
+	assert.Equal(t, "/users/:id", routes[1].Path)
+}
+
+func TestStartupRouteTable(t *testing.T) {
+	var out bytes.Buffer
+	d := New()
+	d.Debug = true
+	d.DisableColor = true
+	d.StartupOutput = &out
+	d.ShowBanner = true
+	d.Banner = "my service\n"
+	d.GET("/users", listUsers)
+
+	assert.Nil(t, d.startup())
+	lines := strings.Split(out.String(), "\n")
+	assert.Equal(t, "my service", lines[0])
+	assert.Equal(t, "METHOD  PATH    HANDLER", lines[1])
+	assert.Equal(t, "GET     /users  github.com/leaderwolfpipi/doris.listUsers", lines[2])
+}
+
+func TestStartupColor(t *testing.T) {
+	var out bytes.Buffer
+	d := New()
+	d.Debug = true
+	d.StartupOutput = &out
+	d.GET("/users", listUsers)
+
+	assert.Nil(t, d.startup())
+	assert.Contains(t, out.String(), colorBlue+"GET"+colorReset)
+}
@@ -0,0 +1,76 @@
+// 参数绑定注册器
+// 按请求的Content-Type选择绑定函数，供c.Bind透明调用
+package doris
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// 常用的MIME类型
+const (
+	MIMEApplicationJSON        = "application/json"
+	MIMEApplicationProblemJSON = "application/problem+json"
+	MIMEApplicationXML         = "application/xml"
+	MIMETextXML                = "text/xml"
+	MIMEApplicationForm        = "application/x-www-form-urlencoded"
+	MIMEMultipartForm          = "multipart/form-data"
+	MIMEProtobuf               = "application/x-protobuf"
+)
+
+// 参数绑定函数
+type BindFunc func(*http.Request, interface{}) error
+
+// 注册Content-Type对应的绑定函数
+// 已存在的绑定函数会被覆盖
+func (doris *Doris) RegisterBinder(contentType string, bind BindFunc) {
+	assert1(contentType != "", "content type can not be empty")
+	assert1(bind != nil, "bind function can not be nil")
+	if doris.binders == nil {
+		doris.binders = make(map[string]BindFunc)
+	}
+	doris.binders[contentType] = bind
+}
+
+// 获取Content-Type对应的绑定函数
+func (doris *Doris) Binder(contentType string) (BindFunc, bool) {
+	bind, ok := doris.binders[contentType]
+	return bind, ok
+}
+
+// 注册默认的绑定函数
+func (doris *Doris) registerDefaultBinders() {
+	doris.RegisterBinder(MIMEApplicationJSON, bindJSON)
+	doris.RegisterBinder(MIMEApplicationXML, bindXML)
+	doris.RegisterBinder(MIMETextXML, bindXML)
+	doris.RegisterBinder(MIMEApplicationForm, bindForm)
+	doris.RegisterBinder(MIMEMultipartForm, bindForm)
+	doris.RegisterBinder(MIMEProtobuf, bindProtobuf)
+}
+
+// 绑定json格式的请求体
+func bindJSON(req *http.Request, obj interface{}) error {
+	return json.NewDecoder(req.Body).Decode(obj)
+}
+
+// 绑定xml格式的请求体
+func bindXML(req *http.Request, obj interface{}) error {
+	return xml.NewDecoder(req.Body).Decode(obj)
+}
+
+// 绑定protobuf格式的请求体
+func bindProtobuf(req *http.Request, obj interface{}) error {
+	msg, ok := obj.(proto.Message)
+	if !ok {
+		return ProtobufTypeErr
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	return proto.Unmarshal(body, msg)
+}
@@ -0,0 +1,142 @@
+package doris
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterBinder(t *testing.T) {
+	d := New()
+	d.RegisterBinder("text/csv", func(req *http.Request, obj interface{}) error {
+		rows, err := csv.NewReader(req.Body).ReadAll()
+		if err != nil {
+			return err
+		}
+		*obj.(*[][]string) = rows
+		return nil
+	})
+	d.POST("/import", func(c *Context) error {
+		var rows [][]string
+		if err := c.Bind(&rows); err != nil {
+			return err
+		}
+		// 绑定之后请求体仍可读取
+		body, err := c.BodyBytes()
+		if err != nil {
+			return err
+		}
+		c.Json(http.StatusOK, D{"rows": rows, "size": len(body)})
+		return nil
+	})
+	serve := func(contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/import", strings.NewReader(body))
+		req.Header.Set(HeaderContentType, contentType)
+		res := httptest.NewRecorder()
+		d.ServeHTTP(res, req)
+		return res
+	}
+
+	// Content-Type的参数不参与匹配
+	res := serve("text/csv; charset=utf-8", "id,name\n1,doris\n")
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.JSONEq(t, `{"rows":[["id","name"],["1","doris"]],"size":16}`, res.Body.String())
+
+	res = serve("application/yaml", "id: 1")
+	assert.Equal(t, http.StatusUnsupportedMediaType, res.Code)
+
+	// 覆盖默认的绑定函数
+	_, ok := d.Binder(MIMEApplicationJSON)
+	assert.True(t, ok)
+	d.RegisterBinder(MIMEApplicationJSON, func(req *http.Request, obj interface{}) error {
+		var row []string
+		if err := json.NewDecoder(req.Body).Decode(&row); err != nil {
+			return err
+		}
+		*obj.(*[][]string) = [][]string{row}
+		return nil
+	})
+	res = serve(MIMEApplicationJSON, `["1","doris"]`)
+	assert.JSONEq(t, `{"rows":[["1","doris"]],"size":13}`, res.Body.String())
+
+	_, ok = d.Binder("application/yaml")
+	assert.False(t, ok)
+	assert.Panics(t, func() { d.RegisterBinder("", bindJSON) })
+	assert.Panics(t, func() { d.RegisterBinder("text/csv", nil) })
+}
+
+func TestBindQueryForReadMethods(t *testing.T) {
+	type filter struct {
+		Name string `param:"name"`
+	}
+	d := New()
+	handler := func(c *Context) error {
+		var f filter
+		if err := c.Bind(&f); err != nil {
+			return err
+		}
+		c.String(http.StatusOK, f.Name)
+		return nil
+	}
+	d.GET("/", handler)
+	d.DELETE("/", handler)
+
+	for _, method := range []string{http.MethodGet, http.MethodDelete} {
+		// 读取类请求忽略Content-Type和请求体
+		req := httptest.NewRequest(method, "/?name=doris", strings.NewReader(`{"name":"body"}`))
+		req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+		res := httptest.NewRecorder()
+		d.ServeHTTP(res, req)
+		assert.Equal(t, "doris", res.Body.String(), method)
+	}
+}
+
+func TestBindProtobuf(t *testing.T) {
+	body, err := proto.Marshal(&wrappers.StringValue{Value: "doris"})
+	assert.Nil(t, err)
+
+	d := New()
+	d.POST("/", func(c *Context) error {
+		var msg wrappers.StringValue
+		if err := c.Bind(&msg); err != nil {
+			return err
+		}
+		// 请求体已缓存，可以再次绑定
+		var again wrappers.StringValue
+		if err := c.BindProtobuf(&again); err != nil {
+			return err
+		}
+		c.String(http.StatusOK, msg.Value+"/"+again.Value)
+		return nil
+	})
+	d.PUT("/", func(c *Context) error {
+		var obj struct{ Value string }
+		return c.Bind(&obj)
+	})
+	serve := func(method string, body []byte) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/", strings.NewReader(string(body)))
+		req.Header.Set(HeaderContentType, MIMEProtobuf)
+		res := httptest.NewRecorder()
+		d.ServeHTTP(res, req)
+		return res
+	}
+
+	res := serve(http.MethodPost, body)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "doris/doris", res.Body.String())
+
+	res = serve(http.MethodPost, []byte{0xff, 0xff})
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+
+	// 绑定目标不是proto.Message
+	res = serve(http.MethodPut, body)
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+	assert.Contains(t, res.Body.String(), `"code":10103`)
+}
@@ -2,14 +2,24 @@ package doris
 
 import (
 	// "fmt"
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"io"
+	"io/ioutil"
 	"math"
+	"mime"
+	"net"
 	"net/http"
 	"net/url"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
-	"github.com/leaderwolfpipi/binding"
+	"github.com/golang/protobuf/proto"
 	"github.com/leaderwolfpipi/render"
 )
 
@@ -17,6 +27,8 @@ import (
 // 1. 负责在各中间件中传递参数；
 // 2. 负责整个执行流程的控制；
 // 3. 负责请求参数的验证以及响应结构的渲染（比如json）
+// 注意：Context由对象池复用，仅在处理链执行期间有效，
+// 处理函数返回后不得继续持有，在goroutine中使用前需调用c.Copy()
 type Context struct {
 	Response  *Response              // 用于内部操作响应对象
 	Request   *http.Request          // 请求对象
@@ -28,9 +40,22 @@ type Context struct {
 	Params    map[string]interface{} // 保存同一个context下的参数（key/value）
 	accepted  []string               // 保存被接受的内容协商类型
 	lock      sync.RWMutex           // 上下文锁
-	// errors   errorMsgs     // 保存同一个context下的所有中间件和主处理函数的错误信息
+	Errors    errorMsgs              // 保存同一个context下的所有中间件和主处理函数的错误信息
+	body      []byte                 // 缓存的请求体
+	bodyRead  bool                   // 请求体是否已被缓存
+	bodyErr   error                  // 读取请求体的错误，请求体已被消费，后续读取返回同一错误
+	pNames    Params                 // 匹配到的路由参数名列表
+	requestID string                 // 请求ID
+	locale    string                 // SetLocale设置的请求语言
+	trace     *TraceContext          // 链路上下文，首次使用时解析
+	timeMain  bool                   // 是否统计主处理函数的耗时
+	mainTime  time.Duration          // 主处理函数的耗时
 }
 
+// Context实现了标准库的context.Context接口
+// 可直接传递给数据库、RPC等调用
+var _ context.Context = &Context{}
+
 // 单个url参数包含key/value
 type KeyValue struct {
 	Key   string
@@ -44,6 +69,61 @@ type KeyValues []KeyValue
 // 当出现异常时直接退出处理链
 const abortIndex int8 = math.MaxInt8 / 2
 
+// 复位上下文供对象池复用
+// Response和Request由调用方在复位前设置
+func (c *Context) reset() {
+	c.handlers = nil
+	c.urlParams = c.urlParams[:0]
+	c.index = -1
+	c.fullPath = ""
+	c.Params = nil
+	c.accepted = nil
+	c.Errors = c.Errors[:0]
+	c.body = nil
+	c.bodyRead = false
+	c.bodyErr = nil
+	c.pNames = nil
+	c.requestID = ""
+	c.locale = ""
+	c.trace = nil
+	c.timeMain = false
+	c.mainTime = 0
+}
+
+// 复制当前上下文的只读快照
+// 快照不持有原始的ResponseWriter（写入会被丢弃）且处理链已终止，可以安全地传递给goroutine使用
+// 例如：go audit(c.Copy())
+func (c *Context) Copy() *Context {
+	cp := &Context{
+		Response: &Response{
+			size:   c.Response.size,
+			status: c.Response.status,
+			Writer: newSnapshotWriter(c.Response.Header()),
+		},
+		Request:   c.Request,
+		index:     abortIndex,
+		fullPath:  c.fullPath,
+		Doris:     c.Doris,
+		body:      c.body,
+		bodyRead:  c.bodyRead,
+		bodyErr:   c.bodyErr,
+		requestID: c.requestID,
+		locale:    c.locale,
+		trace:     c.trace,
+	}
+	if c.Params != nil {
+		cp.Params = make(map[string]interface{}, len(c.Params))
+		for k, v := range c.Params {
+			cp.Params[k] = v
+		}
+	}
+	cp.pNames = make(Params, len(c.pNames))
+	copy(cp.pNames, c.pNames)
+	cp.Errors = make(errorMsgs, len(c.Errors))
+	copy(cp.Errors, c.Errors)
+	return cp
+}
+
 /************************************/
 /******** 中间件相关 ******************/
 /************************************/
@@ -55,11 +135,41 @@ func (c *Context) Next() {
 	c.index++
 	// 循环逐个执行注册的方法
 	for c.index < int8(len(c.handlers)) {
-		c.handlers[c.index](c)
+		if c.timeMain && int(c.index) == len(c.handlers)-1 {
+			start := time.Now()
+			err := c.handlers[c.index](c)
+			c.mainTime = time.Since(start)
+			if err != nil {
+				c.handleError(err)
+			}
+		} else if err := c.handlers[c.index](c); err != nil {
+			c.handleError(err)
+		}
 		c.index++
 	}
 }
 
+// 开启主处理函数（处理链最后一个函数）的耗时统计，供性能分析中间件区分中间件和主处理函数的耗时
+func (c *Context) TimeMainHandler() {
+	c.timeMain = true
+}
+
+// 获取主处理函数的耗时，需先调用TimeMainHandler
+func (c *Context) MainHandlerDuration() time.Duration {
+	return c.mainTime
+}
+
+// 处理函数返回的错误：记录到c.Errors并终止处理链，交由HTTPErrorHandler输出响应
+func (c *Context) handleError(err error) {
+	c.Error(err)
+	c.Abort()
+	if c.Doris != nil && c.Doris.HTTPErrorHandler != nil {
+		c.Doris.HTTPErrorHandler(err, c)
+		return
+	}
+	DefaultHTTPErrorHandler(err, c)
+}
+
 // 终止处理链
 func (c *Context) Abort() {
 	c.index = abortIndex
@@ -73,21 +183,95 @@ func (c *Context) AbortWithStatus(code int) {
 	c.Abort()
 }
 
+// 记录处理过程中的错误到c.Errors
+// 默认为私有错误，可通过返回值修改类型和元数据：
+// c.Error(err).SetType(doris.ErrorTypePublic).SetMeta(doris.D{"uid": uid})
+func (c *Context) Error(err error) *Error {
+	if err == nil {
+		panic("err is nil")
+	}
+	parsedError, ok := err.(*Error)
+	if !ok {
+		parsedError = &Error{
+			Err:  err,
+			Type: ErrorTypePrivate,
+		}
+	}
+	if parsedError.Handler == "" {
+		parsedError.Handler = c.HandlerName()
+	}
+	c.Errors = append(c.Errors, parsedError)
+	return parsedError
+}
+
+// 获取当前正在执行的处理函数名称
+func (c *Context) HandlerName() string {
+	if c.index < 0 || int(c.index) >= len(c.handlers) {
+		return ""
+	}
+	return nameOfFunction(c.handlers[c.index])
+}
+
+// 获取路由主处理函数（处理链最后一个函数）的名称
+func (c *Context) MainHandlerName() string {
+	if len(c.handlers) == 0 {
+		return ""
+	}
+	return nameOfFunction(c.handlers[len(c.handlers)-1])
+}
+
+// 获取匹配到的路由模板，如/users/:id
+// 未匹配到路由时返回空字符串
+func (c *Context) FullPath() string {
+	return c.fullPath
+}
+
 /************************************/
 /******** 参数绑定/获取相关 ************/
 /************************************/
 // 获取GET方法获取的参数
+// 只做绑定不检查validate标签，需要校验时使用BindAndValidate
 func (c *Context) Query(obj interface{}) error {
 	// 获取query参数
-	b := binding.QueryBind{}
-	return b.Bind(c.Request, obj)
+	return bindQuery(c.Request, obj)
+}
+
+// 根据请求方法和Content-Type自动选择绑定函数
+// GET、DELETE、HEAD请求绑定查询参数，其余请求使用Doris注册的绑定函数
+// 除multipart表单外请求体会被缓存，绑定之后仍可再次读取
+func (c *Context) Bind(obj interface{}) error {
+	method := c.Request.Method
+	if method == http.MethodGet || method == http.MethodDelete || method == http.MethodHead {
+		return c.Query(obj)
+	}
+	contentType := c.ContentType()
+	bind, ok := c.Doris.Binder(contentType)
+	if !ok {
+		return UnsupportedMediaTypeErr
+	}
+	// 文件上传的请求体通常较大不做缓存
+	if contentType != MIMEMultipartForm {
+		if _, err := c.BodyBytes(); err != nil {
+			return err
+		}
+	}
+	return bind(c.Request, obj)
+}
+
+// 绑定protobuf格式的请求体
+func (c *Context) BindProtobuf(msg proto.Message) error {
+	body, err := c.BodyBytes()
+	if err != nil {
+		return err
+	}
+	return proto.Unmarshal(body, msg)
 }
 
 // 获取POST方法的参数
+// 只做绑定不检查validate标签，需要校验时使用BindAndValidate
 func (c *Context) Form(param interface{}) error {
-	// 获取query参数
-	b := binding.FormBind{}
-	return b.Bind(c.Request, param)
+	// 获取表单参数
+	return bindForm(c.Request, param)
 }
 
 // 获取单个的查询参数
@@ -97,6 +281,11 @@ func (c *Context) QueryParam(param string) string {
 	return q.Get(param)
 }
 
+// 获取全部的查询参数
+func (c *Context) QueryParams() url.Values {
+	return c.Request.URL.Query()
+}
+
 // 获取GET方法获取的参数带默认值
 func (c *Context) DefaultQuery(param string, def interface{}) string {
 	// 获取query参数不存在则返回默认值
@@ -130,10 +319,70 @@ func (c *Context) DefaultFormParm(param string, def interface{}) string {
 	return f.Get(param)
 }
 
+// 读取并缓存请求体
+// 读取之后会重置Request.Body，因此请求体可以被多次读取（参数绑定、签名校验、日志等）
+// 超过Doris.MaxBodySize时返回BodyTooLargeErr
+func (c *Context) BodyBytes() ([]byte, error) {
+	if !c.bodyRead {
+		if c.Request.Body != nil {
+			maxSize := defaultMaxBodySize
+			if c.Doris != nil && c.Doris.MaxBodySize > 0 {
+				maxSize = c.Doris.MaxBodySize
+			}
+			// 多读取一个字节用于判断是否超出限制
+			body, err := ioutil.ReadAll(io.LimitReader(c.Request.Body, maxSize+1))
+			c.Request.Body.Close()
+			if err == nil && int64(len(body)) > maxSize {
+				err = BodyTooLargeErr
+			}
+			if err != nil {
+				c.bodyRead = true
+				c.bodyErr = err
+				return nil, err
+			}
+			c.body = body
+		}
+		c.bodyRead = true
+	}
+	if c.bodyErr != nil {
+		return nil, c.bodyErr
+	}
+	// 重置请求体供后续处理函数读取
+	c.Request.Body = ioutil.NopCloser(bytes.NewReader(c.body))
+	return c.body, nil
+}
+
 // 处理静态文件方法
+// 支持Range/If-Range断点续传以及If-Modified-Since等条件请求
 func (c *Context) File(filepath string) {
-	//
+	http.ServeFile(c.Response, c.Request, filepath)
+}
+
+// 输出可随机读取的内容
+// 与File一样支持Range请求，适用于非本地文件的内容（如内存中的数据）
+func (c *Context) ServeContent(name string, modtime time.Time, content io.ReadSeeker) {
+	http.ServeContent(c.Response, c.Request, name, modtime, content)
+}
 
+// 以附件形式输出reader中的数据
+// 适用于动态生成的下载内容（zip流、对象存储等），size<0表示长度未知
+func (c *Context) AttachmentReader(r io.Reader, name string, size int64) error {
+	header := c.Response.Header()
+	header.Set(HeaderContentDisposition, contentDisposition("attachment", name))
+	contentType := mime.TypeByExtension(filepath.Ext(name))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	header.Set(HeaderContentType, contentType)
+	if size >= 0 {
+		header.Set(HeaderContentLength, strconv.FormatInt(size, 10))
+	}
+	c.Status(http.StatusOK)
+	if c.Request.Method == http.MethodHead {
+		return nil
+	}
+	_, err := io.Copy(c.Response, r)
+	return err
 }
 
 // 根据参数名获取参数值
@@ -141,6 +390,23 @@ func (c *Context) Param(name string) interface{} {
 	return c.Params[name]
 }
 
+// 获取匹配到的路由参数名列表
+// 不包含通过SetParam设置的参数
+func (c *Context) ParamNames() []string {
+	names := make([]string, len(c.pNames))
+	copy(names, c.pNames)
+	return names
+}
+
+// 获取路由参数值列表顺序与ParamNames一致
+func (c *Context) ParamValues() []interface{} {
+	values := make([]interface{}, len(c.pNames))
+	for i, name := range c.pNames {
+		values[i] = c.Params[name]
+	}
+	return values
+}
+
 // 设置k-v到context中
 func (c *Context) SetParam(name string, value interface{}) {
 	if c.Params == nil {
@@ -149,31 +415,200 @@ func (c *Context) SetParam(name string, value interface{}) {
 	c.Params[name] = value
 }
 
+/************************************/
+/******** 请求信息判断相关 ************/
+/************************************/
+// 判断是否为websocket升级请求
+func (c *Context) IsWebSocket() bool {
+	upgrade := c.Request.Header.Get(HeaderUpgrade)
+	return strings.EqualFold(upgrade, "websocket") &&
+		strings.Contains(strings.ToLower(c.Request.Header.Get("Connection")), "upgrade")
+}
+
+// 判断是否为https请求（仅指当前连接本身）
+func (c *Context) IsTLS() bool {
+	return c.Request.TLS != nil
+}
+
+// 获取双向TLS认证中已验证的客户端证书
+// 返回已验证证书链的叶子证书，未提供或未经验证时返回nil
+func (c *Context) ClientCertificate() *x509.Certificate {
+	state := c.Request.TLS
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return state.VerifiedChains[0][0]
+}
+
+// 获取客户端发送的证书，证书未必经过验证，不能用于认证
+func (c *Context) PeerCertificate() *x509.Certificate {
+	state := c.Request.TLS
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return nil
+	}
+	return state.PeerCertificates[0]
+}
+
+// 判断是否为ajax请求
+func (c *Context) IsAjax() bool {
+	return c.Request.Header.Get(HeaderXRequestedWith) == "XMLHttpRequest"
+}
+
+// 获取请求的Content-Type（不包含charset等参数）
+func (c *Context) ContentType() string {
+	contentType := c.Request.Header.Get(HeaderContentType)
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(contentType))
+}
+
+// 获取请求的协议类型http或https
+// 依次检查TLS连接和代理服务设置的转发头
+func (c *Context) Scheme() string {
+	if c.IsTLS() {
+		return "https"
+	}
+	header := c.Request.Header
+	if scheme := header.Get(HeaderXForwardedProto); scheme != "" {
+		return strings.ToLower(strings.TrimSpace(strings.Split(scheme, ",")[0]))
+	}
+	if scheme := header.Get(HeaderXForwardedProtocol); scheme != "" {
+		return strings.ToLower(scheme)
+	}
+	if ssl := header.Get(HeaderXForwardedSsl); ssl == "on" {
+		return "https"
+	}
+	if scheme := header.Get(HeaderXUrlScheme); scheme != "" {
+		return strings.ToLower(scheme)
+	}
+	return "http"
+}
+
+// 获取客户端真实IP
+// 依次检查X-Forwarded-For、X-Real-IP头和连接的远端地址
+func (c *Context) RealIP() string {
+	if ip := c.Request.Header.Get(HeaderXForwardedFor); ip != "" {
+		return strings.TrimSpace(strings.Split(ip, ",")[0])
+	}
+	if ip := c.Request.Header.Get(HeaderXRealIP); ip != "" {
+		return ip
+	}
+	ip, _, err := net.SplitHostPort(c.Request.RemoteAddr)
+	if err != nil {
+		return c.Request.RemoteAddr
+	}
+	return ip
+}
+
+// 获取请求ID
+// 优先返回RequestID中间件设置的ID，其次读取响应头和请求头中携带的ID
+func (c *Context) RequestID() string {
+	if c.requestID != "" {
+		return c.requestID
+	}
+	if id := c.Response.Header().Get(HeaderXRequestID); id != "" {
+		return id
+	}
+	return c.Request.Header.Get(HeaderXRequestID)
+}
+
+// 设置当前请求的ID，通常由RequestID中间件调用
+func (c *Context) SetRequestID(id string) {
+	c.requestID = id
+}
+
+// 获取携带请求上下文信息的日志记录器
+func (c *Context) Logger() *RequestLogger {
+	return newRequestLogger(c)
+}
+
 /************************************/
 /******** 响应渲染相关 ****************/
 /************************************/
 // 渲染函数
 func (c *Context) render(code int, r render.IRender) {
-	r.WriteContentType(c.Response.Writer) // 设置contentType
-	c.Status(code)                        // 设置status码
-	if !bodyAllowedCode(code) {           // 非允许的code直接返回
-		c.Response.WriteHeaderNow()
+	r.WriteContentType(c.Response) // 设置contentType
+	c.Status(code)                 // 设置status码
+	if !bodyAllowedCode(code) {    // 非允许的code直接返回
 		return
 	}
-	err := r.Render(c.Response.Writer)
+	err := r.Render(c.Response)
 	if err != nil {
 		panic(err)
 	}
 }
 
 // 输出json格式
+// 开启Doris.SecureJsonArrays时按SecureJson输出，编码结果为顶层数组时添加前缀
+// 按编码结果判断，自定义MarshalJSON输出的数组同样会添加前缀
 func (c *Context) Json(code int, obj interface{}) {
-	c.render(code, render.Json{Data: obj})
+	if c.Doris.SecureJsonArrays {
+		c.SecureJson(code, obj)
+		return
+	}
+	c.render(code, jsonRender{Data: obj, escapeHTML: true})
+}
+
+// 输出secureJson格式
+// 顶层为数组时添加Doris.SecureJsonPrefix前缀，防止旧版浏览器的json劫持
+func (c *Context) SecureJson(code int, obj interface{}) {
+	c.render(code, secureJson{Prefix: c.Doris.SecureJsonPrefix, Data: obj})
+}
+
+// json数组流式输出的迭代器
+// ok返回false表示数据已经全部输出
+type JsonIterator func() (item interface{}, ok bool, err error)
+
+// 流式输出json数组的刷新间隔（元素个数）
+const jsonStreamFlushSize = 100
+
+// 流式输出json数组
+// 逐个元素编码写入响应并定期刷新，适用于大数据量导出
+// 迭代出错时直接返回错误且不输出结尾的"]"，客户端可据此判断数据不完整
+func (c *Context) JsonStream(code int, next JsonIterator) error {
+	writeContentType(c.Response, "application/json; charset=utf-8")
+	c.Status(code)
+	if _, err := c.Response.WriteString("["); err != nil {
+		return err
+	}
+	enc := json.NewEncoder(c.Response)
+	flusher, canFlush := c.Response.Writer.(http.Flusher)
+	for count := 0; ; count++ {
+		item, ok, err := next()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		if count > 0 {
+			if _, err := c.Response.WriteString(","); err != nil {
+				return err
+			}
+		}
+		if err := enc.Encode(item); err != nil {
+			return err
+		}
+		if canFlush && (count+1)%jsonStreamFlushSize == 0 {
+			flusher.Flush()
+		}
+	}
+	_, err := c.Response.WriteString("]")
+	return err
+}
+
+// 流式输出通道中的数据为json数组，通道关闭时结束
+func (c *Context) JsonStreamChan(code int, ch <-chan interface{}) error {
+	return c.JsonStream(code, func() (interface{}, bool, error) {
+		item, ok := <-ch
+		return item, ok, nil
+	})
 }
 
 // 输出pureJson格式
 func (c *Context) PureJson(code int, obj interface{}) {
-	c.render(code, render.PureJson{Data: obj})
+	c.render(code, jsonRender{Data: obj})
 }
 
 // 输出IndentJson格式
@@ -188,17 +623,35 @@ func (c *Context) Jsonp(code int, callback string, obj interface{}) {
 
 // 输出字符串格式
 func (c *Context) String(code int, format string, values ...interface{}) {
-	c.render(code, render.String{Format: format, Data: values})
+	c.render(code, stringRender{Format: format, Data: values})
 }
 
 // 输出xml格式
 func (c *Context) Xml(code int, obj interface{}) {
-	c.render(code, render.Xml{Data: obj})
+	c.render(code, xmlRender{Data: obj})
 }
 
-// 输出html格式
-func (c *Context) Html() {
+// 输出html字符串
+func (c *Context) Html(code int, html string) {
+	c.render(code, htmlString{html: html})
+}
+
+// 使用Doris.HTMLRender渲染页面模板
+// 页面使用HTMLRender.Layout配置的默认布局
+func (c *Context) Render(code int, name string, data interface{}) {
+	assert1(c.Doris.HTMLRender != nil, "doris: HTMLRender is not configured")
+	c.RenderWithLayout(code, name, c.Doris.HTMLRender.Layout, data)
+}
 
+// 使用指定的布局渲染页面模板，layout为空表示不使用布局
+func (c *Context) RenderWithLayout(code int, name, layout string, data interface{}) {
+	assert1(c.Doris.HTMLRender != nil, "doris: HTMLRender is not configured")
+	c.render(code, htmlRender{
+		renderer: c.Doris.HTMLRender,
+		name:     name,
+		layout:   layout,
+		data:     data,
+	})
 }
 
 // 检查传入的status是否是http包允许的
@@ -218,9 +671,9 @@ func bodyAllowedCode(code int) bool {
 // 设置响应头状态码行
 func (c *Context) Status(code int) {
 	// 设置封装后的status
-	c.Response.status = code
+	c.Response.WriteHeader(code)
 	// 写实际响应头status
-	c.Response.Writer.WriteHeader(code)
+	c.Response.WriteHeaderNow()
 }
 
 /************************************/
@@ -248,55 +701,29 @@ func (c *Context) SetRequestHeader(key, value string) {
 
 // 添加cookie信息
 // 响应阶段设置set-cookie头信息
-// 请求阶段自动携带Cookie头信息到服务端
-func (c *Context) SetCookie(cookieParams map[string]interface{}) {
-	// 根据字典传递的参数判断，不存在的设置默认值
-	var (
-		name     string
-		value    string
-		maxAge   int
-		path     string
-		domain   string
-		secure   bool
-		httpOnly bool
-		isClient bool // 是否http作为客户端添加cookie
-	)
-	value, _ = cookieParams["value"].(string)
-	name, _ = cookieParams["name"].(string)
-	maxAge, _ = cookieParams["maxAge"].(int)
-	path, _ = cookieParams["path"].(string)
-	domain, _ = cookieParams["domain"].(string)
-	secure, _ = cookieParams["secure"].(bool)
-	httpOnly, _ = cookieParams["httpOnly"].(bool)
-	isClient, _ = cookieParams["isClient"].(bool)
-	if name == "" {
+// 可配合NewCookie构建器使用：
+// c.SetCookie(doris.NewCookie("sid", "xxx", doris.CookieHttpOnly(true)))
+func (c *Context) SetCookie(cookie *http.Cookie) {
+	if cookie == nil || cookie.Name == "" {
 		return
 	}
-	if path == "" {
-		path = "/"
-	}
-	// 设置cookie值
-	var cookie *http.Cookie = &http.Cookie{
-		Name:     name,
-		Value:    url.QueryEscape(value),
-		MaxAge:   maxAge,
-		Path:     path,
-		Domain:   domain,
-		Secure:   secure,
-		HttpOnly: httpOnly,
-	}
-	if isClient {
-		// 客户端设置
-		c.Request.AddCookie(cookie)
-	} else {
-		// 服务端设置
-		// http.SetCookie(c.Response.Writer, cookie)
-		// 或者可以通过header方法实现
-		// c.SetResponseHeader("Set-Cookie", cookie.String())
-		// 需要将空格转换为urlencode的形式
-		ck := strings.Replace(cookie.String(), " ", "%20", -1)
-		c.SetResponseHeader("Set-Cookie", ck)
+	if cookie.Path == "" {
+		// 在副本上设置默认路径，不修改调用方的cookie
+		copied := *cookie
+		copied.Path = "/"
+		cookie = &copied
 	}
+	// 使用Add而非Set以支持同一响应写入多个cookie
+	http.SetCookie(c.Response.Writer, cookie)
+}
+
+// 请求阶段添加cookie信息
+// 通常用于http作为客户端转发请求时携带Cookie头
+func (c *Context) SetRequestCookie(cookie *http.Cookie) {
+	if cookie == nil || cookie.Name == "" {
+		return
+	}
+	c.Request.AddCookie(cookie)
 }
 
 // 获取请求中已设置的cookie信息
@@ -311,6 +738,53 @@ func (c *Context) Cookie(key string) (val string, err error) {
 	return cookieVal.Value, nil
 }
 
+// 获取请求中携带的全部cookie信息
+func (c *Context) Cookies() []*http.Cookie {
+	return c.Request.Cookies()
+}
+
+/************************************/
+/******** context.Context接口 ********/
+/************************************/
+// 返回请求上下文的截止时间
+func (c *Context) Deadline() (deadline time.Time, ok bool) {
+	if c.Request == nil {
+		return
+	}
+	return c.Request.Context().Deadline()
+}
+
+// 返回请求上下文的结束通道
+// 客户端断开或者服务关闭时通道被关闭
+func (c *Context) Done() <-chan struct{} {
+	if c.Request == nil {
+		return nil
+	}
+	return c.Request.Context().Done()
+}
+
+// 返回请求上下文结束的原因
+func (c *Context) Err() error {
+	if c.Request == nil {
+		return nil
+	}
+	return c.Request.Context().Err()
+}
+
+// 根据key获取值
+// 字符串类型的key优先从Params中查找，其次从请求上下文中查找
+func (c *Context) Value(key interface{}) interface{} {
+	if name, ok := key.(string); ok {
+		if value, exists := c.Params[name]; exists {
+			return value
+		}
+	}
+	if c.Request == nil {
+		return nil
+	}
+	return c.Request.Context().Value(key)
+}
+
 /************************************/
 /******** 内容协商相关 ****************/
 /************************************/
@@ -0,0 +1,515 @@
+package doris
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBodyBytes(t *testing.T) {
+	d := New()
+	d.MaxBodySize = 8
+	d.POST("/", func(c *Context) error {
+		// 请求体可以被多次读取
+		body, err := c.BodyBytes()
+		assert.Nil(t, err)
+		again, err := c.BodyBytes()
+		assert.Nil(t, err)
+		assert.Equal(t, body, again)
+		raw, err := ioutil.ReadAll(c.Request.Body)
+		assert.Nil(t, err)
+		assert.Equal(t, body, raw)
+		c.String(http.StatusOK, string(body))
+		return nil
+	})
+	d.PUT("/", func(c *Context) error {
+		_, err := c.BodyBytes()
+		assert.Equal(t, BodyTooLargeErr, err)
+		// 请求体已被消费，再次读取返回相同的错误
+		_, err = c.BodyBytes()
+		assert.Equal(t, BodyTooLargeErr, err)
+		return err
+	})
+
+	res := httptest.NewRecorder()
+	d.ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("12345678")))
+	assert.Equal(t, "12345678", res.Body.String())
+
+	res = httptest.NewRecorder()
+	d.ServeHTTP(res, httptest.NewRequest(http.MethodPut, "/", strings.NewReader("123456789")))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, res.Code)
+}
+
+func TestQueryFormBindOnly(t *testing.T) {
+	type search struct {
+		Q    string `param:"q" validate:"required,min=3"`
+		Page int    `param:"page" validate:"min=1"`
+	}
+	d := New()
+	handler := func(c *Context) error {
+		var s search
+		bind := c.Query
+		if c.Request.Method == http.MethodPost {
+			bind = c.Form
+		}
+		if c.QueryParam("validate") != "" {
+			bind = c.BindAndValidate
+		}
+		if err := bind(&s); err != nil {
+			return err
+		}
+		c.String(http.StatusOK, s.Q)
+		return nil
+	}
+	d.GET("/", handler)
+	d.POST("/", handler)
+
+	// Query和Form只做绑定，不检查validate标签
+	res := httptest.NewRecorder()
+	d.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/?q=do&page=0", nil))
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "do", res.Body.String())
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("q=do&page=2"))
+	req.Header.Set(HeaderContentType, MIMEApplicationForm)
+	res = httptest.NewRecorder()
+	d.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "do", res.Body.String())
+
+	// 需要校验时使用BindAndValidate
+	res = httptest.NewRecorder()
+	d.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/?validate=1&page=2", nil))
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/?validate=1", strings.NewReader("q=do&page=2"))
+	req.Header.Set(HeaderContentType, MIMEApplicationForm)
+	res = httptest.NewRecorder()
+	d.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Code)
+	assert.Contains(t, res.Body.String(), `"rule":"min"`)
+}
+
+func TestContextImplementsContext(t *testing.T) {
+	type traceKey struct{}
+	parent, cancel := context.WithTimeout(context.WithValue(context.Background(), traceKey{}, "trace-1"), time.Minute)
+	defer cancel()
+
+	d := New()
+	d.GET("/users/:id", func(c *Context) error {
+		var ctx context.Context = c
+		deadline, ok := ctx.Deadline()
+		assert.True(t, ok)
+		expected, _ := parent.Deadline()
+		assert.Equal(t, expected, deadline)
+		// 字符串key优先查找Params，其余交给请求上下文
+		assert.Equal(t, "1", ctx.Value("id"))
+		assert.Equal(t, "trace-1", ctx.Value(traceKey{}))
+		assert.Nil(t, ctx.Value("missing"))
+		assert.Nil(t, ctx.Err())
+
+		// 可以作为父上下文派生新的上下文
+		child, stop := context.WithCancel(ctx)
+		defer stop()
+		cancel()
+		<-child.Done()
+		<-ctx.Done()
+		assert.Equal(t, context.Canceled, ctx.Err())
+		assert.Equal(t, context.Canceled, child.Err())
+		return nil
+	})
+	d.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil).WithContext(parent))
+
+	// 没有请求时返回零值
+	c := &Context{}
+	_, ok := c.Deadline()
+	assert.False(t, ok)
+	assert.Nil(t, c.Done())
+	assert.Nil(t, c.Err())
+	assert.Nil(t, c.Value("id"))
+}
+
+func TestRequestClassification(t *testing.T) {
+	newContext := func(header map[string]string) *Context {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		return &Context{Request: req}
+	}
+
+	assert.True(t, newContext(map[string]string{HeaderUpgrade: "WebSocket", "Connection": "keep-alive, Upgrade"}).IsWebSocket())
+	assert.False(t, newContext(map[string]string{HeaderUpgrade: "websocket"}).IsWebSocket())
+	assert.False(t, newContext(nil).IsWebSocket())
+
+	assert.True(t, newContext(map[string]string{HeaderXRequestedWith: "XMLHttpRequest"}).IsAjax())
+	assert.False(t, newContext(nil).IsAjax())
+
+	assert.Equal(t, "application/json", newContext(map[string]string{HeaderContentType: "Application/JSON; charset=utf-8"}).ContentType())
+	assert.Equal(t, "", newContext(nil).ContentType())
+
+	schemes := []struct {
+		header map[string]string
+		scheme string
+	}{
+		{nil, "http"},
+		{map[string]string{HeaderXForwardedProto: "HTTPS, http"}, "https"},
+		{map[string]string{HeaderXForwardedProtocol: "https"}, "https"},
+		{map[string]string{HeaderXForwardedSsl: "on"}, "https"},
+		{map[string]string{HeaderXForwardedSsl: "off"}, "http"},
+		{map[string]string{HeaderXUrlScheme: "https"}, "https"},
+	}
+	for _, tt := range schemes {
+		c := newContext(tt.header)
+		assert.False(t, c.IsTLS())
+		assert.Equal(t, tt.scheme, c.Scheme(), tt.header)
+	}
+
+	// TLS连接优先于转发头
+	c := newContext(map[string]string{HeaderXForwardedProto: "http"})
+	c.Request.TLS = &tls.ConnectionState{}
+	assert.True(t, c.IsTLS())
+	assert.Equal(t, "https", c.Scheme())
+}
+
+func TestQueryParamsAndParamNames(t *testing.T) {
+	d := New()
+	d.GET("/users/:id/posts/:post", func(c *Context) error {
+		c.SetParam("user", "admin")
+		// 不包含通过SetParam设置的参数
+		assert.Equal(t, []string{"id", "post"}, c.ParamNames())
+		assert.Equal(t, []interface{}{"7", "9"}, c.ParamValues())
+		// 返回的是副本
+		names := c.ParamNames()
+		names[0] = "changed"
+		assert.Equal(t, "id", c.ParamNames()[0])
+
+		assert.Equal(t, url.Values{"tag": {"a", "b"}, "q": {"go"}}, c.QueryParams())
+		return nil
+	})
+	d.GET("/", func(c *Context) error {
+		assert.Empty(t, c.ParamNames())
+		assert.Empty(t, c.ParamValues())
+		assert.Empty(t, c.QueryParams())
+		return nil
+	})
+	d.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/7/posts/9?tag=a&tag=b&q=go", nil))
+	d.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func TestContextError(t *testing.T) {
+	var collected errorMsgs
+	d := New()
+	d.Use(func(c *Context) error {
+		c.Next()
+		// Errors随上下文复用，需要复制后再保留
+		collected = append(errorMsgs(nil), c.Errors...)
+		return nil
+	})
+	d.GET("/", func(c *Context) error {
+		c.Error(errors.New("cache miss")).SetMeta(D{"key": "user:1"})
+		public := &Error{Err: errors.New("quota exceeded"), Type: ErrorTypePublic}
+		assert.True(t, c.Error(public) == public)
+		return errors.New("db down")
+	})
+
+	res := httptest.NewRecorder()
+	d.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+	if assert.Len(t, collected, 3) {
+		assert.Equal(t, "cache miss", collected[0].Error())
+		assert.Equal(t, ErrorTypePrivate, collected[0].Type)
+		assert.Equal(t, D{"key": "user:1"}, collected[0].Meta)
+		assert.Contains(t, collected[0].Handler, "TestContextError")
+		assert.Equal(t, ErrorTypePublic, collected[1].Type)
+		// 处理函数返回的错误同样被记录
+		assert.Equal(t, "db down", collected[2].Error())
+		assert.Contains(t, collected[2].Handler, "TestContextError")
+	}
+	assert.Equal(t, []string{"quota exceeded"}, collected.ByType(ErrorTypePublic).Errors())
+	assert.Equal(t, "db down", collected.Last().Error())
+
+	assert.Panics(t, func() {
+		(&Context{}).Error(nil)
+	})
+}
+
+func TestContextReset(t *testing.T) {
+	c := &Context{
+		Response:  &Response{},
+		handlers:  HandlersChain{func(*Context) error { return nil }},
+		index:     3,
+		fullPath:  "/users/:id",
+		Params:    map[string]interface{}{"id": "1"},
+		accepted:  []string{"application/json"},
+		Errors:    errorMsgs{{Err: errors.New("x")}},
+		body:      []byte("body"),
+		bodyRead:  true,
+		bodyErr:   BodyTooLargeErr,
+		pNames:    Params{"id"},
+		requestID: "req-1",
+		locale:    "zh",
+		trace:     &TraceContext{},
+		timeMain:  true,
+		mainTime:  time.Second,
+	}
+	c.Response.Before(func() {})
+	c.Response.After(func() {})
+	c.Response.reset(httptest.NewRecorder())
+	c.reset()
+
+	assert.Nil(t, c.handlers)
+	assert.Equal(t, int8(-1), c.index)
+	assert.Equal(t, "", c.fullPath)
+	assert.Nil(t, c.Params)
+	assert.Nil(t, c.accepted)
+	assert.Empty(t, c.Errors)
+	assert.Nil(t, c.body)
+	assert.False(t, c.bodyRead)
+	assert.Nil(t, c.bodyErr)
+	assert.Nil(t, c.pNames)
+	assert.Equal(t, "", c.requestID)
+	assert.Equal(t, "", c.locale)
+	assert.Nil(t, c.trace)
+	assert.False(t, c.timeMain)
+	assert.Equal(t, time.Duration(0), c.mainTime)
+	assert.False(t, c.Response.Written())
+	assert.Equal(t, http.StatusOK, c.Response.Status())
+	assert.Nil(t, c.Response.beforeFuncs)
+	assert.Nil(t, c.Response.afterFuncs)
+}
+
+func TestContextPoolReuse(t *testing.T) {
+	d := New()
+	d.POST("/users/:id", func(c *Context) error {
+		c.SetParam("user", "admin")
+		c.SetRequestID("req-1")
+		c.Error(errors.New("audit failed"))
+		c.Response.Before(func() {
+			c.SetResponseHeader("X-Hook", "1")
+		})
+		_, err := c.BodyBytes()
+		return err
+	})
+	d.GET("/", func(c *Context) error {
+		// 上一个请求的状态不会泄漏到复用的上下文
+		assert.Nil(t, c.Param("user"))
+		assert.Equal(t, "", c.RequestID())
+		assert.Empty(t, c.Errors)
+		assert.Empty(t, c.ParamNames())
+		assert.Equal(t, "/", c.FullPath())
+		body, err := c.BodyBytes()
+		assert.Nil(t, err)
+		assert.Empty(t, body)
+		return nil
+	})
+
+	d.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/users/1", strings.NewReader("payload")))
+	res := httptest.NewRecorder()
+	d.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, "", res.Header().Get("X-Hook"))
+}
+
+func TestContextCopy(t *testing.T) {
+	copies := make(chan *Context, 2)
+	d := New()
+	d.GET("/users/:id", func(c *Context) error {
+		c.SetRequestID("req-" + c.Param("id").(string))
+		c.SetResponseHeader("X-Trace", "t1")
+		c.Error(errors.New("slow query"))
+		c.String(http.StatusCreated, "ok")
+		copies <- c.Copy()
+		return nil
+	})
+
+	res := httptest.NewRecorder()
+	d.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	// 原上下文归还对象池后被下一个请求复用，快照不受影响
+	d.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/2", nil))
+	cp := <-copies
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.Equal(t, "1", cp.Param("id"))
+		assert.Equal(t, []string{"id"}, cp.ParamNames())
+		assert.Equal(t, "/users/:id", cp.FullPath())
+		assert.Equal(t, "req-1", cp.RequestID())
+		assert.Equal(t, http.StatusCreated, cp.Response.Status())
+		assert.Equal(t, "t1", cp.Response.Header().Get("X-Trace"))
+		assert.Len(t, cp.Errors, 1)
+
+		// 快照不能写入响应
+		_, err := cp.Response.Write([]byte("late"))
+		assert.Equal(t, SnapshotWriteErr, err)
+		cp.Response.Header().Set("X-Late", "1")
+		// 处理链已终止
+		cp.Next()
+	}()
+	<-done
+	assert.Equal(t, "ok", res.Body.String())
+	assert.Equal(t, "", res.Header().Get("X-Late"))
+	assert.Equal(t, "req-2", (<-copies).RequestID())
+}
+
+func TestJsonStream(t *testing.T) {
+	d := New()
+	d.GET("/users", func(c *Context) error {
+		id := 0
+		return c.JsonStream(http.StatusOK, func() (interface{}, bool, error) {
+			if id == 250 {
+				return nil, false, nil
+			}
+			id++
+			return map[string]int{"id": id}, true, nil
+		})
+	})
+	d.GET("/broken", func(c *Context) error {
+		id := 0
+		return c.JsonStream(http.StatusOK, func() (interface{}, bool, error) {
+			if id == 2 {
+				return nil, false, errors.New("cursor closed")
+			}
+			id++
+			return id, true, nil
+		})
+	})
+	d.GET("/chan", func(c *Context) error {
+		ch := make(chan interface{}, 3)
+		ch <- "a"
+		ch <- 1
+		ch <- nil
+		close(ch)
+		return c.JsonStreamChan(http.StatusAccepted, ch)
+	})
+	d.GET("/empty", func(c *Context) error {
+		ch := make(chan interface{})
+		close(ch)
+		return c.JsonStreamChan(http.StatusOK, ch)
+	})
+	serve := func(path string) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		d.ServeHTTP(res, httptest.NewRequest(http.MethodGet, path, nil))
+		return res
+	}
+
+	res := serve("/users")
+	assert.Equal(t, "application/json; charset=utf-8", res.Header().Get(HeaderContentType))
+	assert.True(t, res.Flushed)
+	var users []map[string]int
+	assert.Nil(t, json.Unmarshal(res.Body.Bytes(), &users))
+	assert.Len(t, users, 250)
+	assert.Equal(t, 250, users[249]["id"])
+
+	// 迭代出错时不输出结尾的"]"
+	res = serve("/broken")
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "[1\n,2\n", res.Body.String())
+	assert.NotNil(t, json.Unmarshal(res.Body.Bytes(), &[]int{}))
+
+	res = serve("/chan")
+	assert.Equal(t, http.StatusAccepted, res.Code)
+	assert.JSONEq(t, `["a",1,null]`, res.Body.String())
+
+	assert.Equal(t, "[]", serve("/empty").Body.String())
+}
+
+func TestAttachmentReader(t *testing.T) {
+	d := New()
+	d.GET("/export", func(c *Context) error {
+		return c.AttachmentReader(strings.NewReader(`{"id":1}`), "users.json", 8)
+	})
+	d.HEAD("/export", func(c *Context) error {
+		return c.AttachmentReader(strings.NewReader(`{"id":1}`), "users.json", 8)
+	})
+	d.GET("/stream", func(c *Context) error {
+		return c.AttachmentReader(strings.NewReader("zip"), "报表 2020.zzz", -1)
+	})
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		d.ServeHTTP(res, httptest.NewRequest(method, path, nil))
+		return res
+	}
+
+	res := serve(http.MethodGet, "/export")
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, `attachment; filename="users.json"`, res.Header().Get(HeaderContentDisposition))
+	assert.Equal(t, "application/json", res.Header().Get(HeaderContentType))
+	assert.Equal(t, "8", res.Header().Get(HeaderContentLength))
+	assert.Equal(t, `{"id":1}`, res.Body.String())
+
+	res = serve(http.MethodHead, "/export")
+	assert.Equal(t, "8", res.Header().Get(HeaderContentLength))
+	assert.Equal(t, "", res.Body.String())
+
+	// 长度未知时不设置Content-Length，非ASCII文件名使用filename*
+	res = serve(http.MethodGet, "/stream")
+	assert.Equal(t, `attachment; filename="报表 2020.zzz"; filename*=UTF-8''%E6%8A%A5%E8%A1%A8%202020.zzz`, res.Header().Get(HeaderContentDisposition))
+	assert.Equal(t, "application/octet-stream", res.Header().Get(HeaderContentType))
+	assert.Equal(t, "", res.Header().Get(HeaderContentLength))
+	assert.Equal(t, "zip", res.Body.String())
+}
+
+func TestFileRange(t *testing.T) {
+	dir, err := ioutil.TempDir("", "doris-file")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "video.txt")
+	assert.Nil(t, ioutil.WriteFile(path, []byte("0123456789"), 0644))
+	modtime := time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC)
+	assert.Nil(t, os.Chtimes(path, modtime, modtime))
+
+	d := New()
+	d.GET("/file", func(c *Context) error {
+		c.File(path)
+		return nil
+	})
+	d.GET("/content", func(c *Context) error {
+		c.ServeContent("data.txt", modtime, bytes.NewReader([]byte("0123456789")))
+		return nil
+	})
+	serve := func(path string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		res := httptest.NewRecorder()
+		d.ServeHTTP(res, req)
+		return res
+	}
+
+	res := serve("/file", map[string]string{"Range": "bytes=2-5"})
+	assert.Equal(t, http.StatusPartialContent, res.Code)
+	assert.Equal(t, "bytes 2-5/10", res.Header().Get("Content-Range"))
+	assert.Equal(t, "2345", res.Body.String())
+
+	// If-Range与文件修改时间一致时返回部分内容，否则返回完整内容
+	res = serve("/file", map[string]string{"Range": "bytes=2-5", "If-Range": modtime.Format(http.TimeFormat)})
+	assert.Equal(t, http.StatusPartialContent, res.Code)
+	res = serve("/file", map[string]string{"Range": "bytes=2-5", "If-Range": modtime.Add(-time.Hour).Format(http.TimeFormat)})
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "0123456789", res.Body.String())
+
+	res = serve("/file", map[string]string{"If-Modified-Since": modtime.Format(http.TimeFormat)})
+	assert.Equal(t, http.StatusNotModified, res.Code)
+
+	res = serve("/content", map[string]string{"Range": "bytes=-3"})
+	assert.Equal(t, http.StatusPartialContent, res.Code)
+	assert.Equal(t, "789", res.Body.String())
+
+	res = serve("/file", map[string]string{"Range": "bytes=20-30"})
+	assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, res.Code)
+}
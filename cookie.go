@@ -0,0 +1,76 @@
+// cookie构建器用于快速生成http.Cookie
+// 配合Context的SetCookie方法使用
+package doris
+
+import (
+	"net/http"
+	"time"
+)
+
+// cookie可选项
+type CookieOption func(*http.Cookie)
+
+// 创建一个cookie实例
+// 默认path为"/"，其余属性通过可选项设置
+func NewCookie(name, value string, options ...CookieOption) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:  name,
+		Value: value,
+		Path:  "/",
+	}
+	for _, option := range options {
+		option(cookie)
+	}
+	return cookie
+}
+
+// 设置cookie的路径
+func CookiePath(path string) CookieOption {
+	return func(cookie *http.Cookie) {
+		cookie.Path = path
+	}
+}
+
+// 设置cookie的域名
+func CookieDomain(domain string) CookieOption {
+	return func(cookie *http.Cookie) {
+		cookie.Domain = domain
+	}
+}
+
+// 设置cookie的Max-Age（单位秒）
+// maxAge<0表示立即删除cookie
+func CookieMaxAge(maxAge int) CookieOption {
+	return func(cookie *http.Cookie) {
+		cookie.MaxAge = maxAge
+	}
+}
+
+// 设置cookie的过期时间
+func CookieExpires(expires time.Time) CookieOption {
+	return func(cookie *http.Cookie) {
+		cookie.Expires = expires
+	}
+}
+
+// 设置cookie是否仅在https下传输
+func CookieSecure(secure bool) CookieOption {
+	return func(cookie *http.Cookie) {
+		cookie.Secure = secure
+	}
+}
+
+// 设置cookie是否禁止js访问
+func CookieHttpOnly(httpOnly bool) CookieOption {
+	return func(cookie *http.Cookie) {
+		cookie.HttpOnly = httpOnly
+	}
+}
+
+// 设置cookie的SameSite属性
+// 可选值：http.SameSiteDefaultMode、http.SameSiteLaxMode、http.SameSiteStrictMode
+func CookieSameSite(sameSite http.SameSite) CookieOption {
+	return func(cookie *http.Cookie) {
+		cookie.SameSite = sameSite
+	}
+}
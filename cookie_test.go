@@ -0,0 +1,88 @@
+package doris
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCookie(t *testing.T) {
+	expires := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	cookie := NewCookie("sid", "abc",
+		CookiePath("/admin"),
+		CookieDomain("example.com"),
+		CookieMaxAge(3600),
+		CookieExpires(expires),
+		CookieSecure(true),
+		CookieHttpOnly(true),
+		CookieSameSite(http.SameSiteStrictMode),
+	)
+	assert.Equal(t, &http.Cookie{
+		Name:     "sid",
+		Value:    "abc",
+		Path:     "/admin",
+		Domain:   "example.com",
+		MaxAge:   3600,
+		Expires:  expires,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	}, cookie)
+
+	// 默认路径为"/"
+	assert.Equal(t, "/", NewCookie("sid", "abc").Path)
+}
+
+func TestSetCookie(t *testing.T) {
+	shared := &http.Cookie{Name: "theme", Value: "dark"}
+	d := New()
+	d.GET("/", func(c *Context) error {
+		c.SetCookie(NewCookie("sid", "abc", CookieHttpOnly(true), CookieSameSite(http.SameSiteLaxMode)))
+		c.SetCookie(shared)
+		// 空cookie和无名称的cookie被忽略
+		c.SetCookie(nil)
+		c.SetCookie(&http.Cookie{Value: "anonymous"})
+		return nil
+	})
+
+	res := httptest.NewRecorder()
+	d.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, []string{
+		"sid=abc; Path=/; HttpOnly; SameSite=Lax",
+		"theme=dark; Path=/",
+	}, res.Header()[HeaderSetCookie])
+	// 默认路径不写回调用方的cookie，共享的cookie可以被并发使用
+	assert.Equal(t, "", shared.Path)
+}
+
+func TestRequestCookies(t *testing.T) {
+	d := New()
+	d.GET("/", func(c *Context) error {
+		sid, err := c.Cookie("sid")
+		assert.Nil(t, err)
+		assert.Equal(t, "abc", sid)
+		_, err = c.Cookie("missing")
+		assert.Equal(t, http.ErrNoCookie, err)
+
+		names := []string{}
+		for _, cookie := range c.Cookies() {
+			names = append(names, cookie.Name+"="+cookie.Value)
+		}
+		assert.Equal(t, []string{"sid=abc", "theme=dark"}, names)
+
+		c.SetRequestCookie(&http.Cookie{Name: "lang", Value: "zh"})
+		c.SetRequestCookie(nil)
+		lang, err := c.Cookie("lang")
+		assert.Nil(t, err)
+		assert.Equal(t, "zh", lang)
+		assert.Len(t, c.Cookies(), 3)
+		return nil
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Cookie", "sid=abc; theme=dark")
+	d.ServeHTTP(httptest.NewRecorder(), req)
+}
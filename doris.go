@@ -2,24 +2,31 @@ package doris
 
 import (
 	//"bytes"
-	//"crypto/tls"
-	//"errors"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
-	//"io"
-	//"io/ioutil"
-	//"net"
+	"io"
+	"io/ioutil"
+	"net"
 	"net/http"
 	//"net/url"
+	"os"
 	//"path"
 	//"path/filepath"
 	//"reflect"
 	//"runtime"
-	//"time"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
-	"github.com/leaderwolfpipi/logger"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 type (
@@ -29,33 +36,69 @@ type (
 		maxParam         *int                   // 路由中的最大参数数
 		trees            trees                  // Method路由树
 		pool             sync.Pool              // 用于复用context上下文等对象
-		HTTPErrorHandler HTTPErrorHandler       // http错误处理函数
+		HTTPErrorHandler HTTPErrorHandler       // http错误处理函数，默认为DefaultHTTPErrorHandler
+		DefaultLocale    string                 // 无法从请求确定语言时使用的错误信息语言
+		ErrorEnvelope    ErrorEnvelope          // 内置错误响应的json结构
+		ServerHeader     bool                   // 是否在Server响应头中输出服务名称和版本，需调用VersionInfo
 		Config           map[string]interface{} // 全局用户配置器
 		Debug            bool                   // 是否处于调试模式
 		autoSlash        bool                   // 是否自动在路径的结尾添加'/'
 		noRoute          HandlersChain          // 不存在路由处理链
 		noMethod         HandlersChain          // 不存在方法处理链
 		allowMethod      []string               // 允许的HTTP方法列表
-		Logger           *logger.Logger         // 全局日志记录器
+		Logger           Logger                 // 全局日志记录器，可替换为任意Logger实现
 		ShowBanner       bool                   // 是否显示banner信息
+		Banner           string                 // 自定义banner，为空时使用默认banner
+		StartupOutput    io.Writer              // 启动信息的输出位置，默认os.Stdout，设为ioutil.Discard可关闭
+		DisableColor     bool                   // 启动信息和路由表是否禁用终端颜色
+		routes           []RouteInfo            // 已注册的路由列表
+		MaxBodySize      int64                  // 请求体缓存的最大字节数
+		binders          map[string]BindFunc    // Content-Type对应的参数绑定函数
+		Validator        Validator              // 结构体校验器，默认使用validate标签
+		HTMLRender       *HTMLRender            // html模板渲染器
+		errorPages       map[int]string         // 状态码对应的html错误页面模板
+		SecureJsonPrefix string                 // SecureJson输出数组时的前缀
+		SecureJsonArrays bool                   // 是否对Json输出的顶层数组自动添加前缀
+		TLSConfig        *tls.Config            // https服务的tls配置模板（最低版本、加密套件、曲线、ALPN等）
+		TLSClientCAs     *x509.CertPool         // 双向TLS认证中用于验证客户端证书的CA
+		TLSClientAuth    tls.ClientAuthType     // 双向TLS认证的客户端证书策略
+		AutoTLSCacheDir  string                 // 自动申请的证书缓存目录
+		AutoTLSEmail     string                 // 申请证书时向CA登记的联系邮箱
+		H2C              bool                   // 是否在非TLS服务上支持HTTP/2明文协议（h2c）
+		Server           *http.Server           // 底层http服务的配置模板（超时、请求头大小、ErrorLog、BaseContext等）
+		ShutdownTimeout  time.Duration          // 优雅关闭时等待连接排空的最长时间，超时后强制关闭
+		DrainDelay       time.Duration          // 关闭时就绪探针置为失败后，停止监听前的等待时间
+		PreforkProcesses int                    // prefork模式的工作进程数，默认为CPU核数
+		draining         int32                  // 是否正在关闭，为1时就绪探针返回失败
+		openConns        int32                  // 当前打开的连接数
+		idleConns        int32                  // 当前空闲的keep-alive连接数
+		idle             sync.Map               // 空闲的连接
+		activeRequests   int32                  // 正在处理的请求数
+		listenAddr       net.Addr               // 实际绑定的监听地址
+		started          chan struct{}          // 开始监听时关闭的通知通道
+		stateLock        sync.Mutex             // 保护监听地址、通知通道和运行中的服务
+		servers          []serverCloser         // 运行中的服务，由Stop关闭
+		serveErrs        chan error             // 各服务结束时的错误
+		stopped          chan struct{}          // Stop完成时关闭的通知通道
+		startHooks       []StartHook            // 服务启动前执行的钩子
+		shutdownHooks    []ShutdownHook         // 服务关闭后执行的钩子
+		errorHooks       []ErrorHook            // 请求以错误结束时执行的钩子
+		health           *Health                // 健康检查子系统
+		stats            *stats                 // 请求统计，EnableStats开启
+		version          *Info                  // 服务的版本信息
+		serverHeader     string                 // 预先生成的Server响应头
 		// beforeHandlers   HandlersChain       // 全局前向中间件调用链
 		// afterHandlers    HandlersChain       // 全局后向中间件调用链
 	}
 
-	// 请求过程中出现的错误提示
-	HTTPError struct {
-		Code    int         `json:"-"`       // 错误编号
-		Message interface{} `json:"message"` // 错误信息
-	}
-
 	// 定义http请求处理函数
 	HandlerFunc func(*Context) error
 
 	// 定义HandlerFunc数组
 	HandlersChain []HandlerFunc
 
-	// 集中式http错误处理器
-	HTTPErrorHandler func(error, Context)
+	// 集中式http错误处理器，处理函数或中间件返回错误时调用
+	HTTPErrorHandler func(error, *Context)
 
 	// map[string]interface{}的简短定义
 	D map[string]interface{}
@@ -76,8 +119,10 @@ var httpMethods []string = []string{
 const (
 	HeaderAccept              = "Accept"
 	HeaderAcceptEncoding      = "Accept-Encoding"
+	HeaderAcceptLanguage      = "Accept-Language"
 	HeaderAllow               = "Allow"
 	HeaderAuthorization       = "Authorization"
+	HeaderCacheControl        = "Cache-Control"
 	HeaderContentDisposition  = "Content-Disposition"
 	HeaderContentEncoding     = "Content-Encoding"
 	HeaderContentLength       = "Content-Length"
@@ -145,19 +190,32 @@ _________________________________Author: JonahLou__
 `
 )
 
+// 请求体缓存默认的最大字节数32M
+const defaultMaxBodySize int64 = 32 << 20
+
+// 优雅关闭的默认等待时间
+const defaultShutdownTimeout = 10 * time.Second
+
+// 自动申请证书的默认缓存目录
+const defaultAutoTLSCacheDir = ".autocert"
+
+// SecureJson默认的数组前缀
+const defaultSecureJsonPrefix = "while(1);"
+
 // 实例化框架对象函数
 func New() *Doris {
 	doris := &Doris{
-		maxParam:    new(int),
-		Logger:      logger.NewLogger(),
-		allowMethod: []string{"GET", "POST", "DELETE", "PUT", "OPTIONS", "HEAD"},
+		maxParam:         new(int),
+		Logger:           NewDefaultLogger(),
+		allowMethod:      []string{"GET", "POST", "DELETE", "PUT", "OPTIONS", "HEAD"},
+		MaxBodySize:      defaultMaxBodySize,
+		SecureJsonPrefix: defaultSecureJsonPrefix,
 	}
-	// 启动日志
-	doris.Logger.SetCacheSwitch(true)
-	doris.Logger.Start()
 	// 注册默认404和405函数
 	doris.NoMethod(defaultNoMethod)
 	doris.NoRoute(defaultNoRoute)
+	// 注册默认的参数绑定函数
+	doris.registerDefaultBinders()
 	// 设置错误级别
 	//doris.Logger.SetLevel(log.ERROR)
 	doris.RouteGroup.doris = doris
@@ -169,12 +227,12 @@ func New() *Doris {
 
 // 注册默认404函数
 func defaultNoRoute(c *Context) error {
-	return serveError(c, 404, "not found!")
+	return serveError(c, 404, StatusMessage(404))
 }
 
 // 注册默认405函数
 func defaultNoMethod(c *Context) error {
-	return serveError(c, 405, "method not allowed!")
+	return serveError(c, 405, StatusMessage(405))
 }
 
 // 分配一个新的上下文实例
@@ -219,6 +277,14 @@ func (doris *Doris) addRoute(method, path string, handlers HandlersChain) {
 	assert1(method != "", "HTTP method can not be empty")
 	assert1(len(handlers) > 0, "there must be at least one handler")
 	assert1(doris.validMethod(method), "method not support")
+	doris.routes = append(doris.routes, RouteInfo{
+		Method:  method,
+		Path:    path,
+		Handler: nameOfFunction(handlers[len(handlers)-1]),
+	})
+	if doris.stats != nil {
+		doris.stats.addRoute(method, path)
+	}
 	// 注册路由
 	if root := doris.trees.get(method); root != nil { // 树存在
 		root.debug = doris.Debug // 设置调试参数
@@ -244,35 +310,510 @@ func (doris *Doris) addRoute(method, path string, handlers HandlersChain) {
 }
 
 // 运行框架程序绑定端口
+// 阻塞直到服务出错或被Stop关闭，被Stop关闭时返回nil
 func (doris *Doris) Run(addr ...string) (err error) {
+	if err = doris.Start(addr...); err != nil {
+		return err
+	}
+	return doris.wait()
+}
+
+// 启动http服务，ctx取消时优雅关闭
+// 关闭时等待处理中的请求完成，最长等待ShutdownTimeout，正常关闭时返回nil
+// 可以与errgroup等服务编排工具配合使用
+func (doris *Doris) RunWithContext(ctx context.Context, addr ...string) (err error) {
+	if err = doris.Start(addr...); err != nil {
+		return err
+	}
+	return doris.waitContext(ctx)
+}
+
+// 启动http服务并立即返回，绑定地址失败等启动错误直接返回，而不是终止进程
+// 服务在后台运行，由调用方通过Stop关闭
+func (doris *Doris) Start(addr ...string) (err error) {
 	address := ResolveAddress(addr)
+	if doris.running() {
+		return ServerRunningErr
+	}
 
-	// 判断是否展示banner
-	if doris.ShowBanner {
-		// 显示banner信息
-		fmt.Printf(banner, Version, website)
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	server := doris.newServer(address, doris.handler())
+	if err = doris.launch(server); err != nil {
+		listener.Close()
+		return err
 	}
+	doris.listening(listener)
+	doris.serve(func() error {
+		return server.Serve(listener)
+	})
+	doris.startupf("⇨ http server started on %s \n\n", doris.colorize(colorGreen, listener.Addr().String()))
+
+	return nil
+}
 
-	// 存在多监听的时候只取第一个
-	pi := strings.Index(addr[0], ":")
-	port := addr[0][pi+1:]
+// 可以被Stop关闭的服务，*http.Server和QUICServer实现了该接口
+type serverCloser interface {
+	Shutdown(ctx context.Context) error
+	Close() error
+}
 
-	// 打印引导信息
-	fmt.Printf("⇨ http server started on \033[0;32m[::]:%s\033[0m \n\n", port)
-	err = http.ListenAndServe(address, doris)
+// 登记运行中的服务，使其可以被Stop关闭
+// 已有服务运行时返回ServerRunningErr
+func (doris *Doris) register(servers ...serverCloser) error {
+	doris.stateLock.Lock()
+	defer doris.stateLock.Unlock()
+	if doris.servers != nil {
+		return ServerRunningErr
+	}
+	doris.servers = servers
+	doris.serveErrs = make(chan error, len(servers))
+	doris.stopped = make(chan struct{})
+	// Stop后再次启动时重新记录监听地址
+	if doris.listenAddr != nil {
+		doris.listenAddr = nil
+		doris.started = nil
+	}
+	atomic.StoreInt32(&doris.draining, 0)
+	return nil
+}
 
-	return
+// 登记服务后打印banner并执行启动钩子，启动钩子出错时注销服务
+// 调用前需先绑定监听地址，绑定失败时不会执行启动钩子；
+// 之后服务出错时会执行关闭钩子
+func (doris *Doris) launch(servers ...serverCloser) error {
+	if err := doris.register(servers...); err != nil {
+		return err
+	}
+	if err := doris.startup(); err != nil {
+		doris.stateLock.Lock()
+		doris.servers = nil
+		doris.stateLock.Unlock()
+		return err
+	}
+	return nil
+}
+
+// 在后台运行已登记的服务，run为服务的启动函数
+// run返回http.ErrServerClosed以外的错误时关闭其余服务并执行关闭钩子
+func (doris *Doris) serve(run func() error) {
+	doris.stateLock.Lock()
+	errs := doris.serveErrs
+	doris.stateLock.Unlock()
+	go func() {
+		err := run()
+		errs <- err
+		if err == http.ErrServerClosed {
+			return
+		}
+		if err = doris.stop(); err != nil && err != ServerNotRunningErr {
+			doris.Logger.Error("shutdown: " + err.Error())
+		}
+	}()
 }
 
+// 优雅关闭运行中的服务
+// 就绪探针置为失败并排空连接，ctx到期时强制关闭剩余连接，之后执行关闭钩子
+// 服务未启动时返回ServerNotRunningErr
+func (doris *Doris) Stop(ctx context.Context) (err error) {
+	doris.stateLock.Lock()
+	servers, stopped := doris.servers, doris.stopped
+	doris.servers = nil
+	doris.stateLock.Unlock()
+	if servers == nil {
+		return ServerNotRunningErr
+	}
+	defer close(stopped)
+
+	// 并行关闭所有服务
+	doris.drain(servers...)
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	for _, server := range servers {
+		wg.Add(1)
+		go func(server serverCloser) {
+			defer wg.Done()
+			if e := doris.shutdownContext(ctx, server); e != nil {
+				lock.Lock()
+				if err == nil {
+					err = e
+				}
+				lock.Unlock()
+			}
+		}(server)
+	}
+	wg.Wait()
+	if e := doris.runShutdownHooks(); err == nil {
+		err = e
+	}
+	return err
+}
+
+// 以DrainDelay加ShutdownTimeout为时限关闭服务
+func (doris *Doris) stop() error {
+	ctx, cancel := context.WithTimeout(context.Background(), doris.DrainDelay+doris.shutdownTimeout())
+	defer cancel()
+	return doris.Stop(ctx)
+}
+
+// 服务是否已启动且尚未关闭
+func (doris *Doris) running() bool {
+	doris.stateLock.Lock()
+	defer doris.stateLock.Unlock()
+	return doris.servers != nil
+}
+
+// 等待服务结束，被Stop关闭时等待关闭流程完成后返回nil
+// 服务出错时等待其余服务关闭后返回该错误
+func (doris *Doris) wait() error {
+	doris.stateLock.Lock()
+	errs, stopped := doris.serveErrs, doris.stopped
+	doris.stateLock.Unlock()
+	if errs == nil {
+		return ServerNotRunningErr
+	}
+	err := <-errs
+	<-stopped
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
+// 等待服务结束，ctx取消时优雅关闭
+func (doris *Doris) waitContext(ctx context.Context) error {
+	errs := make(chan error, 1)
+	go func() {
+		errs <- doris.wait()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+	return doris.stop()
+}
+
+// 优雅关闭服务，ctx到期时强制关闭剩余连接
+func (doris *Doris) shutdownContext(ctx context.Context, server serverCloser) error {
+	if err := server.Shutdown(ctx); err != nil {
+		doris.Logger.Warn("shutdown timeout, force closing connections", F("open_conns", doris.OpenConns()))
+		server.Close()
+		return err
+	}
+	return nil
+}
+
+// 记录实际绑定的监听地址并发出开始监听的通知
+// 同时启动多个监听时记录第一个
+func (doris *Doris) listening(listener net.Listener) {
+	doris.stateLock.Lock()
+	defer doris.stateLock.Unlock()
+	if doris.listenAddr != nil {
+		return
+	}
+	doris.listenAddr = listener.Addr()
+	if doris.started == nil {
+		doris.started = make(chan struct{})
+	}
+	close(doris.started)
+}
+
+// 获取实际绑定的监听地址，尚未开始监听时返回nil
+// 监听"localhost:0"等临时端口时可据此获得系统分配的端口
+func (doris *Doris) Addr() net.Addr {
+	doris.stateLock.Lock()
+	defer doris.stateLock.Unlock()
+	return doris.listenAddr
+}
+
+// 获取开始监听的通知通道，绑定地址后通道关闭，此时Addr()返回实际地址
+func (doris *Doris) Started() <-chan struct{} {
+	doris.stateLock.Lock()
+	defer doris.stateLock.Unlock()
+	if doris.started == nil {
+		doris.started = make(chan struct{})
+	}
+	return doris.started
+}
+
+// 获取优雅关闭的等待时间
+func (doris *Doris) shutdownTimeout() time.Duration {
+	if doris.ShutdownTimeout <= 0 {
+		return defaultShutdownTimeout
+	}
+	return doris.ShutdownTimeout
+}
+
+// 在指定的listener上启动http服务
+// 可用于自定义的监听方式，如unix socket、systemd传递的socket等
+func (doris *Doris) Serve(listener net.Listener) (err error) {
+	server := doris.newServer("", doris.handler())
+	if err = doris.launch(server); err != nil {
+		return err
+	}
+	doris.listening(listener)
+	doris.serve(func() error {
+		return server.Serve(listener)
+	})
+	doris.startupf("⇨ http server started on %s \n\n", doris.colorize(colorGreen, listener.Addr().String()))
+
+	return doris.wait()
+}
+
+// 在unix domain socket上启动http服务，用于在nginx、envoy等代理之后部署
+// 启动前会删除残留的socket文件，并将socket文件的权限设置为perm
+func (doris *Doris) RunUnix(path string, perm os.FileMode) (err error) {
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if err = os.Remove(path); err != nil {
+			return err
+		}
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+	if err = os.Chmod(path, perm); err != nil {
+		return err
+	}
+	return doris.Serve(listener)
+}
+
+// 启动https服务
+// 使用TLSConfig中的tls配置；配置了TLSClientCAs时按TLSClientAuth验证客户端证书（默认强制验证）
+func (doris *Doris) RunTLS(addr, certFile, keyFile string) (err error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	server := doris.newServer(addr, doris)
+	server.TLSConfig = doris.tlsConfig()
+	if err = doris.launch(server); err != nil {
+		listener.Close()
+		return err
+	}
+	doris.listening(listener)
+	doris.serve(func() error {
+		return server.ServeTLS(listener, certFile, keyFile)
+	})
+	doris.startupf("⇨ https server started on %s \n\n", doris.colorize(colorGreen, listener.Addr().String()))
+
+	return doris.wait()
+}
+
+// 使用Let's Encrypt自动申请和续期证书启动https服务
+// 证书缓存在AutoTLSCacheDir中，同时在80端口启动http服务
+// 用于ACME http-01验证，并将其他请求重定向到https
+func (doris *Doris) RunAutoTLS(domains ...string) (err error) {
+	if len(domains) == 0 {
+		return AutoTLSDomainsErr
+	}
+	cacheDir := doris.AutoTLSCacheDir
+	if cacheDir == "" {
+		cacheDir = defaultAutoTLSCacheDir
+	}
+	manager := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(domains...),
+		Cache:      autocert.DirCache(cacheDir),
+		Email:      doris.AutoTLSEmail,
+	}
+
+	config := doris.tlsConfig()
+	config.GetCertificate = manager.GetCertificate
+	nextProtos := config.NextProtos
+	if len(nextProtos) == 0 {
+		nextProtos = []string{"h2", "http/1.1"}
+	}
+	// 复制后追加，避免修改TLSConfig模板
+	config.NextProtos = append(append([]string{}, nextProtos...), acme.ALPNProto)
+	listener, err := net.Listen("tcp", ":https")
+	if err != nil {
+		return err
+	}
+	server := doris.newServer(":https", doris)
+	server.TLSConfig = config
+	if err = doris.launch(server); err != nil {
+		listener.Close()
+		return err
+	}
+
+	// http服务：ACME验证和https重定向
+	redirect := doris.newServer(":http", manager.HTTPHandler(nil))
+	go func() {
+		if err := redirect.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			doris.Logger.Error("autotls: http server: " + err.Error())
+		}
+	}()
+	defer redirect.Close()
+
+	doris.listening(listener)
+	doris.serve(func() error {
+		return server.ServeTLS(listener, "", "")
+	})
+	doris.startupf("⇨ https server started on %s for %s \n\n", doris.colorize(colorGreen, listener.Addr().String()), strings.Join(domains, ", "))
+
+	return doris.wait()
+}
+
+// 使用自定义的http.Server启动服务
+// 未设置Handler时使用doris处理请求；TLSConfig中配置了证书时启动https服务
+func (doris *Doris) RunServer(server *http.Server) (err error) {
+	if server.Handler == nil {
+		server.Handler = doris.handler()
+	}
+
+	tlsEnabled := false
+	addr := server.Addr
+	if c := server.TLSConfig; c != nil && (len(c.Certificates) > 0 || c.GetCertificate != nil) {
+		tlsEnabled = true
+		if addr == "" {
+			addr = ":https"
+		}
+	} else if addr == "" {
+		addr = ":http"
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	if err = doris.launch(server); err != nil {
+		listener.Close()
+		return err
+	}
+	doris.listening(listener)
+
+	if tlsEnabled {
+		doris.serve(func() error {
+			return server.ServeTLS(listener, "", "")
+		})
+		doris.startupf("⇨ https server started on %s \n\n", doris.colorize(colorGreen, listener.Addr().String()))
+	} else {
+		doris.serve(func() error {
+			return server.Serve(listener)
+		})
+		doris.startupf("⇨ http server started on %s \n\n", doris.colorize(colorGreen, listener.Addr().String()))
+	}
+
+	return doris.wait()
+}
+
+// 按Doris.Server的配置创建http服务
+// 逐个复制配置字段，各Run方法创建的服务互不影响
+func (doris *Doris) newServer(addr string, handler http.Handler) *http.Server {
+	server := &http.Server{
+		Addr:    addr,
+		Handler: handler,
+	}
+	if tpl := doris.Server; tpl != nil {
+		server.ReadTimeout = tpl.ReadTimeout
+		server.ReadHeaderTimeout = tpl.ReadHeaderTimeout
+		server.WriteTimeout = tpl.WriteTimeout
+		server.IdleTimeout = tpl.IdleTimeout
+		server.MaxHeaderBytes = tpl.MaxHeaderBytes
+		server.ErrorLog = tpl.ErrorLog
+		server.BaseContext = tpl.BaseContext
+		server.ConnContext = tpl.ConnContext
+		server.ConnState = tpl.ConnState
+	}
+	server.ConnState = doris.trackConnState(server.ConnState)
+	return server
+}
+
+// 获取非TLS服务使用的处理器
+// 开启H2C时同时支持HTTP/1.1和HTTP/2明文连接（如gRPC-web、内部HTTP/2客户端）
+func (doris *Doris) handler() http.Handler {
+	if doris.H2C {
+		return h2c.NewHandler(doris, &http2.Server{})
+	}
+	return doris
+}
+
+// 构建https服务的tls配置
+// 以TLSConfig为模板复制一份，未设置最低版本时默认TLS 1.2
+func (doris *Doris) tlsConfig() *tls.Config {
+	config := &tls.Config{}
+	if doris.TLSConfig != nil {
+		config = doris.TLSConfig.Clone()
+	}
+	if config.MinVersion == 0 {
+		config.MinVersion = tls.VersionTLS12
+	}
+	if doris.TLSClientCAs != nil {
+		config.ClientCAs = doris.TLSClientCAs
+		config.ClientAuth = doris.TLSClientAuth
+		if config.ClientAuth == tls.NoClientCert {
+			config.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+	return config
+}
+
+// 从PEM文件加载证书池
+// 常用于设置TLSClientCAs
+func LoadCertPool(files ...string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	for _, file := range files {
+		pem, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("doris: no certificates found in " + file)
+		}
+	}
+	return pool, nil
+}
+
+// Doris实现了http.Handler，可以挂载到net/http的ServeMux、被第三方中间件包装，
+// 或在测试中配合httptest直接调用而无需监听端口
+var _ http.Handler = (*Doris)(nil)
+
 // 实现ServerHTTP接口
+// Context和Response对象通过sync.Pool复用，请求处理完毕后立即归还对象池
+// 因此处理函数返回后不能再持有或使用Context（包括在goroutine中），需要时请使用c.Copy()
 func (doris *Doris) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// 从对象池中获取context并复位
 	c := doris.pool.Get().(*Context)
 	c.Response.reset(w)
 	c.Request = req
+	c.reset()
+	if doris.ServerHeader && doris.serverHeader != "" {
+		// 每个响应使用独立的切片，避免修改响应头时影响其他请求
+		w.Header()[HeaderServer] = []string{doris.serverHeader}
+	}
+	atomic.AddInt32(&doris.activeRequests, 1)
+	defer atomic.AddInt32(&doris.activeRequests, -1)
+	if len(doris.errorHooks) > 0 {
+		defer doris.notifyPanic(c)
+	}
 	doris.handleHTTPRequest(c)
+	// 处理链未写入任何内容时确保响应头被发送
+	c.Response.WriteHeaderNow()
+	if doris.stats != nil {
+		doris.stats.record(c.Request.Method, c.fullPath)
+	}
+	doris.notifyError(c)
 	doris.pool.Put(c)
 }
 
+// 将http.Handler包装为HandlerFunc，便于在路由中复用net/http生态的处理器
+func WrapH(h http.Handler) HandlerFunc {
+	return func(c *Context) error {
+		h.ServeHTTP(c.Response, c.Request)
+		return nil
+	}
+}
+
+// 将http.HandlerFunc包装为HandlerFunc
+func WrapF(f http.HandlerFunc) HandlerFunc {
+	return WrapH(f)
+}
+
 // 实际处理http请求的地方
 func (doris *Doris) handleHTTPRequest(c *Context) {
 	httpMethod := c.Request.Method
@@ -292,6 +833,10 @@ func (doris *Doris) handleHTTPRequest(c *Context) {
 		if nodev != nil && nodev.handlers != nil {
 			c.handlers = nodev.handlers
 			c.Params = SliceToMap(nodev.params, nodev.pvalues)
+			c.pNames = nodev.params
+			if len(c.pNames) > len(nodev.pvalues) {
+				c.pNames = c.pNames[:len(nodev.pvalues)]
+			}
 			c.fullPath = nodev.fullPath
 			c.index = -1 // 默认设置为-1
 			c.Next()     // 执行函数处理链
@@ -324,7 +869,7 @@ func (doris *Doris) validMethod(method string) bool {
 
 // 处理错误
 func serveError(c *Context, code int, defaultMessage string) error {
-	c.Json(code, D{"code": code, "message": defaultMessage})
+	c.writeError(code, code, c.localize(code, defaultMessage), nil)
 	return nil
 }
 
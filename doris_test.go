@@ -0,0 +1,360 @@
+package doris
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunAutoTLSRequiresDomains(t *testing.T) {
+	d := New()
+	assert.Equal(t, AutoTLSDomainsErr, d.RunAutoTLS())
+}
+
+func TestH2CHandlerServesHTTP1(t *testing.T) {
+	d := New()
+	d.H2C = true
+	d.GET("/", func(c *Context) error {
+		c.String(http.StatusOK, c.Request.Proto)
+		return nil
+	})
+	res := httptest.NewRecorder()
+	d.handler().ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "HTTP/1.1", res.Body.String())
+}
+
+func TestRunUnix(t *testing.T) {
+	dir, err := ioutil.TempDir("", "doris-unix")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "doris.sock")
+
+	// 残留的socket文件会被删除
+	stale, err := net.Listen("unix", path)
+	assert.Nil(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	d := New()
+	d.GET("/ping", func(c *Context) error {
+		c.String(http.StatusOK, "pong")
+		return nil
+	})
+	go d.RunUnix(path, 0660)
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return net.Dial("unix", path)
+		},
+	}}
+	var res *http.Response
+	for i := 0; i < 100; i++ {
+		if res, err = client.Get("http://doris/ping"); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Nil(t, err)
+	body, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	assert.Equal(t, "pong", string(body))
+
+	info, err := os.Stat(path)
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0660), info.Mode().Perm())
+}
+
+func TestNewServerUsesTemplate(t *testing.T) {
+	d := New()
+	d.Server = &http.Server{
+		ReadTimeout:       5 * time.Second,
+		ReadHeaderTimeout: 2 * time.Second,
+		WriteTimeout:      10 * time.Second,
+		IdleTimeout:       time.Minute,
+		MaxHeaderBytes:    1 << 16,
+	}
+	server := d.newServer(":8080", d)
+	assert.Equal(t, ":8080", server.Addr)
+	assert.Equal(t, 5*time.Second, server.ReadTimeout)
+	assert.Equal(t, 2*time.Second, server.ReadHeaderTimeout)
+	assert.Equal(t, 10*time.Second, server.WriteTimeout)
+	assert.Equal(t, time.Minute, server.IdleTimeout)
+	assert.Equal(t, 1<<16, server.MaxHeaderBytes)
+	assert.True(t, server != d.Server)
+
+	d.Server = nil
+	server = d.newServer(":8080", d)
+	assert.Equal(t, time.Duration(0), server.ReadTimeout)
+}
+
+// 获取一个空闲的本地地址
+func freeAddr(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer l.Close()
+	return l.Addr().String()
+}
+
+// 等待服务可以接受连接
+func waitListening(t *testing.T, addr string) {
+	for i := 0; i < 100; i++ {
+		if conn, err := net.Dial("tcp", addr); err == nil {
+			conn.Close()
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("server on %s not started", addr)
+}
+
+func TestRunWithContext(t *testing.T) {
+	addr := freeAddr(t)
+	started := make(chan struct{})
+	d := New()
+	d.GET("/slow", func(c *Context) error {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		c.String(http.StatusOK, "done")
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() {
+		result <- d.RunWithContext(ctx, addr)
+	}()
+	waitListening(t, addr)
+
+	body := make(chan string, 1)
+	go func() {
+		res, err := http.Get("http://" + addr + "/slow")
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		b, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		body <- string(b)
+	}()
+	<-started
+	cancel()
+
+	// 处理中的请求完成后服务才会退出
+	assert.Equal(t, "done", <-body)
+	assert.Nil(t, <-result)
+	_, err := net.Dial("tcp", addr)
+	assert.NotNil(t, err)
+}
+
+func TestMountInServeMux(t *testing.T) {
+	d := New()
+	d.GET("/users/:id", func(c *Context) error {
+		c.String(http.StatusOK, "user %s", c.Param("id"))
+		return nil
+	})
+	mux := http.NewServeMux()
+	mux.Handle("/api/", http.StripPrefix("/api", d))
+
+	res := httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/api/users/7", nil))
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "user 7", res.Body.String())
+}
+
+func TestWrapH(t *testing.T) {
+	d := New()
+	d.GET("/legacy", WrapF(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Legacy", "1")
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(r.URL.Path))
+	}))
+
+	res := httptest.NewRecorder()
+	d.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/legacy", nil))
+	assert.Equal(t, http.StatusAccepted, res.Code)
+	assert.Equal(t, "1", res.Header().Get("X-Legacy"))
+	assert.Equal(t, "/legacy", res.Body.String())
+}
+
+func TestTLSConfigTemplate(t *testing.T) {
+	d := New()
+	assert.Equal(t, uint16(tls.VersionTLS12), d.tlsConfig().MinVersion)
+
+	d.TLSConfig = &tls.Config{
+		MinVersion:       tls.VersionTLS13,
+		CipherSuites:     []uint16{tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384},
+		CurvePreferences: []tls.CurveID{tls.X25519},
+		NextProtos:       []string{"http/1.1"},
+	}
+	d.TLSClientCAs = x509.NewCertPool()
+	config := d.tlsConfig()
+	assert.Equal(t, uint16(tls.VersionTLS13), config.MinVersion)
+	assert.Equal(t, d.TLSConfig.CipherSuites, config.CipherSuites)
+	assert.Equal(t, d.TLSConfig.CurvePreferences, config.CurvePreferences)
+	assert.Equal(t, []string{"http/1.1"}, config.NextProtos)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, config.ClientAuth)
+	// 模板本身不被修改
+	assert.Equal(t, tls.NoClientCert, d.TLSConfig.ClientAuth)
+	assert.Nil(t, d.TLSConfig.ClientCAs)
+}
+
+func TestClientCertificate(t *testing.T) {
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "partner"}}
+	c := &Context{Request: httptest.NewRequest(http.MethodGet, "/", nil)}
+	assert.Nil(t, c.ClientCertificate())
+	assert.Nil(t, c.PeerCertificate())
+
+	// 未经验证的证书不作为客户端证书返回
+	c.Request.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+	assert.Nil(t, c.ClientCertificate())
+	assert.Equal(t, cert, c.PeerCertificate())
+
+	c.Request.TLS.VerifiedChains = [][]*x509.Certificate{{cert}}
+	assert.Equal(t, cert, c.ClientCertificate())
+}
+
+func TestRunEphemeralPort(t *testing.T) {
+	d := New()
+	d.GET("/", func(c *Context) error {
+		c.String(http.StatusOK, "ok")
+		return nil
+	})
+	assert.Nil(t, d.Addr())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() {
+		result <- d.RunWithContext(ctx, "localhost:0")
+	}()
+	<-d.Started()
+
+	addr := d.Addr().(*net.TCPAddr)
+	assert.NotEqual(t, 0, addr.Port)
+	res, err := http.Get("http://" + addr.String() + "/")
+	assert.Nil(t, err)
+	body, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	assert.Equal(t, "ok", string(body))
+
+	// 端口被占用时返回绑定错误
+	assert.NotNil(t, New().Run(addr.String()))
+
+	cancel()
+	assert.Nil(t, <-result)
+}
+
+func TestStartStop(t *testing.T) {
+	d := New()
+	d.GET("/", func(c *Context) error {
+		c.String(http.StatusOK, "ok")
+		return nil
+	})
+	assert.Equal(t, ServerNotRunningErr, d.Stop(context.Background()))
+
+	assert.Nil(t, d.Start("localhost:0"))
+	assert.Equal(t, ServerRunningErr, d.Start("localhost:0"))
+	addr := d.Addr().String()
+	res, err := http.Get("http://" + addr + "/")
+	assert.Nil(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	// 绑定失败时返回错误而不是终止进程
+	assert.NotNil(t, New().Start(addr))
+
+	assert.Nil(t, d.Stop(context.Background()))
+	_, err = net.Dial("tcp", addr)
+	assert.NotNil(t, err)
+
+	// 关闭后可以再次启动
+	assert.Nil(t, d.Start("localhost:0"))
+	assert.True(t, d.Ready())
+	assert.NotNil(t, d.Addr())
+	assert.Nil(t, d.Stop(context.Background()))
+}
+
+func TestRunReturnsAfterStop(t *testing.T) {
+	d := New()
+	result := make(chan error, 1)
+	go func() {
+		result <- d.Run("localhost:0")
+	}()
+	<-d.Started()
+	assert.Nil(t, d.Stop(context.Background()))
+	assert.Nil(t, <-result)
+}
+
+func TestStopServeAndRunServer(t *testing.T) {
+	runs := map[string]func(d *Doris) error{
+		"Serve": func(d *Doris) error {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				return err
+			}
+			return d.Serve(listener)
+		},
+		"RunServer": func(d *Doris) error {
+			return d.RunServer(&http.Server{Addr: "127.0.0.1:0"})
+		},
+	}
+	for name, run := range runs {
+		stopped := false
+		d := New()
+		d.OnShutdown(func(ctx context.Context) error {
+			stopped = true
+			return nil
+		})
+		result := make(chan error, 1)
+		go func() {
+			result <- run(d)
+		}()
+		<-d.Started()
+		assert.Equal(t, ServerRunningErr, d.Start("127.0.0.1:0"), name)
+		assert.Nil(t, d.Stop(context.Background()), name)
+		assert.Nil(t, <-result, name)
+		assert.True(t, stopped, name)
+	}
+}
+
+// Accept返回错误的listener
+type brokenListener struct {
+	net.Listener
+}
+
+func (l brokenListener) Accept() (net.Conn, error) {
+	return nil, errors.New("accept failed")
+}
+
+func TestServeErrorClearsState(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	stopped := false
+	d := New()
+	d.OnShutdown(func(ctx context.Context) error {
+		stopped = true
+		return nil
+	})
+
+	err = d.Serve(brokenListener{listener})
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "accept failed", err.Error())
+	}
+	// 服务出错时执行关闭钩子，之后可以再次启动
+	assert.True(t, stopped)
+	assert.Equal(t, ServerNotRunningErr, d.Stop(context.Background()))
+	assert.Nil(t, d.Start("127.0.0.1:0"))
+	assert.Nil(t, d.Stop(context.Background()))
+}
@@ -0,0 +1,26 @@
+module github.com/leaderwolfpipi/doris/dorisquic
+
+go 1.26.0
+
+require (
+	github.com/leaderwolfpipi/doris v0.0.0-00010101000000-000000000000
+	github.com/quic-go/quic-go v0.63.0
+	github.com/stretchr/testify v1.12.1
+)
+
+require (
+	github.com/golang/protobuf v1.3.3 // indirect
+	github.com/json-iterator/go v1.1.12 // indirect
+	github.com/leaderwolfpipi/logger v0.0.0-20200105024148-3e9e4bc27bd3 // indirect
+	github.com/leaderwolfpipi/render v0.0.0-20200203051326-e6cdbceef35a // indirect
+	github.com/modern-go/concurrent v0.0.0-20180228061459-e0a39a4cb421 // indirect
+	github.com/modern-go/reflect2 v1.0.2 // indirect
+	github.com/quic-go/qpack v0.6.0 // indirect
+	go.yaml.in/yaml/v3 v3.0.5 // indirect
+	golang.org/x/crypto v0.54.0 // indirect
+	golang.org/x/net v0.56.0 // indirect
+	golang.org/x/sys v0.47.0 // indirect
+	golang.org/x/text v0.40.0 // indirect
+)
+
+replace github.com/leaderwolfpipi/doris => ..
@@ -0,0 +1,32 @@
+// Package dorisquic provides the HTTP/3 (QUIC) server used by
+// (*doris.Doris).RunQUIC. It is a separate module so that only applications
+// using HTTP/3 depend on quic-go. Import it for its side effect:
+//
+//	import _ "github.com/leaderwolfpipi/doris/dorisquic"
+//
+//	d := doris.New()
+//	log.Fatal(d.RunQUIC(":443", "cert.pem", "key.pem"))
+package dorisquic
+
+import (
+	"net/http"
+
+	"github.com/leaderwolfpipi/doris"
+	"github.com/quic-go/quic-go/http3"
+)
+
+func init() {
+	doris.RegisterQUIC(NewServer)
+}
+
+// NewServer returns an HTTP/3 server with the address, handler, TLS config
+// and limits of server.
+func NewServer(server *http.Server) doris.QUICServer {
+	return &http3.Server{
+		Addr:           server.Addr,
+		Handler:        server.Handler,
+		TLSConfig:      server.TLSConfig,
+		MaxHeaderBytes: server.MaxHeaderBytes,
+		IdleTimeout:    server.IdleTimeout,
+	}
+}
@@ -0,0 +1,97 @@
+package dorisquic
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leaderwolfpipi/doris"
+	"github.com/quic-go/quic-go/http3"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeTestCert writes a self-signed certificate for 127.0.0.1 into dir.
+func writeTestCert(t *testing.T, dir string) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	assert.Nil(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.Nil(t, err)
+
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	assert.Nil(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	assert.Nil(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+	return certFile, keyFile
+}
+
+func TestRunQUIC(t *testing.T) {
+	certFile, keyFile := writeTestCert(t, t.TempDir())
+	d := doris.New()
+	d.ShowBanner = false
+	d.GET("/", func(c *doris.Context) error {
+		c.String(http.StatusOK, c.Request.Proto)
+		return nil
+	})
+
+	result := make(chan error, 1)
+	go func() {
+		result <- d.RunQUIC("127.0.0.1:0", certFile, keyFile)
+	}()
+	<-d.Started()
+
+	// The TLS server advertises the UDP port of the HTTP/3 server.
+	tlsConfig := &tls.Config{InsecureSkipVerify: true}
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+	var altSvc string
+	for i := 0; i < 50 && altSvc == ""; i++ {
+		res, err := client.Get("https://" + d.Addr().String() + "/")
+		if !assert.Nil(t, err) {
+			return
+		}
+		res.Body.Close()
+		altSvc = res.Header.Get("Alt-Svc")
+		if altSvc == "" {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	if !assert.True(t, strings.HasPrefix(altSvc, `h3=":`), altSvc) {
+		return
+	}
+	port := strings.TrimPrefix(altSvc, `h3=":`)
+	port = port[:strings.Index(port, `"`)]
+
+	transport := &http3.Transport{TLSClientConfig: tlsConfig}
+	defer transport.Close()
+	res, err := (&http.Client{Transport: transport}).Get("https://127.0.0.1:" + port + "/")
+	if assert.Nil(t, err) {
+		body, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "HTTP/3.0", string(body))
+	}
+
+	assert.Nil(t, d.Stop(context.Background()))
+	assert.Nil(t, <-result)
+}
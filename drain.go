@@ -0,0 +1,77 @@
+package doris
+
+import (
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// 是否可以接收新流量，关闭开始时置为未就绪
+func (doris *Doris) Ready() bool {
+	return atomic.LoadInt32(&doris.draining) == 0
+}
+
+// 获取当前打开的连接数（包括空闲的keep-alive连接）
+func (doris *Doris) OpenConns() int {
+	return int(atomic.LoadInt32(&doris.openConns))
+}
+
+// 内置的就绪探针处理函数，用于Kubernetes的readinessProbe
+// 正常时返回200，关闭开始后返回503，负载均衡据此摘除实例
+// 示例：d.GET("/readyz", d.ReadinessHandler)
+func (doris *Doris) ReadinessHandler(c *Context) error {
+	if !doris.Ready() {
+		c.Json(http.StatusServiceUnavailable, D{"status": "draining"})
+		return nil
+	}
+	c.Json(http.StatusOK, D{"status": "ready"})
+	return nil
+}
+
+// 开始排空连接：就绪探针置为失败并关闭keep-alive，
+// 等待DrainDelay让负载均衡摘除实例后再停止监听
+func (doris *Doris) drain(servers ...serverCloser) {
+	atomic.StoreInt32(&doris.draining, 1)
+	for _, server := range servers {
+		if server, ok := server.(*http.Server); ok {
+			server.SetKeepAlivesEnabled(false)
+		}
+	}
+	if doris.DrainDelay > 0 {
+		time.Sleep(doris.DrainDelay)
+	}
+}
+
+// 获取当前空闲的keep-alive连接数
+func (doris *Doris) IdleConns() int {
+	return int(atomic.LoadInt32(&doris.idleConns))
+}
+
+// 获取正在处理的请求数，可用于限流、过载保护
+func (doris *Doris) ActiveRequests() int {
+	return int(atomic.LoadInt32(&doris.activeRequests))
+}
+
+// 统计打开和空闲的连接数，并调用Server模板中的ConnState
+func (doris *Doris) trackConnState(next func(net.Conn, http.ConnState)) func(net.Conn, http.ConnState) {
+	return func(conn net.Conn, state http.ConnState) {
+		switch state {
+		case http.StateNew:
+			atomic.AddInt32(&doris.openConns, 1)
+		case http.StateHijacked, http.StateClosed:
+			atomic.AddInt32(&doris.openConns, -1)
+		}
+		// 同一连接的状态变化是顺序发生的
+		if state == http.StateIdle {
+			doris.idle.Store(conn, struct{}{})
+			atomic.AddInt32(&doris.idleConns, 1)
+		} else if _, ok := doris.idle.Load(conn); ok {
+			doris.idle.Delete(conn)
+			atomic.AddInt32(&doris.idleConns, -1)
+		}
+		if next != nil {
+			next(conn, state)
+		}
+	}
+}
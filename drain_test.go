@@ -0,0 +1,109 @@
+package doris
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadinessHandler(t *testing.T) {
+	d := New()
+	d.GET("/readyz", d.ReadinessHandler)
+
+	res := httptest.NewRecorder()
+	d.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	d.drain()
+	res = httptest.NewRecorder()
+	d.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, res.Code)
+	var body map[string]string
+	assert.Nil(t, json.Unmarshal(res.Body.Bytes(), &body))
+	assert.Equal(t, "draining", body["status"])
+}
+
+func TestDrainFlipsReadiness(t *testing.T) {
+	addr := freeAddr(t)
+	d := New()
+	d.DrainDelay = 200 * time.Millisecond
+	d.GET("/readyz", d.ReadinessHandler)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() {
+		result <- d.RunWithContext(ctx, addr)
+	}()
+	waitListening(t, addr)
+
+	conn, err := net.Dial("tcp", addr)
+	assert.Nil(t, err)
+	for i := 0; i < 50 && d.OpenConns() == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, 1, d.OpenConns())
+	conn.Close()
+
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	// DrainDelay期间仍在监听，但就绪探针返回失败，且不再保持长连接
+	res, err := http.Get("http://" + addr + "/readyz")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+	assert.True(t, res.Close)
+	res.Body.Close()
+
+	assert.Nil(t, <-result)
+	assert.False(t, d.Ready())
+}
+
+func TestActiveRequestsAndIdleConns(t *testing.T) {
+	d := New()
+	inHandler := make(chan struct{})
+	release := make(chan struct{})
+	d.GET("/slow", func(c *Context) error {
+		assert.Equal(t, 1, d.ActiveRequests())
+		close(inHandler)
+		<-release
+		c.String(http.StatusOK, "ok")
+		return nil
+	})
+	assert.Nil(t, d.Start("localhost:0"))
+	defer d.Stop(context.Background())
+	<-d.Started()
+
+	client := &http.Client{Transport: &http.Transport{}}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		res, err := client.Get("http://" + d.Addr().String() + "/slow")
+		if assert.Nil(t, err) {
+			res.Body.Close()
+		}
+	}()
+	<-inHandler
+	assert.Equal(t, 1, d.Stats().ActiveRequests)
+	assert.Equal(t, 0, d.IdleConns())
+	close(release)
+	<-done
+
+	// 响应完成后连接保持为空闲的keep-alive连接
+	for i := 0; i < 50 && d.IdleConns() == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, 0, d.ActiveRequests())
+	assert.Equal(t, 1, d.IdleConns())
+	assert.Equal(t, 1, d.Stats().OpenConns)
+
+	client.Transport.(*http.Transport).CloseIdleConnections()
+	for i := 0; i < 50 && d.IdleConns() == 1; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, 0, d.IdleConns())
+}
@@ -0,0 +1,93 @@
+package doris
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// 业务错误，将业务错误码与http状态码、描述信息绑定
+// 处理函数返回*CodeError时，HTTPErrorHandler按Status输出{"code": Code, "message": Message}
+type CodeError struct {
+	Code     int    // 业务错误码
+	Status   int    // http状态码
+	Message  string // 描述信息
+	Internal error  // 内部错误，仅在调试模式下展示给客户端
+	stack    stack  // 设置内部错误时的调用栈
+}
+
+// 已注册的业务错误码
+var (
+	errorCodes     = make(map[int]*CodeError)
+	errorCodesLock sync.RWMutex
+)
+
+// 注册业务错误码，同一错误码重复注册时panic
+// 10xxx为框架保留的错误码
+func NewError(code, status int, message string) *CodeError {
+	errorCodesLock.Lock()
+	defer errorCodesLock.Unlock()
+	if _, ok := errorCodes[code]; ok {
+		panic(fmt.Sprintf("doris: error code %d is already registered", code))
+	}
+	e := &CodeError{Code: code, Status: status, Message: message}
+	errorCodes[code] = e
+	return e
+}
+
+// 按业务错误码查找已注册的错误
+func LookupError(code int) (*CodeError, bool) {
+	errorCodesLock.RLock()
+	defer errorCodesLock.RUnlock()
+	e, ok := errorCodes[code]
+	return e, ok
+}
+
+// 获取错误链中的业务错误码，不是*CodeError时返回0
+// 例如：switch doris.ErrorCode(err) { case doris.TokenExpiredErr.Code: ... }
+func ErrorCode(err error) int {
+	var ce *CodeError
+	if errors.As(err, &ce) {
+		return ce.Code
+	}
+	return 0
+}
+
+// 实现error接口
+func (e *CodeError) Error() string {
+	if e.Internal == nil {
+		return e.Message
+	}
+	return e.Message + ": " + e.Internal.Error()
+}
+
+// 返回内部错误，支持errors.Is和errors.As
+func (e *CodeError) Unwrap() error {
+	return e.Internal
+}
+
+// 错误码相同即视为同一错误，使errors.Is(err, TokenExpiredErr)对副本同样成立
+func (e *CodeError) Is(target error) bool {
+	t, ok := target.(*CodeError)
+	return ok && t.Code == e.Code
+}
+
+// 返回附带内部错误的副本，已注册的错误本身不被修改
+func (e *CodeError) WithInternal(err error) *CodeError {
+	cp := *e
+	cp.Internal = err
+	cp.stack = callers(1)
+	return &cp
+}
+
+// 返回设置内部错误时的调用栈
+func (e *CodeError) StackTrace() []string {
+	return e.stack.format()
+}
+
+// 返回替换描述信息的副本，已注册的错误本身不被修改
+func (e *CodeError) WithMessage(message string) *CodeError {
+	cp := *e
+	cp.Message = message
+	return &cp
+}
@@ -0,0 +1,73 @@
+package doris
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewError(t *testing.T) {
+	orderMissing := NewError(20001, http.StatusNotFound, "Order not found")
+	defer func() {
+		errorCodesLock.Lock()
+		delete(errorCodes, 20001)
+		errorCodesLock.Unlock()
+	}()
+
+	e, ok := LookupError(20001)
+	assert.True(t, ok)
+	assert.Equal(t, orderMissing, e)
+	assert.Panics(t, func() { NewError(20001, http.StatusBadRequest, "dup") })
+
+	internal := errors.New("no rows")
+	wrapped := orderMissing.WithInternal(internal)
+	assert.Nil(t, orderMissing.Internal)
+	assert.Equal(t, "Order not found: no rows", wrapped.Error())
+	assert.True(t, errors.Is(wrapped, orderMissing))
+	assert.True(t, errors.Is(wrapped, internal))
+	assert.False(t, errors.Is(wrapped, TokenExpiredErr))
+
+	e, ok = LookupError(TokenExpiredErr.Code)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusUnauthorized, e.Status)
+}
+
+func TestCodeErrorResponse(t *testing.T) {
+	d := New()
+	d.GET("/", func(c *Context) error {
+		return PermissionDeniedErr.WithInternal(errors.New("role guest"))
+	})
+
+	res := httptest.NewRecorder()
+	d.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusForbidden, res.Code)
+	assert.JSONEq(t, `{"code":10411,"message":"Insufficient permissions"}`, res.Body.String())
+}
+
+func TestSentinelErrors(t *testing.T) {
+	wrapped := fmt.Errorf("bind order: %w", BodyTooLargeErr)
+	assert.True(t, errors.Is(wrapped, BodyTooLargeErr))
+	assert.False(t, errors.Is(wrapped, UnsupportedMediaTypeErr))
+	assert.Equal(t, BodyTooLargeErr.Code, ErrorCode(wrapped))
+	assert.Equal(t, 0, ErrorCode(errors.New("plain")))
+
+	var ce *CodeError
+	assert.True(t, errors.As(SignatureReplayErr.WithInternal(errors.New("redis")), &ce))
+	assert.Equal(t, http.StatusUnauthorized, ce.Status)
+
+	d := New()
+	d.POST("/", func(c *Context) error {
+		return c.Bind(&struct{}{})
+	})
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("<x/>"))
+	req.Header.Set(HeaderContentType, "application/x-unknown")
+	d.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusUnsupportedMediaType, res.Code)
+	assert.JSONEq(t, `{"code":10102,"message":"Unsupported media type"}`, res.Body.String())
+}
@@ -0,0 +1,86 @@
+// 调试模式下的错误详情
+// Debug为true时错误响应附带内部错误和调用栈，生产环境不输出
+package doris
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// 调用栈最大深度
+const maxStackDepth = 32
+
+type (
+	// 调试模式下错误响应附带的详情
+	ErrorDebug struct {
+		Internal string   `json:"internal,omitempty"` // 内部错误信息
+		Stack    []string `json:"stack,omitempty"`    // 调用栈，格式为"函数 文件:行号"
+	}
+
+	// 携带调用栈的错误
+	// HTTPError.SetInternal和CodeError.WithInternal会记录调用位置的调用栈
+	StackTracer interface {
+		StackTrace() []string
+	}
+
+	// 调用栈的程序计数器
+	stack []uintptr
+)
+
+// 记录调用栈，skip为需要跳过的调用层数
+func callers(skip int) stack {
+	pcs := make([]uintptr, maxStackDepth)
+	return stack(pcs[:runtime.Callers(skip+2, pcs)])
+}
+
+// 格式化调用栈，跳过运行时内部的帧
+func (s stack) format() []string {
+	if len(s) == 0 {
+		return nil
+	}
+	lines := make([]string, 0, len(s))
+	frames := runtime.CallersFrames(s)
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "runtime.") {
+			lines = append(lines, fmt.Sprintf("%s %s:%d", frame.Function, frame.File, frame.Line))
+		}
+		if !more {
+			break
+		}
+	}
+	return lines
+}
+
+// 获取错误的调试详情，非调试模式下返回nil
+// 优先使用错误自身记录的调用栈，否则记录错误处理时的调用栈
+func (c *Context) errorDebug(err error) *ErrorDebug {
+	if c.Doris == nil || !c.Doris.Debug || err == nil {
+		return nil
+	}
+	debug := &ErrorDebug{}
+	var he *HTTPError
+	var ce *CodeError
+	switch {
+	case errors.As(err, &ce):
+		if ce.Internal != nil {
+			debug.Internal = ce.Internal.Error()
+		}
+	case errors.As(err, &he):
+		if he.Internal != nil {
+			debug.Internal = he.Internal.Error()
+		}
+	default:
+		debug.Internal = err.Error()
+	}
+	var st StackTracer
+	if errors.As(err, &st) {
+		debug.Stack = st.StackTrace()
+	}
+	if len(debug.Stack) == 0 {
+		debug.Stack = callers(1).format()
+	}
+	return debug
+}
@@ -0,0 +1,52 @@
+// 错误响应的json结构
+package doris
+
+// 内置错误响应的json结构，零值输出{"code": code, "message": message}
+// 例如：d.ErrorEnvelope = doris.ErrorEnvelope{CodeKey: "errcode", MessageKey: "errmsg", Fields: doris.D{"success": false}}
+type ErrorEnvelope struct {
+	CodeKey    string // 错误码的键名，默认为"code"
+	MessageKey string // 错误信息的键名，默认为"message"
+	DataKey    string // 附加数据的键名，默认为"data"，如字段校验错误列表
+	Fields     D      // 每个错误响应都附带的固定字段
+}
+
+// 按错误响应结构生成响应内容，data为nil时不输出
+func (e *ErrorEnvelope) body(code int, message, data interface{}) D {
+	body := make(D, len(e.Fields)+3)
+	for k, v := range e.Fields {
+		body[k] = v
+	}
+	body[orDefault(e.CodeKey, "code")] = code
+	body[orDefault(e.MessageKey, "message")] = message
+	if data != nil {
+		body[orDefault(e.DataKey, "data")] = data
+	}
+	return body
+}
+
+// 按Doris.ErrorEnvelope输出json格式的错误响应
+// 中间件输出错误信息时应使用该方法，使错误响应与接口约定保持一致
+func (c *Context) ErrorJson(status, code int, message interface{}, data ...interface{}) {
+	c.Json(status, c.errorBody(code, message, data...))
+}
+
+// 生成错误响应内容
+func (c *Context) errorBody(code int, message interface{}, data ...interface{}) D {
+	var envelope ErrorEnvelope
+	if c.Doris != nil {
+		envelope = c.Doris.ErrorEnvelope
+	}
+	var payload interface{}
+	if len(data) > 0 {
+		payload = data[0]
+	}
+	return envelope.body(code, message, payload)
+}
+
+// 字符串为空时返回默认值
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
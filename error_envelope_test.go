@@ -0,0 +1,38 @@
+package doris
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorEnvelope(t *testing.T) {
+	d := New()
+	d.GET("/denied", func(c *Context) error {
+		return PermissionDeniedErr
+	})
+	d.GET("/quota", func(c *Context) error {
+		c.ErrorJson(http.StatusTooManyRequests, 20001, "Quota exceeded", D{"retry_after": 30})
+		return nil
+	})
+	serve := func(path string) string {
+		res := httptest.NewRecorder()
+		d.ServeHTTP(res, httptest.NewRequest(http.MethodGet, path, nil))
+		return res.Body.String()
+	}
+
+	assert.JSONEq(t, `{"code":10411,"message":"Insufficient permissions"}`, serve("/denied"))
+	assert.JSONEq(t, `{"code":20001,"message":"Quota exceeded","data":{"retry_after":30}}`, serve("/quota"))
+
+	d.ErrorEnvelope = ErrorEnvelope{
+		CodeKey:    "errcode",
+		MessageKey: "errmsg",
+		DataKey:    "detail",
+		Fields:     D{"success": false},
+	}
+	assert.JSONEq(t, `{"errcode":10411,"errmsg":"Insufficient permissions","success":false}`, serve("/denied"))
+	assert.JSONEq(t, `{"errcode":404,"errmsg":"Not found","success":false}`, serve("/missing"))
+	assert.JSONEq(t, `{"errcode":20001,"errmsg":"Quota exceeded","success":false,"detail":{"retry_after":30}}`, serve("/quota"))
+}
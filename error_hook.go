@@ -0,0 +1,46 @@
+// 请求错误钩子
+package doris
+
+import "fmt"
+
+// 请求以错误结束时执行的钩子，用于统计错误率、告警等
+type ErrorHook func(c *Context, err error)
+
+// 注册请求错误钩子，按注册顺序执行
+// 处理函数返回错误、发生panic或响应状态码为4xx/5xx时，每个请求执行一次
+// 钩子在响应写出后执行，不应再修改响应
+func (doris *Doris) OnError(fn ErrorHook) {
+	doris.errorHooks = append(doris.errorHooks, fn)
+}
+
+// 请求结束后判断是否以错误结束，并执行错误钩子
+// 优先使用处理链中记录的最后一个错误，否则按状态码生成*HTTPError
+func (doris *Doris) notifyError(c *Context) {
+	if len(doris.errorHooks) == 0 {
+		return
+	}
+	var err error
+	if last := c.Errors.Last(); last != nil {
+		err = last.Err
+	} else if status := c.Response.Status(); status >= 400 {
+		err = NewHTTPError(status)
+	}
+	if err != nil {
+		doris.runErrorHooks(c, err)
+	}
+}
+
+// 未被恢复的panic：执行错误钩子后继续向上抛出，由net/http处理
+func (doris *Doris) notifyPanic(c *Context) {
+	if r := recover(); r != nil {
+		doris.runErrorHooks(c, fmt.Errorf("panic: %v", r))
+		panic(r)
+	}
+}
+
+// 执行错误钩子
+func (doris *Doris) runErrorHooks(c *Context, err error) {
+	for _, hook := range doris.errorHooks {
+		hook(c, err)
+	}
+}
@@ -0,0 +1,47 @@
+package doris
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnError(t *testing.T) {
+	var got []string
+	d := New()
+	d.OnError(func(c *Context, err error) {
+		got = append(got, c.Request.URL.Path+" "+err.Error())
+	})
+	d.GET("/ok", func(c *Context) error {
+		c.String(http.StatusOK, "ok")
+		return nil
+	})
+	d.GET("/fail", func(c *Context) error {
+		return errors.New("db down")
+	})
+	d.GET("/teapot", func(c *Context) error {
+		c.String(http.StatusTeapot, "short and stout")
+		return nil
+	})
+	d.GET("/panic", func(c *Context) error {
+		panic("boom")
+	})
+	serve := func(path string) {
+		d.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	serve("/ok")
+	serve("/fail")
+	serve("/teapot")
+	serve("/missing")
+	assert.Panics(t, func() { serve("/panic") })
+	assert.Equal(t, []string{
+		"/fail db down",
+		"/teapot code=418, message=I'm a teapot",
+		"/missing code=404, message=Not found",
+		"/panic panic: boom",
+	}, got)
+}
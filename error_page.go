@@ -0,0 +1,63 @@
+package doris
+
+import "strings"
+
+// 错误页面模板的渲染数据
+type ErrorPageData struct {
+	Status    int         // http状态码
+	Code      int         // 错误码，业务错误时为业务错误码，否则与Status相同
+	Message   interface{} // 错误信息
+	Path      string      // 请求路径
+	RequestID string      // 请求ID
+	Debug     *ErrorDebug // 调试详情，仅调试模式下不为nil
+}
+
+// 注册状态码对应的html错误页面，name为HTMLRender中的模板名称（如"errors/404"）
+// 客户端接受text/html时渲染错误页面，其他客户端仍输出json格式的错误信息
+// 模板数据为ErrorPageData，使用HTMLRender.Layout配置的默认布局
+func (doris *Doris) ErrorPage(status int, name string) {
+	if doris.errorPages == nil {
+		doris.errorPages = make(map[int]string)
+	}
+	doris.errorPages[status] = name
+}
+
+// 输出错误响应：客户端接受html且注册了错误页面时渲染页面，否则输出json
+// debug不为nil时响应附带调试详情
+func (c *Context) writeError(status, code int, message interface{}, debug *ErrorDebug) {
+	if c.renderErrorPage(status, code, message, debug) {
+		return
+	}
+	body := c.errorBody(code, message)
+	if debug != nil {
+		body["debug"] = debug
+	}
+	c.Json(status, body)
+}
+
+// 渲染错误页面，未注册页面、客户端不接受html或模板渲染失败时返回false
+func (c *Context) renderErrorPage(status, code int, message interface{}, debug *ErrorDebug) bool {
+	doris := c.Doris
+	if doris == nil || doris.HTMLRender == nil {
+		return false
+	}
+	name, ok := doris.errorPages[status]
+	if !ok || !strings.Contains(c.Request.Header.Get(HeaderAccept), "text/html") {
+		return false
+	}
+	buf, err := doris.HTMLRender.execute(name, doris.HTMLRender.Layout, ErrorPageData{
+		Status:    status,
+		Code:      code,
+		Message:   message,
+		Path:      c.Request.URL.Path,
+		RequestID: c.RequestID(),
+		Debug:     debug,
+	})
+	if err != nil {
+		// 模板出错时退回json，避免错误页面本身导致panic
+		c.Error(err)
+		return false
+	}
+	c.Html(status, buf.String())
+	return true
+}
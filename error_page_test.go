@@ -0,0 +1,54 @@
+package doris
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorPage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "doris-views")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "errors"), 0755))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "errors", "404.html"),
+		[]byte(`<h1>{{ .Status }} {{ .Message }}</h1><p>{{ .Path }}</p>`), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "errors", "broken.html"),
+		[]byte(`{{ .Missing.Field }}`), 0644))
+
+	d := New()
+	d.HTMLRender = NewHTMLRender(dir)
+	d.ErrorPage(http.StatusNotFound, "errors/404")
+	d.ErrorPage(http.StatusForbidden, "errors/broken")
+	d.GET("/secret", func(c *Context) error {
+		return NewHTTPError(http.StatusForbidden)
+	})
+	serve := func(path, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set(HeaderAccept, accept)
+		res := httptest.NewRecorder()
+		d.ServeHTTP(res, req)
+		return res
+	}
+
+	res := serve("/missing", "text/html,application/xhtml+xml")
+	assert.Equal(t, http.StatusNotFound, res.Code)
+	assert.True(t, strings.HasPrefix(res.Header().Get(HeaderContentType), "text/html"))
+	assert.Equal(t, "<h1>404 Not found</h1><p>/missing</p>", res.Body.String())
+
+	// json客户端仍然输出结构化的错误信息
+	res = serve("/missing", "application/json")
+	assert.Equal(t, http.StatusNotFound, res.Code)
+	assert.JSONEq(t, `{"code":404,"message":"Not found"}`, res.Body.String())
+
+	// 模板渲染失败时退回json
+	res = serve("/secret", "text/html")
+	assert.Equal(t, http.StatusForbidden, res.Code)
+	assert.JSONEq(t, `{"code":403,"message":"Forbidden"}`, res.Body.String())
+}
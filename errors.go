@@ -3,10 +3,202 @@ package doris
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
 )
 
-// Errors
+// 错误类型
+type ErrorType uint64
+
+// 错误类型定义
+const (
+	ErrorTypePrivate ErrorType = 1 << iota // 私有错误，仅用于记录日志
+	ErrorTypePublic                        // 公开错误，可以展示给客户端
+	ErrorTypeBind                          // 参数绑定错误
+	ErrorTypeRender                        // 响应渲染错误
+
+	ErrorTypeAny ErrorType = 1<<64 - 1 // 任意类型
+)
+
+type (
+	// 处理链中收集的错误信息
+	Error struct {
+		Err     error       // 原始错误
+		Type    ErrorType   // 错误类型
+		Handler string      // 产生错误的处理函数名称
+		Meta    interface{} // 附加的元数据
+	}
+
+	// 错误信息列表
+	errorMsgs []*Error
+
+	// 请求过程中出现的错误提示
+	// 处理函数返回*HTTPError时，由HTTPErrorHandler按Code和Message输出响应
+	HTTPError struct {
+		Code     int         `json:"-"`       // 错误编号
+		Message  interface{} `json:"message"` // 错误信息
+		Internal error       `json:"-"`       // 内部错误，仅在调试模式下展示给客户端
+		stack    stack       // 设置内部错误时的调用栈
+	}
+)
+
+// 创建HTTPError，未指定message时使用状态码对应的描述信息
+func NewHTTPError(code int, message ...interface{}) *HTTPError {
+	he := &HTTPError{Code: code, Message: StatusMessage(code)}
+	if len(message) > 0 {
+		he.Message = message[0]
+	}
+	return he
+}
+
+// 实现error接口
+func (he *HTTPError) Error() string {
+	if he.Internal == nil {
+		return fmt.Sprintf("code=%d, message=%v", he.Code, he.Message)
+	}
+	return fmt.Sprintf("code=%d, message=%v, internal=%v", he.Code, he.Message, he.Internal)
+}
+
+// 设置内部错误
+func (he *HTTPError) SetInternal(err error) *HTTPError {
+	he.Internal = err
+	he.stack = callers(1)
+	return he
+}
+
+// 返回设置内部错误时的调用栈
+func (he *HTTPError) StackTrace() []string {
+	return he.stack.format()
+}
+
+// 返回内部错误，支持errors.Is和errors.As
+func (he *HTTPError) Unwrap() error {
+	return he.Internal
+}
+
+// 默认的http错误处理函数，按Doris.ErrorEnvelope输出json，默认为{"code": code, "message": message}
+// *CodeError按Status输出业务错误码，*HTTPError按其Code和Message输出；
+// 其他错误输出500，调试模式下message为错误信息
+// 调试模式下响应附带debug字段，包含内部错误和调用栈
+// 错误信息按请求语言从RegisterErrorMessages注册的目录中本地化，浏览器请求可通过ErrorPage输出html页面
+// 响应已经写入时（如中间件已输出错误信息后返回错误）不再输出
+func DefaultHTTPErrorHandler(err error, c *Context) {
+	if c.Response.Written() {
+		return
+	}
+	var ce *CodeError
+	if errors.As(err, &ce) {
+		message := ce.Message
+		// 未通过WithMessage自定义的描述信息按请求语言本地化
+		if registered, ok := LookupError(ce.Code); ok && registered.Message == message {
+			message = c.localize(ce.Code, message)
+		}
+		c.writeError(ce.Status, ce.Code, message, c.errorDebug(err))
+		return
+	}
+	var ve ValidationErrors
+	if errors.As(err, &ve) {
+		status := http.StatusUnprocessableEntity
+		c.ErrorJson(status, status, c.localize(status, StatusMessage(status)), ve.localize(c.Locale()))
+		return
+	}
+	var he *HTTPError
+	if !errors.As(err, &he) {
+		he = NewHTTPError(http.StatusInternalServerError)
+		if c.Doris != nil && c.Doris.Debug {
+			he.Message = err.Error()
+		}
+	}
+	message := he.Message
+	// 未自定义的默认描述信息按请求语言本地化
+	if s, ok := message.(string); ok && s == StatusMessage(he.Code) {
+		message = c.localize(he.Code, s)
+	}
+	c.writeError(he.Code, he.Code, message, c.errorDebug(err))
+}
+
+// 实现error接口
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// 设置错误类型
+func (e *Error) SetType(flags ErrorType) *Error {
+	e.Type = flags
+	return e
+}
+
+// 设置附加的元数据
+func (e *Error) SetMeta(data interface{}) *Error {
+	e.Meta = data
+	return e
+}
+
+// 判断是否为指定类型的错误
+func (e *Error) IsType(flags ErrorType) bool {
+	return (e.Type & flags) > 0
+}
+
+// 按类型过滤错误列表
+func (es errorMsgs) ByType(typ ErrorType) errorMsgs {
+	if len(es) == 0 {
+		return nil
+	}
+	if typ == ErrorTypeAny {
+		return es
+	}
+	var result errorMsgs
+	for _, e := range es {
+		if e.IsType(typ) {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// 返回最后一个错误
+func (es errorMsgs) Last() *Error {
+	if length := len(es); length > 0 {
+		return es[length-1]
+	}
+	return nil
+}
+
+// 返回全部错误的描述信息
+func (es errorMsgs) Errors() []string {
+	if len(es) == 0 {
+		return nil
+	}
+	errorStrings := make([]string, len(es))
+	for i, e := range es {
+		errorStrings[i] = e.Error()
+	}
+	return errorStrings
+}
+
+// 格式化输出全部错误
+func (es errorMsgs) String() string {
+	if len(es) == 0 {
+		return ""
+	}
+	var buffer strings.Builder
+	for i, e := range es {
+		fmt.Fprintf(&buffer, "Error #%02d: %s\n", i+1, e.Err)
+		if e.Handler != "" {
+			fmt.Fprintf(&buffer, "     Handler: %s\n", e.Handler)
+		}
+		if e.Meta != nil {
+			fmt.Fprintf(&buffer, "     Meta: %v\n", e.Meta)
+		}
+	}
+	return buffer.String()
+}
+
+// 状态码对应的描述信息
+// 通过RegisterStatusMessage注册，通过StatusMessage读取
 var HTTPErrorMessages = map[int]error{
 	http.StatusOK:                    errors.New("Success"),
 	http.StatusUnsupportedMediaType:  errors.New("Unsupported mediatype"),
@@ -23,23 +215,100 @@ var HTTPErrorMessages = map[int]error{
 	http.StatusServiceUnavailable:    errors.New("Service unavailable"),
 }
 
+// 保护HTTPErrorMessages的并发读写
+var statusMessagesLock sync.RWMutex
+
+// 注册状态码对应的描述信息，覆盖已有的注册
+func RegisterStatusMessage(code int, text string) {
+	statusMessagesLock.Lock()
+	HTTPErrorMessages[code] = errors.New(text)
+	statusMessagesLock.Unlock()
+}
+
+// 获取状态码对应的描述信息
+// 未注册的状态码回退到http.StatusText，未知状态码返回"Status <code>"
+func StatusMessage(code int) string {
+	statusMessagesLock.RLock()
+	err := HTTPErrorMessages[code]
+	statusMessagesLock.RUnlock()
+	if err != nil {
+		return err.Error()
+	}
+	if text := http.StatusText(code); text != "" {
+		return text
+	}
+	return "Status " + strconv.Itoa(code)
+}
+
+// Define request Errors
+// 以下错误均为*CodeError，可通过errors.Is判断类型，通过errors.As获取错误码和http状态码
+var (
+	BodyTooLargeErr         = NewError(10100, http.StatusRequestEntityTooLarge, "Request body too large")
+	SnapshotWriteErr        = NewError(10101, http.StatusInternalServerError, "Can't write to a copied context")
+	UnsupportedMediaTypeErr = NewError(10102, http.StatusUnsupportedMediaType, "Unsupported media type")
+	ProtobufTypeErr         = NewError(10103, http.StatusInternalServerError, "Bind target is not a proto.Message")
+)
+
 // Define jwt Errors
+// 10xxx is system error of the doris
+var (
+	TokenExpiredErr     = NewError(10400, http.StatusUnauthorized, "Token is expired")
+	TokenNotValidYetErr = NewError(10401, http.StatusUnauthorized, "Token not active yet")
+	TokenMalformedErr   = NewError(10402, http.StatusUnauthorized, "That's not even a token")
+	TokenInvalidErr     = NewError(10403, http.StatusUnauthorized, "Couldn't handle this token:")
+	JWTMissingErr       = NewError(10404, http.StatusUnauthorized, "Missing or Malformed JWT")
+	TokenRefreshErr     = NewError(10405, http.StatusUnauthorized, "This token is for refresh!")
+	TokenRevokedErr     = NewError(10406, http.StatusUnauthorized, "Token has been revoked")
+	TokenReusedErr      = NewError(10407, http.StatusUnauthorized, "Refresh token has already been used")
+	TokenIssuerErr      = NewError(10408, http.StatusUnauthorized, "Token issuer is not accepted")
+	TokenAudienceErr    = NewError(10409, http.StatusUnauthorized, "Token audience is not accepted")
+	TokenClaimErr       = NewError(10410, http.StatusUnauthorized, "Token is missing a required claim")
+	PermissionDeniedErr = NewError(10411, http.StatusForbidden, "Insufficient permissions")
+)
+
+// Define basic auth Errors
+var (
+	BasicAuthMissingErr = NewError(10412, http.StatusUnauthorized, "Missing or malformed basic auth")
+	BasicAuthInvalidErr = NewError(10413, http.StatusUnauthorized, "Invalid username or password")
+)
+
+// Define request signature Errors
 var (
-	TokenExpiredErr     error = errors.New("Token is expired")
-	TokenNotValidYetErr error = errors.New("Token not active yet")
-	TokenMalformedErr   error = errors.New("That's not even a token")
-	TokenInvalidErr     error = errors.New("Couldn't handle this token:")
-	JWTMissingErr       error = errors.New("Missing or Malformed JWT")
-	TokenRefreshErr     error = errors.New("This token is for refresh!")
+	SignatureMissingErr = NewError(10414, http.StatusUnauthorized, "Missing request signature")
+	SignatureInvalidErr = NewError(10415, http.StatusUnauthorized, "Invalid request signature")
+	SignatureExpiredErr = NewError(10416, http.StatusUnauthorized, "Request timestamp is outside the allowed window")
+	SignatureReplayErr  = NewError(10417, http.StatusUnauthorized, "Request nonce has already been used")
+)
+
+// Define client certificate Errors
+var (
+	ClientCertMissingErr = NewError(10418, http.StatusUnauthorized, "Missing client certificate")
+)
+
+// Define server Errors
+var (
+	AutoTLSDomainsErr   error = errors.New("Auto TLS requires at least one domain")
+	NoEndpointErr       error = errors.New("No endpoint to listen on")
+	GracefulListenerErr error = errors.New("Graceful restart requires a TCP listener")
+	SystemdListenerErr  error = errors.New("No socket passed by systemd (LISTEN_FDS)")
+	ServerRunningErr    error = errors.New("Server is already running")
+	ServerNotRunningErr error = errors.New("Server is not running")
+	QUICUnavailableErr  error = errors.New("HTTP/3 requires importing github.com/leaderwolfpipi/doris/dorisquic")
 )
 
 // define jwt err code
-// 10xxx is system error of the doris
+// Deprecated: 使用对应错误的Code字段，如TokenExpiredErr.Code
 var (
-	TokenExpired     int = 10400
-	TokenNotValidYet int = 10401
-	TokenMalformed   int = 10402
-	TokenInvalid     int = 10403
-	JWTMissing       int = 10404
-	TokenRefresh     int = 10405
+	TokenExpired     int = TokenExpiredErr.Code
+	TokenNotValidYet int = TokenNotValidYetErr.Code
+	TokenMalformed   int = TokenMalformedErr.Code
+	TokenInvalid     int = TokenInvalidErr.Code
+	JWTMissing       int = JWTMissingErr.Code
+	TokenRefresh     int = TokenRefreshErr.Code
+	TokenRevoked     int = TokenRevokedErr.Code
+	TokenReused      int = TokenReusedErr.Code
+	TokenIssuer      int = TokenIssuerErr.Code
+	TokenAudience    int = TokenAudienceErr.Code
+	TokenClaim       int = TokenClaimErr.Code
+	PermissionDenied int = PermissionDeniedErr.Code
 )
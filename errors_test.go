@@ -0,0 +1,150 @@
+package doris
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusMessage(t *testing.T) {
+	assert.Equal(t, "Not found", StatusMessage(http.StatusNotFound))
+	assert.Equal(t, "Created", StatusMessage(http.StatusCreated))
+	assert.Equal(t, "I'm a teapot", StatusMessage(http.StatusTeapot))
+	assert.Equal(t, "Status 599", StatusMessage(599))
+
+	RegisterStatusMessage(599, "Upstream gave up")
+	defer func() {
+		statusMessagesLock.Lock()
+		delete(HTTPErrorMessages, 599)
+		statusMessagesLock.Unlock()
+	}()
+	assert.Equal(t, "Upstream gave up", StatusMessage(599))
+}
+
+func TestHTTPError(t *testing.T) {
+	internal := errors.New("connection refused")
+	he := NewHTTPError(http.StatusBadGateway).SetInternal(internal)
+	assert.Equal(t, "Bad gateway", he.Message)
+	assert.Equal(t, "code=502, message=Bad gateway, internal=connection refused", he.Error())
+	assert.True(t, errors.Is(he, internal))
+	assert.Equal(t, "teapot", NewHTTPError(http.StatusTeapot, "teapot").Message)
+}
+
+func TestHandlerReturnedError(t *testing.T) {
+	var errs []string
+	d := New()
+	d.Use(func(c *Context) error {
+		c.Next()
+		errs = c.Errors.Errors()
+		return nil
+	})
+	d.GET("/teapot", func(c *Context) error {
+		return NewHTTPError(http.StatusTeapot, "short and stout")
+	})
+	d.GET("/boom", func(c *Context) error {
+		return errors.New("db password is hunter2")
+	})
+
+	res := httptest.NewRecorder()
+	d.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/teapot", nil))
+	assert.Equal(t, http.StatusTeapot, res.Code)
+	assert.JSONEq(t, `{"code":418,"message":"short and stout"}`, res.Body.String())
+	assert.Equal(t, []string{"code=418, message=short and stout"}, errs)
+
+	// 普通错误不向客户端泄露错误信息
+	res = httptest.NewRecorder()
+	d.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/boom", nil))
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+	assert.JSONEq(t, `{"code":500,"message":"Internal server error"}`, res.Body.String())
+	assert.Equal(t, []string{"db password is hunter2"}, errs)
+
+	d.Debug = true
+	res = httptest.NewRecorder()
+	d.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/boom", nil))
+	var body struct {
+		Message string
+		Debug   ErrorDebug
+	}
+	assert.Nil(t, json.Unmarshal(res.Body.Bytes(), &body))
+	assert.Equal(t, "db password is hunter2", body.Message)
+	assert.Equal(t, "db password is hunter2", body.Debug.Internal)
+}
+
+func TestErrorDebug(t *testing.T) {
+	d := New()
+	d.GET("/upstream", func(c *Context) error {
+		return NewHTTPError(http.StatusBadGateway).SetInternal(errors.New("connection refused"))
+	})
+	d.GET("/denied", func(c *Context) error {
+		return PermissionDeniedErr.WithInternal(errors.New("role guest"))
+	})
+	serve := func(path string) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		d.ServeHTTP(res, httptest.NewRequest(http.MethodGet, path, nil))
+		return res
+	}
+
+	// 生产环境不输出调试详情
+	res := serve("/upstream")
+	assert.JSONEq(t, `{"code":502,"message":"Bad gateway"}`, res.Body.String())
+
+	d.Debug = true
+	for _, path := range []string{"/upstream", "/denied"} {
+		var body struct {
+			Debug *ErrorDebug
+		}
+		res = serve(path)
+		assert.Nil(t, json.Unmarshal(res.Body.Bytes(), &body))
+		assert.NotNil(t, body.Debug)
+		assert.NotEmpty(t, body.Debug.Stack)
+		// 调用栈从设置内部错误的位置开始
+		assert.Contains(t, body.Debug.Stack[0], "TestErrorDebug")
+	}
+	assert.Contains(t, res.Body.String(), `"internal":"role guest"`)
+}
+
+func TestMiddlewareErrorAbortsChain(t *testing.T) {
+	called := false
+	d := New()
+	d.GET("/", func(c *Context) error {
+		return NewHTTPError(http.StatusForbidden)
+	}, func(c *Context) error {
+		called = true
+		return nil
+	})
+
+	res := httptest.NewRecorder()
+	d.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusForbidden, res.Code)
+	assert.False(t, called)
+}
+
+func TestCustomHTTPErrorHandler(t *testing.T) {
+	d := New()
+	d.HTTPErrorHandler = func(err error, c *Context) {
+		c.String(http.StatusServiceUnavailable, "custom: %s", err)
+	}
+	d.GET("/", func(c *Context) error {
+		return errors.New("down")
+	})
+	// 已经输出响应后返回的错误不会被再次输出
+	d.GET("/written", func(c *Context) error {
+		c.String(http.StatusUnauthorized, "denied")
+		return errors.New("denied")
+	})
+
+	res := httptest.NewRecorder()
+	d.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, res.Code)
+	assert.Equal(t, "custom: down", res.Body.String())
+
+	d.HTTPErrorHandler = nil
+	res = httptest.NewRecorder()
+	d.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/written", nil))
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+	assert.Equal(t, "denied", res.Body.String())
+}
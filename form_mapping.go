@@ -0,0 +1,251 @@
+// 表单和查询参数到结构体的映射
+// 支持基础类型、time.Time、time.Duration、切片、指针以及点号分隔的嵌套结构体
+package doris
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 绑定相关的结构体标签
+const (
+	formTag       = "param"       // 参数名称标签，"-"表示忽略
+	timeFormatTag = "time_format" // 时间格式标签，"unix"表示秒级时间戳
+)
+
+// multipart表单解析时使用的最大内存32M
+const defaultMultipartMemory = 32 << 20
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// 绑定表单参数（包含查询参数和请求体中的表单）
+func bindForm(req *http.Request, obj interface{}) error {
+	if strings.HasPrefix(req.Header.Get(HeaderContentType), MIMEMultipartForm) {
+		if err := req.ParseMultipartForm(defaultMultipartMemory); err != nil {
+			return err
+		}
+	} else if err := req.ParseForm(); err != nil {
+		return err
+	}
+	return mapForm(obj, req.Form)
+}
+
+// 绑定查询参数
+func bindQuery(req *http.Request, obj interface{}) error {
+	return mapForm(obj, req.URL.Query())
+}
+
+// 将参数映射到结构体指针
+func mapForm(ptr interface{}, form map[string][]string) error {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("doris: bind target must be a non-nil struct pointer, got %T", ptr)
+	}
+	return mapStruct(v.Elem(), form, "")
+}
+
+// 映射结构体的各个字段
+// prefix为嵌套结构体的参数名前缀，例如"address."
+func mapStruct(v reflect.Value, form map[string][]string, prefix string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name := sf.Tag.Get(formTag)
+		if name == "-" {
+			continue
+		}
+		// 跳过非导出字段
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue
+		}
+		field := v.Field(i)
+		// 未指定标签的匿名字段平铺处理
+		if sf.Anonymous && name == "" {
+			if field.Kind() == reflect.Ptr {
+				if sf.Type.Elem().Kind() != reflect.Struct || !field.CanSet() {
+					continue
+				}
+				if field.IsNil() {
+					field.Set(reflect.New(sf.Type.Elem()))
+				}
+				field = field.Elem()
+			}
+			if field.Kind() == reflect.Struct {
+				if err := mapStruct(field, form, prefix); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if !field.CanSet() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		if err := setField(field, sf, prefix+name, form); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 设置单个字段的值
+func setField(field reflect.Value, sf reflect.StructField, key string, form map[string][]string) error {
+	// 指针字段仅在存在对应参数时分配
+	if field.Kind() == reflect.Ptr {
+		if !hasFormKey(form, key) {
+			return nil
+		}
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+		return setField(field.Elem(), sf, key, form)
+	}
+
+	// 嵌套结构体使用点号分隔的参数名
+	if field.Kind() == reflect.Struct && field.Type() != timeType {
+		return mapStruct(field, form, key+".")
+	}
+
+	values, ok := form[key]
+	if !ok || len(values) == 0 {
+		return nil
+	}
+
+	// 切片支持重复参数以及逗号分隔的参数值
+	if field.Kind() == reflect.Slice && field.Type().Elem().Kind() != reflect.Uint8 {
+		if len(values) == 1 && strings.Contains(values[0], ",") {
+			values = strings.Split(values[0], ",")
+		}
+		slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setValue(slice.Index(i), sf, strings.TrimSpace(value)); err != nil {
+				return fmt.Errorf("doris: bind field %s: %v", key, err)
+			}
+		}
+		field.Set(slice)
+		return nil
+	}
+
+	if err := setValue(field, sf, values[0]); err != nil {
+		return fmt.Errorf("doris: bind field %s: %v", key, err)
+	}
+	return nil
+}
+
+// 判断是否存在指定参数或者以其为前缀的嵌套参数
+func hasFormKey(form map[string][]string, key string) bool {
+	if _, ok := form[key]; ok {
+		return true
+	}
+	prefix := key + "."
+	for k := range form {
+		if strings.HasPrefix(k, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// 将字符串转换为字段对应的类型
+func setValue(v reflect.Value, sf reflect.StructField, value string) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+
+	switch v.Type() {
+	case timeType:
+		return setTime(v, sf, value)
+	case durationType:
+		if value == "" {
+			return nil
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		if value == "" {
+			return nil
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if value == "" {
+			return nil
+		}
+		i, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if value == "" {
+			return nil
+		}
+		u, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		if value == "" {
+			return nil
+		}
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// 按time_format标签解析时间
+// 未指定时默认使用RFC3339格式
+func setTime(v reflect.Value, sf reflect.StructField, value string) error {
+	if value == "" {
+		return nil
+	}
+	layout := sf.Tag.Get(timeFormatTag)
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	if layout == "unix" {
+		sec, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(time.Unix(sec, 0)))
+		return nil
+	}
+	t, err := time.ParseInLocation(layout, value, time.Local)
+	if err != nil {
+		return err
+	}
+	v.Set(reflect.ValueOf(t))
+	return nil
+}
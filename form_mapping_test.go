@@ -0,0 +1,58 @@
+package doris
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type bindAddress struct {
+	Street string `param:"street"`
+	Zip    *int   `param:"zip"`
+}
+
+type bindBase struct {
+	ID int `param:"id"`
+}
+
+type bindTarget struct {
+	bindBase
+	Name     string        `param:"name"`
+	Tags     []string      `param:"tags"`
+	Nums     []int         `param:"nums"`
+	Birthday time.Time     `param:"birthday" time_format:"2006-01-02"`
+	Created  time.Time     `param:"created" time_format:"unix"`
+	Timeout  time.Duration `param:"timeout"`
+	Nick     *string       `param:"nick"`
+	Missing  *string       `param:"missing"`
+	Address  bindAddress   `param:"address"`
+	Office   *bindAddress  `param:"office"`
+	Ignored  string        `param:"-"`
+}
+
+func TestMapForm(t *testing.T) {
+	form, _ := url.ParseQuery("id=7&name=doris&tags=a&tags=b&nums=1,2,3&birthday=2020-01-02&created=10" +
+		"&timeout=1m&nick=jonah&address.street=s1&address.zip=9&office.street=s2&Ignored=x")
+	var obj bindTarget
+	assert.NoError(t, mapForm(&obj, form))
+	assert.Equal(t, 7, obj.ID)
+	assert.Equal(t, "doris", obj.Name)
+	assert.Equal(t, []string{"a", "b"}, obj.Tags)
+	assert.Equal(t, []int{1, 2, 3}, obj.Nums)
+	assert.Equal(t, 2020, obj.Birthday.Year())
+	assert.Equal(t, int64(10), obj.Created.Unix())
+	assert.Equal(t, time.Minute, obj.Timeout)
+	assert.Equal(t, "jonah", *obj.Nick)
+	assert.Nil(t, obj.Missing)
+	assert.Equal(t, "s1", obj.Address.Street)
+	assert.Equal(t, 9, *obj.Address.Zip)
+	assert.Equal(t, "s2", obj.Office.Street)
+	assert.Equal(t, "", obj.Ignored)
+
+	// 类型错误
+	assert.Error(t, mapForm(&obj, url.Values{"nums": {"x"}}))
+	// 非结构体指针
+	assert.Error(t, mapForm(obj, form))
+}
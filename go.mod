@@ -1,12 +1,15 @@
 module github.com/leaderwolfpipi/doris
 
-go 1.12
+go 1.13
 
 require (
 	github.com/dgrijalva/jwt-go v3.2.0+incompatible
-	github.com/leaderwolfpipi/binding v0.0.0-20200203052103-9d1c782eabc9
+	github.com/golang/protobuf v1.3.3
+	github.com/json-iterator/go v1.1.12 // indirect
 	github.com/leaderwolfpipi/logger v0.0.0-20200105024148-3e9e4bc27bd3
 	github.com/leaderwolfpipi/render v0.0.0-20200203051326-e6cdbceef35a
-	github.com/leaderwolfpipi/validator v0.0.0-20200203043844-96c4959533b9 // indirect
 	github.com/stretchr/testify v1.4.0
+	golang.org/x/crypto v0.0.0-20200220183623-bac4c82f6975
+	golang.org/x/net v0.0.0-20200222125558-5a598a2470a0
+	golang.org/x/oauth2 v0.0.0-20200107190931-bf48bf16ab8d
 )
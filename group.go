@@ -239,19 +239,26 @@ func (group *RouteGroup) createStaticHandler(relativePath string, fs http.FileSy
 			c.Response.WriteHeader(http.StatusNotFound)
 		}
 
-		file := c.Param("filepath")
+		// 全匹配路由的参数名统一保存为"*"
+		file, ok := c.Param("filepath").(string)
+		if !ok {
+			file, _ = c.Param("*").(string)
+		}
 		// 检查文件是否存在以及是否有权限访问
-		if _, err := fs.Open(file.(string)); err != nil {
+		f, err := fs.Open(file)
+		if err != nil {
 			c.Response.WriteHeader(http.StatusNotFound)
 			// 将没有路由的函数链赋值给ctx的处理链
 			c.handlers = group.doris.noRoute
-			// 复位中间键索引值
+			// 复位中间键索引值，由noRoute处理链输出响应
 			c.index = -1
-			return err
+			return nil
 		}
+		f.Close()
 
 		// 调用文件服务的ServeHTTP方法
-		fileServer.ServeHTTP(c.Response.Writer, c.Request)
+		// http.FileServer内部使用http.ServeContent，支持Range/If-Range请求
+		fileServer.ServeHTTP(c.Response, c.Request)
 		return nil
 	}
 }
@@ -0,0 +1,179 @@
+// 健康检查
+package doris
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// 健康检查的默认超时时间
+const defaultHealthTimeout = 5 * time.Second
+
+// 健康检查状态
+const (
+	HealthStatusOK       = "ok"
+	HealthStatusFail     = "fail"
+	HealthStatusDraining = "draining"
+)
+
+type (
+	// 健康检查函数，返回错误表示检查失败，如数据库ping、缓存、磁盘空间
+	HealthChecker func(ctx context.Context) error
+
+	// 健康检查子系统，通过Doris.Health获取
+	// /healthz为存活探针，只执行存活检查；/readyz为就绪探针，执行全部检查，关闭开始后返回503
+	Health struct {
+		Timeout  time.Duration // 单次检查的超时时间，默认为5秒
+		doris    *Doris
+		lock     sync.RWMutex
+		liveness []healthCheck
+		checks   []healthCheck
+	}
+
+	// 已注册的检查
+	healthCheck struct {
+		name    string
+		checker HealthChecker
+	}
+
+	// 健康检查结果
+	HealthReport struct {
+		Status string                 `json:"status"`
+		Checks map[string]CheckResult `json:"checks,omitempty"`
+	}
+
+	// 单个检查的结果
+	CheckResult struct {
+		Status  string `json:"status"`
+		Latency string `json:"latency"`
+		Error   string `json:"error,omitempty"`
+	}
+)
+
+// 获取健康检查子系统，首次调用时注册GET /healthz和GET /readyz
+// 示例：d.Health().AddCheck("db", db.PingContext)
+func (doris *Doris) Health() *Health {
+	doris.stateLock.Lock()
+	defer doris.stateLock.Unlock()
+	if doris.health == nil {
+		doris.health = &Health{doris: doris}
+		doris.GET("/healthz", doris.health.LivenessHandler)
+		doris.GET("/readyz", doris.health.ReadinessHandler)
+	}
+	return doris.health
+}
+
+// 注册就绪检查，检查失败时实例不再接收流量
+func (h *Health) AddCheck(name string, checker HealthChecker) *Health {
+	h.lock.Lock()
+	h.checks = append(h.checks, healthCheck{name, checker})
+	h.lock.Unlock()
+	return h
+}
+
+// 注册存活检查，检查失败时实例应被重启，如检测死锁
+// 存活检查同时也是就绪检查
+func (h *Health) AddLivenessCheck(name string, checker HealthChecker) *Health {
+	h.lock.Lock()
+	h.liveness = append(h.liveness, healthCheck{name, checker})
+	h.lock.Unlock()
+	return h
+}
+
+// 执行存活检查
+func (h *Health) Liveness(ctx context.Context) HealthReport {
+	h.lock.RLock()
+	checks := append([]healthCheck(nil), h.liveness...)
+	h.lock.RUnlock()
+	return h.run(ctx, checks)
+}
+
+// 执行全部检查，关闭开始后直接返回draining
+func (h *Health) Readiness(ctx context.Context) HealthReport {
+	if h.doris != nil && !h.doris.Ready() {
+		return HealthReport{Status: HealthStatusDraining}
+	}
+	h.lock.RLock()
+	checks := append(append([]healthCheck(nil), h.liveness...), h.checks...)
+	h.lock.RUnlock()
+	return h.run(ctx, checks)
+}
+
+// 存活探针处理函数，检查通过返回200，否则返回503
+func (h *Health) LivenessHandler(c *Context) error {
+	return h.respond(c, h.Liveness(c.Request.Context()))
+}
+
+// 就绪探针处理函数，检查通过返回200，检查失败或关闭开始后返回503
+func (h *Health) ReadinessHandler(c *Context) error {
+	return h.respond(c, h.Readiness(c.Request.Context()))
+}
+
+// 输出检查结果
+func (h *Health) respond(c *Context, report HealthReport) error {
+	// 探针结果不应被缓存
+	c.Response.Header().Set(HeaderCacheControl, "no-store")
+	status := http.StatusOK
+	if report.Status != HealthStatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	c.Json(status, report)
+	return nil
+}
+
+// 并发执行检查，每个检查共享Timeout的时限
+func (h *Health) run(ctx context.Context, checks []healthCheck) HealthReport {
+	report := HealthReport{Status: HealthStatusOK}
+	if len(checks) == 0 {
+		return report
+	}
+	timeout := h.Timeout
+	if timeout <= 0 {
+		timeout = defaultHealthTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check healthCheck) {
+			defer wg.Done()
+			results[i] = runCheck(ctx, check.checker)
+		}(i, check)
+	}
+	wg.Wait()
+
+	report.Checks = make(map[string]CheckResult, len(checks))
+	for i, check := range checks {
+		report.Checks[check.name] = results[i]
+		if results[i].Status != HealthStatusOK {
+			report.Status = HealthStatusFail
+		}
+	}
+	return report
+}
+
+// 执行单个检查，超时未返回的检查视为失败
+func runCheck(ctx context.Context, checker HealthChecker) CheckResult {
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- checker(ctx)
+	}()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	result := CheckResult{Status: HealthStatusOK, Latency: time.Since(start).String()}
+	if err != nil {
+		result.Status = HealthStatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
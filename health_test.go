@@ -0,0 +1,67 @@
+package doris
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealth(t *testing.T) {
+	var dbErr error
+	d := New()
+	health := d.Health()
+	assert.Equal(t, health, d.Health())
+	health.Timeout = 50 * time.Millisecond
+	health.AddLivenessCheck("goroutines", func(ctx context.Context) error { return nil })
+	health.AddCheck("db", func(ctx context.Context) error { return dbErr })
+	serve := func(path string) (int, HealthReport) {
+		res := httptest.NewRecorder()
+		d.ServeHTTP(res, httptest.NewRequest(http.MethodGet, path, nil))
+		var report HealthReport
+		assert.Nil(t, json.Unmarshal(res.Body.Bytes(), &report))
+		return res.Code, report
+	}
+
+	code, report := serve("/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, HealthStatusOK, report.Status)
+	assert.Len(t, report.Checks, 2)
+	assert.NotEmpty(t, report.Checks["db"].Latency)
+
+	dbErr = errors.New("connection refused")
+	code, report = serve("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, HealthStatusFail, report.Status)
+	assert.Equal(t, "connection refused", report.Checks["db"].Error)
+	assert.Equal(t, HealthStatusOK, report.Checks["goroutines"].Status)
+
+	// 存活探针不受就绪检查影响
+	code, report = serve("/healthz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, report.Checks, 1)
+
+	// 关闭开始后就绪探针返回draining
+	d.drain()
+	code, report = serve("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, HealthStatusDraining, report.Status)
+}
+
+func TestHealthCheckTimeout(t *testing.T) {
+	health := &Health{Timeout: 20 * time.Millisecond}
+	health.AddCheck("slow", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+	start := time.Now()
+	report := health.Readiness(context.Background())
+	assert.True(t, time.Since(start) < 500*time.Millisecond)
+	assert.Equal(t, HealthStatusFail, report.Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks["slow"].Error)
+}
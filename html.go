@@ -0,0 +1,175 @@
+// html模板渲染器
+// 支持布局模板、公共片段以及按模板配置的函数集
+package doris
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// html模板渲染器
+// 目录结构示例：
+//
+//	views/layouts/main.html   布局模板，通过{{ block "content" . }}{{ end }}预留区块
+//	views/partials/header.html 公共片段，通过{{ template "partials/header" . }}引用
+//	views/users/index.html    页面模板，通过{{ define "content" }}...{{ end }}填充区块
+//
+// 模板名称为相对于Directory且不含后缀的路径
+type HTMLRender struct {
+	Directory     string                      // 模板根目录
+	Extension     string                      // 模板文件后缀，默认".html"
+	Layout        string                      // 默认布局模板名称，为空表示不使用布局
+	Partials      []string                    // 公共片段模板名称列表
+	Funcs         template.FuncMap            // 全部模板共享的函数集
+	TemplateFuncs map[string]template.FuncMap // 按页面模板名称配置的函数集
+	Reload        bool                        // 每次渲染都重新加载模板（开发模式）
+	lock          sync.RWMutex                // 缓存锁
+	cache         map[string]*template.Template
+}
+
+// 创建html模板渲染器
+func NewHTMLRender(directory string) *HTMLRender {
+	return &HTMLRender{
+		Directory: directory,
+		Extension: ".html",
+	}
+}
+
+// 渲染指定页面模板到writer
+// layout为空时直接渲染页面模板
+func (r *HTMLRender) Render(w http.ResponseWriter, name, layout string, data interface{}) error {
+	// 先渲染到缓冲区，避免模板出错时输出不完整的页面
+	buf, err := r.execute(name, layout, data)
+	if err != nil {
+		return err
+	}
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+// 渲染页面模板到缓冲区
+func (r *HTMLRender) execute(name, layout string, data interface{}) (*bytes.Buffer, error) {
+	tpl, err := r.template(name, layout)
+	if err != nil {
+		return nil, err
+	}
+	entry := name
+	if layout != "" {
+		entry = layout
+	}
+	buf := new(bytes.Buffer)
+	if err := tpl.ExecuteTemplate(buf, entry, data); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// 获取（或加载）页面模板和布局组合后的模板集
+func (r *HTMLRender) template(name, layout string) (*template.Template, error) {
+	key := layout + "|" + name
+	if !r.Reload {
+		r.lock.RLock()
+		tpl, ok := r.cache[key]
+		r.lock.RUnlock()
+		if ok {
+			return tpl, nil
+		}
+	}
+	tpl, err := r.load(name, layout)
+	if err != nil {
+		return nil, err
+	}
+	r.lock.Lock()
+	if r.cache == nil {
+		r.cache = make(map[string]*template.Template)
+	}
+	r.cache[key] = tpl
+	r.lock.Unlock()
+	return tpl, nil
+}
+
+// 按布局、公共片段、页面的顺序解析模板
+func (r *HTMLRender) load(name, layout string) (*template.Template, error) {
+	tpl := template.New("doris")
+	funcs := template.FuncMap{}
+	for k, fn := range r.Funcs {
+		funcs[k] = fn
+	}
+	for k, fn := range r.TemplateFuncs[name] {
+		funcs[k] = fn
+	}
+	tpl.Funcs(funcs)
+
+	names := make([]string, 0, len(r.Partials)+2)
+	if layout != "" {
+		names = append(names, layout)
+	}
+	names = append(names, r.Partials...)
+	names = append(names, name)
+	for _, n := range names {
+		content, err := ioutil.ReadFile(r.file(n))
+		if err != nil {
+			return nil, fmt.Errorf("doris: load template %s: %v", n, err)
+		}
+		if _, err := tpl.New(n).Parse(string(content)); err != nil {
+			return nil, err
+		}
+	}
+	return tpl, nil
+}
+
+// 模板名称转换为文件路径
+func (r *HTMLRender) file(name string) string {
+	ext := r.Extension
+	if ext == "" {
+		ext = ".html"
+	}
+	if !strings.HasSuffix(name, ext) {
+		name += ext
+	}
+	return filepath.Join(r.Directory, filepath.FromSlash(name))
+}
+
+// 适配Context.render的html渲染结构
+type htmlRender struct {
+	renderer *HTMLRender
+	name     string
+	layout   string
+	data     interface{}
+}
+
+func (r htmlRender) Render(w http.ResponseWriter) error {
+	return r.renderer.Render(w, r.name, r.layout, r.data)
+}
+
+func (r htmlRender) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, "text/html; charset=utf-8")
+}
+
+// 原始html字符串渲染结构
+type htmlString struct {
+	html string
+}
+
+func (r htmlString) Render(w http.ResponseWriter) error {
+	_, err := w.Write([]byte(r.html))
+	return err
+}
+
+func (r htmlString) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, "text/html; charset=utf-8")
+}
+
+// 未设置时写入Content-Type
+func writeContentType(w http.ResponseWriter, value string) {
+	header := w.Header()
+	if header.Get(HeaderContentType) == "" {
+		header.Set(HeaderContentType, value)
+	}
+}
@@ -0,0 +1,141 @@
+package doris
+
+import (
+	"errors"
+	"html/template"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 在临时目录中写入模板文件
+func writeTemplates(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "doris-views")
+	assert.Nil(t, err)
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.Nil(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+	return dir
+}
+
+func TestHTMLRender(t *testing.T) {
+	dir := writeTemplates(t, map[string]string{
+		"layouts/main.html":    `<title>{{ block "title" . }}Doris{{ end }}</title>{{ template "partials/header" . }}{{ block "content" . }}{{ end }}`,
+		"layouts/plain.html":   `<main>{{ block "content" . }}{{ end }}</main>`,
+		"partials/header.html": `<h1>{{ upper .Site }}</h1>`,
+		"users/index.html":     `{{ define "title" }}Users{{ end }}{{ define "content" }}<ul>{{ range .Users }}<li>{{ initial . }}:{{ . }}</li>{{ end }}</ul>{{ end }}`,
+		"about.html":           `{{ define "content" }}<p>{{ .Site }}</p>{{ end }}<p>{{ .Site }} &amp; friends</p>`,
+	})
+	defer os.RemoveAll(dir)
+
+	r := NewHTMLRender(dir)
+	r.Layout = "layouts/main"
+	r.Partials = []string{"partials/header"}
+	r.Funcs = template.FuncMap{"upper": strings.ToUpper}
+	r.TemplateFuncs = map[string]template.FuncMap{
+		"users/index": {"initial": func(s string) string { return s[:1] }},
+	}
+	data := D{"Site": "doris", "Users": []string{"alice", "<bob>"}}
+
+	d := New()
+	d.HTMLRender = r
+	d.GET("/users", func(c *Context) error {
+		c.Render(http.StatusOK, "users/index", data)
+		return nil
+	})
+	d.GET("/about", func(c *Context) error {
+		c.RenderWithLayout(http.StatusOK, "about", "layouts/plain", data)
+		return nil
+	})
+	d.GET("/bare", func(c *Context) error {
+		c.RenderWithLayout(http.StatusOK, "about", "", data)
+		return nil
+	})
+	serve := func(path string) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		d.ServeHTTP(res, httptest.NewRequest(http.MethodGet, path, nil))
+		return res
+	}
+
+	// 页面填充布局的区块，引用公共片段，并使用共享和按页面配置的函数
+	res := serve("/users")
+	assert.Equal(t, "text/html; charset=utf-8", res.Header().Get(HeaderContentType))
+	assert.Equal(t, `<title>Users</title><h1>DORIS</h1><ul><li>a:alice</li><li>&lt;:&lt;bob&gt;</li></ul>`, res.Body.String())
+
+	assert.Equal(t, `<main><p>doris</p></main>`, serve("/about").Body.String())
+	assert.Equal(t, `<p>doris &amp; friends</p>`, serve("/bare").Body.String())
+}
+
+func TestHTMLRenderCache(t *testing.T) {
+	dir := writeTemplates(t, map[string]string{"index.html": `v1`})
+	defer os.RemoveAll(dir)
+	r := NewHTMLRender(dir)
+	render := func() string {
+		res := httptest.NewRecorder()
+		assert.Nil(t, r.Render(res, "index", "", nil))
+		return res.Body.String()
+	}
+
+	assert.Equal(t, "v1", render())
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "index.html"), []byte(`v2`), 0644))
+	// 默认缓存解析后的模板
+	assert.Equal(t, "v1", render())
+	r.Reload = true
+	assert.Equal(t, "v2", render())
+}
+
+func TestHTMLRenderErrors(t *testing.T) {
+	dir := writeTemplates(t, map[string]string{
+		"broken.html":  `<p>start</p>{{ fail }}`,
+		"initial.html": `{{ initial .Site }}`,
+	})
+	defer os.RemoveAll(dir)
+	r := NewHTMLRender(dir)
+	r.Funcs = template.FuncMap{"fail": func() (string, error) {
+		return "", errors.New("query failed")
+	}}
+
+	// 执行出错时不输出不完整的页面
+	res := httptest.NewRecorder()
+	err := r.Render(res, "broken", "", nil)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "query failed")
+	}
+	assert.Equal(t, "", res.Body.String())
+
+	// 按页面配置的函数只对该页面可用
+	r.TemplateFuncs = map[string]template.FuncMap{
+		"other": {"initial": func(s string) string { return s[:1] }},
+	}
+	assert.NotNil(t, r.Render(httptest.NewRecorder(), "initial", "", D{"Site": "doris"}))
+
+	err = r.Render(httptest.NewRecorder(), "missing", "", nil)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "doris: load template missing")
+	}
+
+	// 未配置HTMLRender时渲染会panic
+	c := &Context{Doris: New()}
+	assert.Panics(t, func() { c.Render(http.StatusOK, "index", nil) })
+}
+
+func TestHtml(t *testing.T) {
+	d := New()
+	d.GET("/", func(c *Context) error {
+		c.Html(http.StatusAccepted, "<b>raw</b>")
+		return nil
+	})
+	res := httptest.NewRecorder()
+	d.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusAccepted, res.Code)
+	assert.Equal(t, "text/html; charset=utf-8", res.Header().Get(HeaderContentType))
+	assert.Equal(t, "<b>raw</b>", res.Body.String())
+}
@@ -0,0 +1,123 @@
+package doris
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 按语言和错误码（业务错误码或http状态码）保存的错误信息目录
+var (
+	errorMessages     = make(map[string]map[int]string)
+	errorMessagesLock sync.RWMutex
+)
+
+// 注册指定语言的错误信息，key为业务错误码或http状态码，与已有的注册合并
+// 例如：doris.RegisterErrorMessages("zh-CN", map[int]string{404: "资源不存在", 10400: "令牌已过期"})
+func RegisterErrorMessages(lang string, messages map[int]string) {
+	lang = strings.ToLower(lang)
+	errorMessagesLock.Lock()
+	defer errorMessagesLock.Unlock()
+	catalog, ok := errorMessages[lang]
+	if !ok {
+		catalog = make(map[int]string, len(messages))
+		errorMessages[lang] = catalog
+	}
+	for code, message := range messages {
+		catalog[code] = message
+	}
+}
+
+// 获取错误码在指定语言下的错误信息
+func LocalizedMessage(lang string, code int) (string, bool) {
+	errorMessagesLock.RLock()
+	defer errorMessagesLock.RUnlock()
+	message, ok := errorMessages[strings.ToLower(lang)][code]
+	return message, ok
+}
+
+// 设置当前请求的语言，优先于Accept-Language
+func (c *Context) SetLocale(lang string) {
+	c.locale = lang
+}
+
+// 获取当前请求的语言
+// 依次使用SetLocale设置的语言、Accept-Language中与已注册语言匹配度最高的语言、Doris.DefaultLocale
+func (c *Context) Locale() string {
+	if c.locale != "" {
+		return c.locale
+	}
+	if lang := matchLanguage(c.Request.Header.Get(HeaderAcceptLanguage)); lang != "" {
+		return lang
+	}
+	if c.Doris != nil {
+		return c.Doris.DefaultLocale
+	}
+	return ""
+}
+
+// 获取错误码在当前请求语言下的错误信息，未注册时返回defaultMessage
+func (c *Context) localize(code int, defaultMessage string) string {
+	if message, ok := LocalizedMessage(c.Locale(), code); ok {
+		return message
+	}
+	return defaultMessage
+}
+
+// 按q值从高到低匹配Accept-Language与已注册的语言
+// 先精确匹配（zh-CN），再按主语言匹配（zh匹配zh-CN，zh-TW匹配zh-CN）
+func matchLanguage(header string) string {
+	if header == "" {
+		return ""
+	}
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag := strings.TrimSpace(part)
+		q := 1.0
+		if i := strings.IndexByte(tag, ';'); i >= 0 {
+			if v := strings.TrimSpace(tag[i+1:]); strings.HasPrefix(v, "q=") {
+				if f, err := strconv.ParseFloat(v[2:], 64); err == nil {
+					q = f
+				}
+			}
+			tag = strings.TrimSpace(tag[:i])
+		}
+		if tag != "" && tag != "*" && q > 0 {
+			tags = append(tags, weighted{strings.ToLower(tag), q})
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	errorMessagesLock.RLock()
+	langs := make([]string, 0, len(errorMessages))
+	for lang := range errorMessages {
+		langs = append(langs, lang)
+	}
+	errorMessagesLock.RUnlock()
+	sort.Strings(langs)
+	for _, t := range tags {
+		if i := sort.SearchStrings(langs, t.tag); i < len(langs) && langs[i] == t.tag {
+			return t.tag
+		}
+		primary := primaryLanguage(t.tag)
+		for _, lang := range langs {
+			if primaryLanguage(lang) == primary {
+				return lang
+			}
+		}
+	}
+	return ""
+}
+
+// 获取语言标签的主语言，如zh-CN返回zh
+func primaryLanguage(tag string) string {
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		return tag[:i]
+	}
+	return tag
+}
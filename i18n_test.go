@@ -0,0 +1,74 @@
+package doris
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalizedErrors(t *testing.T) {
+	RegisterErrorMessages("zh-CN", map[int]string{
+		http.StatusNotFound:   "资源不存在",
+		TokenExpiredErr.Code:  "令牌已过期",
+		http.StatusBadGateway: "网关错误",
+	})
+	RegisterErrorMessages("en-US", map[int]string{http.StatusNotFound: "Nothing here"})
+	defer func() {
+		errorMessagesLock.Lock()
+		delete(errorMessages, "zh-cn")
+		delete(errorMessages, "en-us")
+		errorMessagesLock.Unlock()
+	}()
+
+	d := New()
+	d.GET("/expired", func(c *Context) error {
+		return TokenExpiredErr
+	})
+	d.GET("/gateway", func(c *Context) error {
+		return NewHTTPError(http.StatusBadGateway)
+	})
+	d.GET("/custom", func(c *Context) error {
+		return NewHTTPError(http.StatusBadGateway, "upstream timed out")
+	})
+	d.GET("/forced", func(c *Context) error {
+		c.SetLocale("zh-CN")
+		return TokenExpiredErr
+	})
+	serve := func(path, acceptLanguage string) string {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if acceptLanguage != "" {
+			req.Header.Set(HeaderAcceptLanguage, acceptLanguage)
+		}
+		res := httptest.NewRecorder()
+		d.ServeHTTP(res, req)
+		return res.Body.String()
+	}
+
+	assert.JSONEq(t, `{"code":10400,"message":"令牌已过期"}`, serve("/expired", "zh-CN,zh;q=0.9"))
+	assert.JSONEq(t, `{"code":10400,"message":"Token is expired"}`, serve("/expired", "en-US"))
+	assert.JSONEq(t, `{"code":502,"message":"网关错误"}`, serve("/gateway", "en;q=0.5, zh-TW"))
+	assert.JSONEq(t, `{"code":502,"message":"upstream timed out"}`, serve("/custom", "zh-CN"))
+	assert.JSONEq(t, `{"code":404,"message":"Nothing here"}`, serve("/missing", "fr, en-GB;q=0.8, zh-CN;q=0.3"))
+	assert.JSONEq(t, `{"code":10400,"message":"令牌已过期"}`, serve("/forced", "en-US"))
+	assert.JSONEq(t, `{"code":404,"message":"Not found"}`, serve("/missing", ""))
+
+	d.DefaultLocale = "zh-CN"
+	assert.JSONEq(t, `{"code":404,"message":"资源不存在"}`, serve("/missing", "fr"))
+}
+
+func TestMatchLanguage(t *testing.T) {
+	RegisterErrorMessages("zh-CN", map[int]string{http.StatusNotFound: "资源不存在"})
+	defer func() {
+		errorMessagesLock.Lock()
+		delete(errorMessages, "zh-cn")
+		errorMessagesLock.Unlock()
+	}()
+
+	assert.Equal(t, "zh-cn", matchLanguage("zh-CN"))
+	assert.Equal(t, "zh-cn", matchLanguage("zh"))
+	assert.Equal(t, "", matchLanguage("en-US, *"))
+	assert.Equal(t, "", matchLanguage("zh;q=0"))
+	assert.Equal(t, "", matchLanguage(""))
+}
@@ -0,0 +1,35 @@
+// 框架内置的json渲染结构
+package doris
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+)
+
+// secureJson渲染结构
+type secureJson struct {
+	Prefix string
+	Data   interface{}
+}
+
+func (r secureJson) Render(w http.ResponseWriter) error {
+	e := getJsonEncoder(true)
+	defer putJsonEncoder(e)
+	if err := e.enc.Encode(r.Data); err != nil {
+		return err
+	}
+	jsonBytes := e.bytes()
+	// 仅对顶层数组添加前缀
+	if bytes.HasPrefix(jsonBytes, []byte("[")) && bytes.HasSuffix(jsonBytes, []byte("]")) {
+		if _, err := io.WriteString(w, r.Prefix); err != nil {
+			return err
+		}
+	}
+	_, err := w.Write(jsonBytes)
+	return err
+}
+
+func (r secureJson) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, "application/json; charset=utf-8")
+}
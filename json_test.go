@@ -0,0 +1,78 @@
+package doris
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecureJson(t *testing.T) {
+	d := New()
+	d.GET("/array", func(c *Context) error {
+		c.SecureJson(http.StatusOK, []string{"a", "<b>"})
+		return nil
+	})
+	d.GET("/empty", func(c *Context) error {
+		c.SecureJson(http.StatusOK, []int{})
+		return nil
+	})
+	d.GET("/object", func(c *Context) error {
+		c.SecureJson(http.StatusOK, D{"list": "[1]"})
+		return nil
+	})
+	d.GET("/json", func(c *Context) error {
+		c.Json(http.StatusOK, &[]int{1, 2})
+		return nil
+	})
+	d.GET("/bytes", func(c *Context) error {
+		c.Json(http.StatusOK, []byte("hi"))
+		return nil
+	})
+	d.GET("/marshaler", func(c *Context) error {
+		c.Json(http.StatusOK, jsonList{"a", "b"})
+		return nil
+	})
+	d.GET("/null", func(c *Context) error {
+		var users []string
+		c.Json(http.StatusOK, users)
+		return nil
+	})
+	serve := func(path string) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		d.ServeHTTP(res, httptest.NewRequest(http.MethodGet, path, nil))
+		return res
+	}
+
+	res := serve("/array")
+	assert.Equal(t, "application/json; charset=utf-8", res.Header().Get(HeaderContentType))
+	assert.Equal(t, `while(1);["a","\u003cb\u003e"]`, res.Body.String())
+	assert.Equal(t, `while(1);[]`, serve("/empty").Body.String())
+	// 只对顶层数组添加前缀
+	assert.Equal(t, `{"list":"[1]"}`, serve("/object").Body.String())
+
+	// 默认Json不添加前缀
+	assert.Equal(t, `[1,2]`, serve("/json").Body.String())
+	assert.Equal(t, `["a","b"]`, serve("/marshaler").Body.String())
+
+	d.SecureJsonArrays = true
+	d.SecureJsonPrefix = ")]}',\n"
+	assert.Equal(t, ")]}',\n[1,2]", serve("/json").Body.String())
+	assert.Equal(t, ")]}',\n[]", serve("/empty").Body.String())
+	// 按编码结果判断：自定义MarshalJSON输出的数组同样添加前缀
+	assert.Equal(t, ")]}',\n[\"a\",\"b\"]", serve("/marshaler").Body.String())
+	// []byte被编码为字符串，nil切片被编码为null
+	assert.Equal(t, `"aGk="`, serve("/bytes").Body.String())
+	assert.Equal(t, `null`, serve("/null").Body.String())
+}
+
+// 自定义编码为json数组的类型
+type jsonList struct {
+	first, second string
+}
+
+func (l jsonList) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]string{l.first, l.second})
+}
@@ -0,0 +1,60 @@
+package doris
+
+import (
+	"context"
+)
+
+type (
+	// 服务启动前执行的钩子
+	StartHook func() error
+
+	// 服务优雅关闭后执行的钩子
+	ShutdownHook func(ctx context.Context) error
+)
+
+// 注册服务启动前执行的钩子，如预热缓存
+// 在绑定监听地址之后、开始处理请求之前按注册顺序执行，任一钩子返回错误时终止启动
+// 之后服务启动失败或出错时会执行关闭钩子
+func (doris *Doris) OnStart(fn StartHook) {
+	doris.startHooks = append(doris.startHooks, fn)
+}
+
+// 注册服务优雅关闭后执行的钩子，如关闭数据库连接池
+// 处理中的请求完成后按注册的相反顺序执行，共享ShutdownTimeout的时限
+func (doris *Doris) OnShutdown(fn ShutdownHook) {
+	doris.shutdownHooks = append(doris.shutdownHooks, fn)
+}
+
+// 打印banner和路由表并执行启动钩子
+func (doris *Doris) startup() error {
+	// 判断是否展示banner
+	if doris.ShowBanner {
+		doris.printBanner()
+	}
+	// 调试模式下打印路由表
+	if doris.Debug {
+		doris.printRoutes()
+	}
+	for _, fn := range doris.startHooks {
+		if err := fn(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 执行关闭钩子，返回第一个错误，其余错误记录到日志
+func (doris *Doris) runShutdownHooks() (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), doris.shutdownTimeout())
+	defer cancel()
+	for i := len(doris.shutdownHooks) - 1; i >= 0; i-- {
+		if e := doris.shutdownHooks[i](ctx); e != nil {
+			if err == nil {
+				err = e
+			} else {
+				doris.Logger.Error("shutdown hook: " + e.Error())
+			}
+		}
+	}
+	return err
+}
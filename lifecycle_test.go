@@ -0,0 +1,97 @@
+package doris
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLifecycleHooks(t *testing.T) {
+	addr := freeAddr(t)
+	var calls []string
+	d := New()
+	d.OnStart(func() error {
+		calls = append(calls, "start1")
+		return nil
+	})
+	d.OnStart(func() error {
+		calls = append(calls, "start2")
+		return nil
+	})
+	d.OnShutdown(func(ctx context.Context) error {
+		calls = append(calls, "shutdown1")
+		return nil
+	})
+	d.OnShutdown(func(ctx context.Context) error {
+		_, ok := ctx.Deadline()
+		assert.True(t, ok)
+		calls = append(calls, "shutdown2")
+		return nil
+	})
+
+	assert.Nil(t, d.Start(addr))
+	assert.Equal(t, []string{"start1", "start2"}, calls)
+
+	assert.Nil(t, d.Stop(context.Background()))
+	// 关闭钩子按注册的相反顺序执行
+	assert.Equal(t, []string{"start1", "start2", "shutdown2", "shutdown1"}, calls)
+}
+
+func TestStartHookErrorAbortsRun(t *testing.T) {
+	startErr := errors.New("warm cache failed")
+	called := false
+	d := New()
+	d.OnStart(func() error {
+		return startErr
+	})
+	d.OnStart(func() error {
+		called = true
+		return nil
+	})
+
+	assert.Equal(t, startErr, d.RunWithContext(context.Background(), freeAddr(t)))
+	assert.False(t, called)
+}
+
+func TestStartHooksRunAfterBind(t *testing.T) {
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer busy.Close()
+
+	var calls []string
+	d := New()
+	d.OnStart(func() error {
+		calls = append(calls, "start")
+		return nil
+	})
+	d.OnShutdown(func(ctx context.Context) error {
+		calls = append(calls, "shutdown")
+		return nil
+	})
+
+	// 绑定失败时不执行启动钩子
+	assert.NotNil(t, d.Start(busy.Addr().String()))
+	assert.NotNil(t, d.RunEndpoints(context.Background(), Endpoint{Addr: freeAddr(t)}, Endpoint{Addr: busy.Addr().String()}))
+	assert.Nil(t, calls)
+
+	// 绑定之后的步骤失败时执行关闭钩子
+	assert.NotNil(t, d.RunTLS(freeAddr(t), "missing.crt", "missing.key"))
+	assert.Equal(t, []string{"start", "shutdown"}, calls)
+	assert.Equal(t, ServerNotRunningErr, d.Stop(context.Background()))
+}
+
+func TestShutdownHookError(t *testing.T) {
+	first := errors.New("close db")
+	d := New()
+	d.OnShutdown(func(ctx context.Context) error {
+		return errors.New("close cache")
+	})
+	d.OnShutdown(func(ctx context.Context) error {
+		return first
+	})
+
+	assert.Equal(t, first, d.runShutdownHooks())
+}
@@ -1 +1,124 @@
 package doris
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/leaderwolfpipi/logger"
+)
+
+// 结构化日志字段
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// 创建日志字段
+func F(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// 日志记录器接口
+// 可通过适配器接入zap、zerolog、logrus等日志库
+type Logger interface {
+	Debug(msg string, fields ...Field)
+	Info(msg string, fields ...Field)
+	Warn(msg string, fields ...Field)
+	Error(msg string, fields ...Field)
+}
+
+// 默认日志记录器
+// 基于leaderwolfpipi/logger，字段以key=value的形式追加在消息之后
+type defaultLogger struct {
+	logger *logger.Logger
+}
+
+// 创建并启动默认日志记录器
+func NewDefaultLogger() Logger {
+	l := logger.NewLogger()
+	l.SetCacheSwitch(true)
+	l.Start()
+	return &defaultLogger{logger: l}
+}
+
+func (l *defaultLogger) Debug(msg string, fields ...Field) {
+	l.logger.Debug(formatFields(msg, fields))
+}
+
+func (l *defaultLogger) Info(msg string, fields ...Field) {
+	l.logger.Info(formatFields(msg, fields))
+}
+
+func (l *defaultLogger) Warn(msg string, fields ...Field) {
+	l.logger.Warn(formatFields(msg, fields))
+}
+
+func (l *defaultLogger) Error(msg string, fields ...Field) {
+	l.logger.Error(formatFields(msg, fields))
+}
+
+// 将字段格式化为key=value并追加到消息之后
+func formatFields(msg string, fields []Field) string {
+	if len(fields) == 0 {
+		return msg
+	}
+	var b strings.Builder
+	b.WriteString(msg)
+	for _, f := range fields {
+		b.WriteByte(' ')
+		b.WriteString(f.Key)
+		b.WriteByte('=')
+		b.WriteString(fmt.Sprint(f.Value))
+	}
+	return b.String()
+}
+
+// 请求级日志记录器
+// 每条日志自动携带请求ID、请求方法、路径和客户端IP
+type RequestLogger struct {
+	logger Logger  // 底层日志记录器
+	fields []Field // 请求上下文字段
+}
+
+// 创建请求级日志记录器
+func newRequestLogger(c *Context) *RequestLogger {
+	return &RequestLogger{
+		logger: c.Doris.Logger,
+		fields: []Field{
+			F("request_id", c.RequestID()),
+			F("method", c.Request.Method),
+			F("path", c.Request.URL.Path),
+			F("ip", c.RealIP()),
+		},
+	}
+}
+
+// 合并请求上下文字段和调用方字段
+func (l *RequestLogger) with(fields []Field) []Field {
+	all := make([]Field, 0, len(l.fields)+len(fields))
+	all = append(all, l.fields...)
+	return append(all, fields...)
+}
+
+// 记录debug级别日志
+func (l *RequestLogger) Debug(msg string, fields ...Field) {
+	l.logger.Debug(msg, l.with(fields)...)
+}
+
+// 记录info级别日志
+func (l *RequestLogger) Info(msg string, fields ...Field) {
+	l.logger.Info(msg, l.with(fields)...)
+}
+
+// 记录warn级别日志
+func (l *RequestLogger) Warn(msg string, fields ...Field) {
+	l.logger.Warn(msg, l.with(fields)...)
+}
+
+// 记录error级别日志
+func (l *RequestLogger) Error(msg string, fields ...Field) {
+	l.logger.Error(msg, l.with(fields)...)
+}
+
+// RequestLogger同样实现了Logger接口
+var _ Logger = &RequestLogger{}
@@ -0,0 +1,63 @@
+package doris
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordLogger struct {
+	lines []string
+}
+
+func (l *recordLogger) Debug(msg string, fields ...Field) { l.record("DEBUG", msg, fields) }
+func (l *recordLogger) Info(msg string, fields ...Field)  { l.record("INFO", msg, fields) }
+func (l *recordLogger) Warn(msg string, fields ...Field)  { l.record("WARN", msg, fields) }
+func (l *recordLogger) Error(msg string, fields ...Field) { l.record("ERROR", msg, fields) }
+
+func (l *recordLogger) record(level, msg string, fields []Field) {
+	l.lines = append(l.lines, level+" "+formatFields(msg, fields))
+}
+
+func TestFormatFields(t *testing.T) {
+	assert.Equal(t, "hello", formatFields("hello", nil))
+	assert.Equal(t, "hello user=1 ok=true", formatFields("hello", []Field{F("user", 1), F("ok", true)}))
+}
+
+func TestRequestLogger(t *testing.T) {
+	l := &recordLogger{}
+	d := New()
+	d.Logger = l
+	d.GET("/users", func(c *Context) error {
+		c.Logger().Info("listed", F("count", 2))
+		return nil
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set(HeaderXRequestID, "abc")
+	req.RemoteAddr = "10.0.0.1:1234"
+	d.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, []string{"INFO listed request_id=abc method=GET path=/users ip=10.0.0.1 count=2"}, l.lines)
+
+	// 中间件设置的请求ID和代理转发的客户端IP，各级别写入同一个日志记录器
+	d.POST("/users", func(c *Context) error {
+		c.SetRequestID("gen-1")
+		logger := c.Logger()
+		logger.Debug("decoding")
+		logger.Warn("duplicate", F("name", "doris"))
+		logger.Error("failed")
+		return nil
+	})
+	l.lines = nil
+	req = httptest.NewRequest(http.MethodPost, "/users", nil)
+	req.Header.Set(HeaderXForwardedFor, "203.0.113.7, 10.0.0.1")
+	d.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, []string{
+		"DEBUG decoding request_id=gen-1 method=POST path=/users ip=203.0.113.7",
+		"WARN duplicate request_id=gen-1 method=POST path=/users ip=203.0.113.7 name=doris",
+		"ERROR failed request_id=gen-1 method=POST path=/users ip=203.0.113.7",
+	}, l.lines)
+}
@@ -0,0 +1,7 @@
+// Package logadapter groups the doris.Logger adapters for common logging
+// libraries. Each adapter is a separate module so applications only depend
+// on the library they use:
+//
+//	d := doris.New()
+//	d.Logger = doriszap.New(zapLogger)
+package logadapter
@@ -0,0 +1,11 @@
+module github.com/leaderwolfpipi/doris/logadapter/dorislogrus
+
+go 1.13
+
+require (
+	github.com/leaderwolfpipi/doris v0.0.0-00010101000000-000000000000
+	github.com/sirupsen/logrus v1.4.2
+	github.com/stretchr/testify v1.4.0
+)
+
+replace github.com/leaderwolfpipi/doris => ../..
@@ -0,0 +1,51 @@
+// Package dorislogrus adapts a logrus logger to doris.Logger.
+package dorislogrus
+
+import (
+	"github.com/leaderwolfpipi/doris"
+	"github.com/sirupsen/logrus"
+)
+
+// Logger writes doris logs to a logrus logger or entry.
+type Logger struct {
+	logger logrus.FieldLogger
+}
+
+// New returns a doris.Logger backed by the logrus logger.
+func New(logger logrus.FieldLogger) *Logger {
+	return &Logger{logger: logger}
+}
+
+// Debug implements doris.Logger.
+func (l *Logger) Debug(msg string, fields ...doris.Field) {
+	l.with(fields).Debug(msg)
+}
+
+// Info implements doris.Logger.
+func (l *Logger) Info(msg string, fields ...doris.Field) {
+	l.with(fields).Info(msg)
+}
+
+// Warn implements doris.Logger.
+func (l *Logger) Warn(msg string, fields ...doris.Field) {
+	l.with(fields).Warn(msg)
+}
+
+// Error implements doris.Logger.
+func (l *Logger) Error(msg string, fields ...doris.Field) {
+	l.with(fields).Error(msg)
+}
+
+// with returns the logger with the fields attached.
+func (l *Logger) with(fields []doris.Field) logrus.FieldLogger {
+	if len(fields) == 0 {
+		return l.logger
+	}
+	lf := make(logrus.Fields, len(fields))
+	for _, f := range fields {
+		lf[f.Key] = f.Value
+	}
+	return l.logger.WithFields(lf)
+}
+
+var _ doris.Logger = &Logger{}
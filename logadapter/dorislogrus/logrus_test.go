@@ -0,0 +1,42 @@
+package dorislogrus
+
+import (
+	"testing"
+
+	"github.com/leaderwolfpipi/doris"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogger(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	logger.SetLevel(logrus.InfoLevel)
+	l := New(logger)
+
+	l.Debug("dropped")
+	l.Info("started", doris.F("addr", ":8080"), doris.F("workers", 4))
+	l.Warn("slow")
+	l.Error("failed", doris.F("status", 500))
+
+	entries := hook.AllEntries()
+	if assert.Len(t, entries, 3) {
+		assert.Equal(t, logrus.InfoLevel, entries[0].Level)
+		assert.Equal(t, "started", entries[0].Message)
+		assert.Equal(t, logrus.Fields{"addr": ":8080", "workers": 4}, entries[0].Data)
+		assert.Equal(t, logrus.WarnLevel, entries[1].Level)
+		assert.Empty(t, entries[1].Data)
+		assert.Equal(t, logrus.ErrorLevel, entries[2].Level)
+		assert.Equal(t, logrus.Fields{"status": 500}, entries[2].Data)
+	}
+}
+
+func TestLoggerEntry(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	l := New(logger.WithField("component", "doris"))
+
+	l.Info("started", doris.F("addr", ":8080"))
+	if assert.NotNil(t, hook.LastEntry()) {
+		assert.Equal(t, logrus.Fields{"component": "doris", "addr": ":8080"}, hook.LastEntry().Data)
+	}
+}
@@ -0,0 +1,11 @@
+module github.com/leaderwolfpipi/doris/logadapter/doriszap
+
+go 1.13
+
+require (
+	github.com/leaderwolfpipi/doris v0.0.0-00010101000000-000000000000
+	github.com/stretchr/testify v1.4.0
+	go.uber.org/zap v1.13.0
+)
+
+replace github.com/leaderwolfpipi/doris => ../..
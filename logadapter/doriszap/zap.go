@@ -0,0 +1,51 @@
+// Package doriszap adapts a zap logger to doris.Logger.
+package doriszap
+
+import (
+	"github.com/leaderwolfpipi/doris"
+	"go.uber.org/zap"
+)
+
+// Logger writes doris logs to a zap logger.
+type Logger struct {
+	logger *zap.Logger
+}
+
+// New returns a doris.Logger backed by the zap logger.
+func New(logger *zap.Logger) *Logger {
+	return &Logger{logger: logger}
+}
+
+// Debug implements doris.Logger.
+func (l *Logger) Debug(msg string, fields ...doris.Field) {
+	l.logger.Debug(msg, zapFields(fields)...)
+}
+
+// Info implements doris.Logger.
+func (l *Logger) Info(msg string, fields ...doris.Field) {
+	l.logger.Info(msg, zapFields(fields)...)
+}
+
+// Warn implements doris.Logger.
+func (l *Logger) Warn(msg string, fields ...doris.Field) {
+	l.logger.Warn(msg, zapFields(fields)...)
+}
+
+// Error implements doris.Logger.
+func (l *Logger) Error(msg string, fields ...doris.Field) {
+	l.logger.Error(msg, zapFields(fields)...)
+}
+
+// zapFields converts doris fields to zap fields.
+func zapFields(fields []doris.Field) []zap.Field {
+	if len(fields) == 0 {
+		return nil
+	}
+	zf := make([]zap.Field, len(fields))
+	for i, f := range fields {
+		zf[i] = zap.Any(f.Key, f.Value)
+	}
+	return zf
+}
+
+var _ doris.Logger = &Logger{}
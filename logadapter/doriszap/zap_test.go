@@ -0,0 +1,32 @@
+package doriszap
+
+import (
+	"testing"
+
+	"github.com/leaderwolfpipi/doris"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLogger(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	l := New(zap.New(core))
+
+	l.Debug("dropped")
+	l.Info("started", doris.F("addr", ":8080"), doris.F("workers", 4))
+	l.Warn("slow")
+	l.Error("failed", doris.F("status", 500))
+
+	entries := logs.All()
+	if assert.Len(t, entries, 3) {
+		assert.Equal(t, zapcore.InfoLevel, entries[0].Level)
+		assert.Equal(t, "started", entries[0].Message)
+		assert.Equal(t, map[string]interface{}{"addr": ":8080", "workers": int64(4)}, entries[0].ContextMap())
+		assert.Equal(t, zapcore.WarnLevel, entries[1].Level)
+		assert.Empty(t, entries[1].Context)
+		assert.Equal(t, zapcore.ErrorLevel, entries[2].Level)
+		assert.Equal(t, map[string]interface{}{"status": int64(500)}, entries[2].ContextMap())
+	}
+}
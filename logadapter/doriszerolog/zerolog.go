@@ -0,0 +1,51 @@
+// Package doriszerolog adapts a zerolog logger to doris.Logger.
+package doriszerolog
+
+import (
+	"github.com/leaderwolfpipi/doris"
+	"github.com/rs/zerolog"
+)
+
+// Logger writes doris logs to a zerolog logger.
+type Logger struct {
+	logger zerolog.Logger
+}
+
+// New returns a doris.Logger backed by the zerolog logger.
+func New(logger zerolog.Logger) *Logger {
+	return &Logger{logger: logger}
+}
+
+// Debug implements doris.Logger.
+func (l *Logger) Debug(msg string, fields ...doris.Field) {
+	send(l.logger.Debug(), msg, fields)
+}
+
+// Info implements doris.Logger.
+func (l *Logger) Info(msg string, fields ...doris.Field) {
+	send(l.logger.Info(), msg, fields)
+}
+
+// Warn implements doris.Logger.
+func (l *Logger) Warn(msg string, fields ...doris.Field) {
+	send(l.logger.Warn(), msg, fields)
+}
+
+// Error implements doris.Logger.
+func (l *Logger) Error(msg string, fields ...doris.Field) {
+	send(l.logger.Error(), msg, fields)
+}
+
+// send adds the fields to the event and writes it.
+// A nil event means the level is disabled.
+func send(e *zerolog.Event, msg string, fields []doris.Field) {
+	if e == nil {
+		return
+	}
+	for _, f := range fields {
+		e = e.Interface(f.Key, f.Value)
+	}
+	e.Msg(msg)
+}
+
+var _ doris.Logger = &Logger{}
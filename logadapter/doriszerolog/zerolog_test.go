@@ -0,0 +1,38 @@
+package doriszerolog
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/leaderwolfpipi/doris"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogger(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := New(zerolog.New(buf).Level(zerolog.InfoLevel))
+
+	l.Debug("dropped")
+	assert.Equal(t, 0, buf.Len())
+
+	l.Info("started", doris.F("addr", ":8080"), doris.F("workers", 4))
+	l.Warn("slow")
+	l.Error("failed", doris.F("status", 500))
+
+	var entries []map[string]interface{}
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		entry := map[string]interface{}{}
+		if !assert.NoError(t, dec.Decode(&entry)) {
+			return
+		}
+		entries = append(entries, entry)
+	}
+	if assert.Len(t, entries, 3) {
+		assert.Equal(t, map[string]interface{}{"level": "info", "message": "started", "addr": ":8080", "workers": float64(4)}, entries[0])
+		assert.Equal(t, map[string]interface{}{"level": "warn", "message": "slow"}, entries[1])
+		assert.Equal(t, map[string]interface{}{"level": "error", "message": "failed", "status": float64(500)}, entries[2])
+	}
+}
@@ -0,0 +1,185 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/leaderwolfpipi/doris"
+)
+
+type (
+	// AuditConfig defines the config for Audit middleware.
+	AuditConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Sink stores the audit entries.
+		// Required.
+		Sink AuditSink
+
+		// Subject returns who made the request.
+		// Optional. Default value AuditSubject (the JWT "sub" claim or the
+		// basic auth username).
+		Subject func(c *doris.Context) string
+
+		// Params lists the query parameters recorded in addition to the
+		// route parameters. Other parameters are left out so secrets don't
+		// end up in the audit trail.
+		// Optional. Default value nil.
+		Params []string
+	}
+
+	// AuditEntry records who did what, when, from where and the outcome.
+	AuditEntry struct {
+		Time      time.Time         `json:"time"`
+		Subject   string            `json:"subject"`
+		Method    string            `json:"method"`
+		Route     string            `json:"route"`
+		Path      string            `json:"path"`
+		Params    map[string]string `json:"params,omitempty"`
+		IP        string            `json:"ip"`
+		UserAgent string            `json:"user_agent"`
+		RequestID string            `json:"request_id,omitempty"`
+		Status    int               `json:"status"`
+		Latency   time.Duration     `json:"latency"`
+		Error     string            `json:"error,omitempty"`
+	}
+
+	// AuditSink stores audit entries, e.g. in a file, a database or Kafka.
+	// Write is called after the response is sent; failures are logged.
+	AuditSink interface {
+		Write(entry *AuditEntry) error
+	}
+
+	// AuditSinkFunc is an adapter to use a function as AuditSink.
+	AuditSinkFunc func(entry *AuditEntry) error
+
+	// auditWriter writes entries as JSON lines.
+	auditWriter struct {
+		lock sync.Mutex
+		enc  *json.Encoder
+	}
+)
+
+var (
+	// DefaultAuditConfig is the default Audit middleware config.
+	DefaultAuditConfig = AuditConfig{
+		Skipper: DefaultSkipper,
+		Subject: AuditSubject,
+	}
+)
+
+// Audit returns a middleware which records every request to the sink,
+// usually for admin routes:
+//
+//	admin := d.Group("/admin", middleware.JWT(key), middleware.Audit(middleware.NewAuditWriter(file)))
+func Audit(sink AuditSink) doris.HandlerFunc {
+	config := DefaultAuditConfig
+	config.Sink = sink
+	return AuditWithConfig(config)
+}
+
+// AuditWithConfig returns an Audit middleware with config.
+// See: `Audit()`.
+func AuditWithConfig(config AuditConfig) doris.HandlerFunc {
+	// Defaults
+	if config.Sink == nil {
+		panic("doris: audit middleware requires a sink")
+	}
+	if config.Skipper == nil {
+		config.Skipper = DefaultAuditConfig.Skipper
+	}
+	if config.Subject == nil {
+		config.Subject = DefaultAuditConfig.Subject
+	}
+
+	return func(c *doris.Context) error {
+		if config.Skipper(c) {
+			c.Next()
+			return nil
+		}
+
+		start := time.Now()
+		c.Next()
+
+		entry := &AuditEntry{
+			Time:      start,
+			Subject:   config.Subject(c),
+			Method:    c.Request.Method,
+			Route:     c.FullPath(),
+			Path:      c.Request.URL.Path,
+			IP:        c.RealIP(),
+			UserAgent: c.Request.UserAgent(),
+			RequestID: c.RequestID(),
+			Status:    c.Response.Status(),
+			Latency:   time.Since(start),
+		}
+		entry.Params = auditParams(c, config.Params)
+		if last := c.Errors.Last(); last != nil {
+			entry.Error = last.Error()
+		}
+		if err := config.Sink.Write(entry); err != nil {
+			c.Doris.Logger.Error("audit: write entry: "+err.Error(), doris.F("request_id", entry.RequestID))
+		}
+		return nil
+	}
+}
+
+// AuditSubject returns the "sub" claim of the token stored by the JWT
+// middleware or the username stored by the BasicAuth middleware.
+func AuditSubject(c *doris.Context) string {
+	if token, err := TokenFromContext(c); err == nil {
+		if claims, err := claimsMap(token.Claims); err == nil {
+			if sub, ok := claims[ClaimSubject].(string); ok {
+				return sub
+			}
+		}
+	}
+	if user, ok := c.Param(DefaultBasicAuthConfig.ContextKey).(string); ok {
+		return user
+	}
+	return ""
+}
+
+// auditParams collects the route parameters and the listed query parameters.
+func auditParams(c *doris.Context, query []string) map[string]string {
+	names := c.ParamNames()
+	if len(names) == 0 && len(query) == 0 {
+		return nil
+	}
+	params := make(map[string]string, len(names)+len(query))
+	for i, value := range c.ParamValues() {
+		params[names[i]] = fmt.Sprint(value)
+	}
+	values := c.QueryParams()
+	for _, name := range query {
+		if v, ok := values[name]; ok && len(v) > 0 {
+			params[name] = v[0]
+		}
+	}
+	if len(params) == 0 {
+		return nil
+	}
+	return params
+}
+
+// Write implements AuditSink.
+func (f AuditSinkFunc) Write(entry *AuditEntry) error {
+	return f(entry)
+}
+
+// NewAuditWriter returns an AuditSink writing entries as JSON lines to w,
+// e.g. an append-only file. Writes are serialized.
+func NewAuditWriter(w io.Writer) AuditSink {
+	return &auditWriter{enc: json.NewEncoder(w)}
+}
+
+// Write implements AuditSink.
+func (w *auditWriter) Write(entry *AuditEntry) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.enc.Encode(entry)
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/leaderwolfpipi/doris"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAudit(t *testing.T) {
+	key := []byte("secret")
+	token, err := NewToken(NewClaims().Subject("alice"), key, AlgorithmHS256, time.Hour)
+	assert.NoError(t, err)
+
+	var out bytes.Buffer
+	d := doris.New()
+	admin := d.Group("/admin", JWT(key), AuditWithConfig(AuditConfig{
+		Sink:   NewAuditWriter(&out),
+		Params: []string{"reason"},
+	}))
+	admin.DELETE("/users/:id", func(c *doris.Context) error {
+		c.String(http.StatusNoContent, "")
+		return nil
+	})
+
+	req := httptest.NewRequest(http.MethodDelete, "/admin/users/42?reason=spam&password=hunter2", nil)
+	req.Header.Set(doris.Authorization, DefaultJWTConfig.AuthScheme+" "+token)
+	req.Header.Set("User-Agent", "curl/7.68.0")
+	req.RemoteAddr = "10.0.0.1:1234"
+	d.ServeHTTP(httptest.NewRecorder(), req)
+
+	var entry AuditEntry
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &entry))
+	assert.Equal(t, "alice", entry.Subject)
+	assert.Equal(t, http.MethodDelete, entry.Method)
+	assert.Equal(t, "/admin/users/:id", entry.Route)
+	assert.Equal(t, map[string]string{"id": "42", "reason": "spam"}, entry.Params)
+	assert.Equal(t, "10.0.0.1", entry.IP)
+	assert.Equal(t, "curl/7.68.0", entry.UserAgent)
+	assert.Equal(t, http.StatusNoContent, entry.Status)
+	assert.False(t, entry.Time.IsZero())
+}
+
+func TestAuditOutcome(t *testing.T) {
+	var entries []*AuditEntry
+	d := doris.New()
+	d.Use(Audit(AuditSinkFunc(func(entry *AuditEntry) error {
+		entries = append(entries, entry)
+		return errors.New("sink down")
+	})))
+	d.POST("/settings", func(c *doris.Context) error {
+		return doris.PermissionDeniedErr
+	})
+	d.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/settings", nil))
+
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "", entries[0].Subject)
+	assert.Equal(t, http.StatusForbidden, entries[0].Status)
+	assert.Equal(t, doris.PermissionDeniedErr.Error(), entries[0].Error)
+	assert.Nil(t, entries[0].Params)
+}
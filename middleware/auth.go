@@ -1 +1,153 @@
 package middleware
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/leaderwolfpipi/doris"
+)
+
+type (
+	// BasicAuthConfig defines the config for BasicAuth middleware.
+	BasicAuthConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Validator is a function to validate BasicAuth credentials.
+		// Required.
+		Validator BasicAuthValidator
+
+		// Realm is a string to define realm attribute of BasicAuth.
+		// Optional. Default value "Restricted".
+		Realm string
+
+		// ContextKey is the context key to store the authenticated username.
+		// Optional. Default value "username".
+		ContextKey string
+	}
+
+	// BasicAuthValidator defines a function to validate BasicAuth credentials.
+	BasicAuthValidator func(user, pass string, c *doris.Context) (bool, error)
+)
+
+const (
+	basic        = "basic"
+	defaultRealm = "Restricted"
+)
+
+var (
+	// DefaultBasicAuthConfig is the default BasicAuth middleware config.
+	DefaultBasicAuthConfig = BasicAuthConfig{
+		Skipper:    DefaultSkipper,
+		Realm:      defaultRealm,
+		ContextKey: "username",
+	}
+)
+
+// BasicAuth returns an BasicAuth middleware.
+//
+// For valid credentials it calls the next handler.
+// For missing or invalid credentials, it sends "401 - Unauthorized" response.
+func BasicAuth(fn BasicAuthValidator) doris.HandlerFunc {
+	c := DefaultBasicAuthConfig
+	c.Validator = fn
+	return BasicAuthWithConfig(c)
+}
+
+// BasicAuthWithConfig returns an BasicAuth middleware with config.
+// See `BasicAuth()`.
+func BasicAuthWithConfig(config BasicAuthConfig) doris.HandlerFunc {
+	// Defaults
+	if config.Validator == nil {
+		panic("doris: basic-auth middleware requires a validator function")
+	}
+	if config.Skipper == nil {
+		config.Skipper = DefaultBasicAuthConfig.Skipper
+	}
+	if config.Realm == "" {
+		config.Realm = defaultRealm
+	}
+	if config.ContextKey == "" {
+		config.ContextKey = DefaultBasicAuthConfig.ContextKey
+	}
+	realm := basic + " realm=" + strconv.Quote(config.Realm)
+
+	return func(c *doris.Context) error {
+		if config.Skipper(c) {
+			c.Next()
+			return nil
+		}
+
+		user, pass, ok := basicAuthCredentials(c.Request.Header.Get(doris.HeaderAuthorization))
+		if ok {
+			valid, err := config.Validator(user, pass, c)
+			if err != nil {
+				return err
+			}
+			if valid {
+				c.SetParam(config.ContextKey, user)
+				c.Next()
+				return nil
+			}
+		}
+
+		err := doris.BasicAuthMissingErr
+		if ok {
+			err = doris.BasicAuthInvalidErr
+		}
+		// Need to return `401` for browsers to pop-up login box.
+		c.Response.Header().Set(doris.HeaderWWWAuthenticate, realm)
+		c.ErrorJson(http.StatusUnauthorized, http.StatusUnauthorized, err.Error())
+		c.Abort()
+		return err
+	}
+}
+
+// basicAuthCredentials parses the credentials of a basic Authorization header.
+func basicAuthCredentials(auth string) (user, pass string, ok bool) {
+	l := len(basic)
+	if len(auth) <= l+1 || !strings.EqualFold(auth[:l], basic) || auth[l] != ' ' {
+		return "", "", false
+	}
+	b, err := base64.StdEncoding.DecodeString(auth[l+1:])
+	if err != nil {
+		return "", "", false
+	}
+	cred := string(b)
+	i := strings.IndexByte(cred, ':')
+	if i < 0 {
+		return "", "", false
+	}
+	return cred[:i], cred[i+1:], true
+}
+
+// SecureCompare compares two strings in constant time, it doesn't leak the
+// length of the secret either as both values are hashed first.
+func SecureCompare(given, actual string) bool {
+	g := sha256.Sum256([]byte(given))
+	a := sha256.Sum256([]byte(actual))
+	return subtle.ConstantTimeCompare(g[:], a[:]) == 1
+}
+
+// BasicAuthAccounts returns a validator accepting the given username/password
+// pairs, compared in constant time.
+//
+// Usage: d.Use(middleware.BasicAuth(middleware.BasicAuthAccounts(map[string]string{"admin": "secret"})))
+func BasicAuthAccounts(accounts map[string]string) BasicAuthValidator {
+	return func(user, pass string, c *doris.Context) (bool, error) {
+		valid := false
+		for u, p := range accounts {
+			// 遍历全部账户, 避免用户名存在与否产生时间差
+			userOK := SecureCompare(user, u)
+			passOK := SecureCompare(pass, p)
+			if userOK && passOK {
+				valid = true
+			}
+		}
+		return valid, nil
+	}
+}
@@ -0,0 +1,68 @@
+// basic auth test file
+package middleware
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/leaderwolfpipi/doris"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBasicAuth(t *testing.T) {
+	validator := BasicAuthAccounts(map[string]string{"joe": "secret"})
+	serve := func(config BasicAuthConfig, auth string) (*httptest.ResponseRecorder, string) {
+		var user string
+		d := doris.New()
+		d.GET("/", BasicAuthWithConfig(config), func(c *doris.Context) error {
+			user, _ = c.Param("username").(string)
+			c.String(http.StatusOK, "ok")
+			return nil
+		})
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if auth != "" {
+			req.Header.Set(doris.HeaderAuthorization, auth)
+		}
+		res := httptest.NewRecorder()
+		d.ServeHTTP(res, req)
+		return res, user
+	}
+	encode := func(cred string) string {
+		return base64.StdEncoding.EncodeToString([]byte(cred))
+	}
+
+	config := BasicAuthConfig{Validator: validator}
+	res, user := serve(config, "Basic "+encode("joe:secret"))
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "joe", user)
+
+	// case-insensitive scheme
+	res, _ = serve(config, "basic "+encode("joe:secret"))
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	for _, auth := range []string{"", "Basic " + encode("joe:wrong"), "Basic " + encode("jane:secret"), "Basic invalid", "Bearer " + encode("joe:secret")} {
+		res, _ = serve(config, auth)
+		assert.Equal(t, http.StatusUnauthorized, res.Code, auth)
+		assert.Equal(t, `basic realm="Restricted"`, res.Header().Get(doris.HeaderWWWAuthenticate), auth)
+	}
+
+	res, _ = serve(BasicAuthConfig{Validator: validator, Realm: "Admin"}, "")
+	assert.Equal(t, `basic realm="Admin"`, res.Header().Get(doris.HeaderWWWAuthenticate))
+
+	res, _ = serve(BasicAuthConfig{Validator: func(string, string, *doris.Context) (bool, error) {
+		return false, errors.New("backend down")
+	}}, "Basic "+encode("joe:secret"))
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+	assert.NotContains(t, res.Body.String(), "backend down")
+
+	assert.Panics(t, func() { BasicAuthWithConfig(BasicAuthConfig{}) })
+}
+
+func TestSecureCompare(t *testing.T) {
+	assert.True(t, SecureCompare("secret", "secret"))
+	assert.False(t, SecureCompare("secret", "secrets"))
+	assert.False(t, SecureCompare("", "secret"))
+}
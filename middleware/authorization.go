@@ -0,0 +1,150 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/leaderwolfpipi/doris"
+)
+
+type (
+	// AuthorizationConfig defines the config for the role and scope middlewares.
+	AuthorizationConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Context key of the token stored by the JWT middleware.
+		// Optional. Default value "user".
+		ContextKey string
+
+		// RolesClaim is the claim holding the roles of the user, an array of
+		// strings or a space or comma separated string.
+		// Optional. Default value "roles".
+		RolesClaim string
+
+		// ScopesClaim is the claim holding the granted scopes, an array of
+		// strings or a space separated string (RFC 8693).
+		// Optional. Default value "scope".
+		ScopesClaim string
+	}
+)
+
+var (
+	// DefaultAuthorizationConfig is the default authorization middleware config.
+	DefaultAuthorizationConfig = AuthorizationConfig{
+		Skipper:     DefaultSkipper,
+		ContextKey:  "user",
+		RolesClaim:  "roles",
+		ScopesClaim: "scope",
+	}
+)
+
+// RequireRoles returns a middleware allowing users having at least one of the roles.
+// It must be used after the JWT middleware.
+//
+// Usage: admin := d.Group("/admin", middleware.JWT(key), middleware.RequireRoles("admin"))
+func RequireRoles(roles ...string) doris.HandlerFunc {
+	return RequireRolesWithConfig(DefaultAuthorizationConfig, roles...)
+}
+
+// RequireRolesWithConfig returns a RequireRoles middleware with config.
+func RequireRolesWithConfig(config AuthorizationConfig, roles ...string) doris.HandlerFunc {
+	config = config.withDefaults()
+	return config.handler(roles, func(claims map[string]interface{}) bool {
+		granted := claimValues(claims[config.RolesClaim])
+		for _, role := range roles {
+			if granted[role] {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// RequireScopes returns a middleware allowing tokens granted all of the scopes.
+// It must be used after the JWT middleware.
+//
+// Usage: d.POST("/orders", middleware.RequireScopes("orders:write"), createOrder)
+func RequireScopes(scopes ...string) doris.HandlerFunc {
+	return RequireScopesWithConfig(DefaultAuthorizationConfig, scopes...)
+}
+
+// RequireScopesWithConfig returns a RequireScopes middleware with config.
+func RequireScopesWithConfig(config AuthorizationConfig, scopes ...string) doris.HandlerFunc {
+	config = config.withDefaults()
+	return config.handler(scopes, func(claims map[string]interface{}) bool {
+		granted := claimValues(claims[config.ScopesClaim])
+		for _, scope := range scopes {
+			if !granted[scope] {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// withDefaults fills the defaults of the config.
+func (config AuthorizationConfig) withDefaults() AuthorizationConfig {
+	if config.Skipper == nil {
+		config.Skipper = DefaultAuthorizationConfig.Skipper
+	}
+	if config.ContextKey == "" {
+		config.ContextKey = DefaultAuthorizationConfig.ContextKey
+	}
+	if config.RolesClaim == "" {
+		config.RolesClaim = DefaultAuthorizationConfig.RolesClaim
+	}
+	if config.ScopesClaim == "" {
+		config.ScopesClaim = DefaultAuthorizationConfig.ScopesClaim
+	}
+	return config
+}
+
+// handler returns the middleware checking the claims with allow.
+func (config AuthorizationConfig) handler(required []string, allow func(map[string]interface{}) bool) doris.HandlerFunc {
+	return func(c *doris.Context) error {
+		if config.Skipper(c) {
+			c.Next()
+			return nil
+		}
+
+		token, ok := c.Param(config.ContextKey).(*jwt.Token)
+		if !ok {
+			c.ErrorJson(doris.JWTMissingErr.Status, doris.JWTMissingErr.Code, "JWT ERR: "+doris.JWTMissingErr.Error())
+			c.Abort()
+			return doris.JWTMissingErr
+		}
+		claims, err := claimsMap(token.Claims)
+		if err != nil || !allow(claims) {
+			c.ErrorJson(doris.PermissionDeniedErr.Status, doris.PermissionDeniedErr.Code,
+				doris.PermissionDeniedErr.Error(), doris.D{"required": required})
+			c.Abort()
+			return doris.PermissionDeniedErr
+		}
+
+		c.Next()
+		return nil
+	}
+}
+
+// claimValues returns the set of values of an array or a separated string claim.
+func claimValues(claim interface{}) map[string]bool {
+	values := map[string]bool{}
+	switch v := claim.(type) {
+	case string:
+		for _, s := range strings.FieldsFunc(v, func(r rune) bool { return r == ' ' || r == ',' }) {
+			values[s] = true
+		}
+	case []interface{}:
+		for _, s := range v {
+			if s, ok := s.(string); ok {
+				values[s] = true
+			}
+		}
+	case []string:
+		for _, s := range v {
+			values[s] = true
+		}
+	}
+	return values
+}
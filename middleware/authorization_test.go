@@ -0,0 +1,59 @@
+// authorization test file
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/leaderwolfpipi/doris"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireRolesAndScopes(t *testing.T) {
+	serve := func(h doris.HandlerFunc, claims jwt.Claims) (int, error) {
+		d := doris.New()
+		var err error
+		d.GET("/", func(c *doris.Context) error {
+			if claims != nil {
+				c.SetParam("user", &jwt.Token{Claims: claims})
+			}
+			err = h(c)
+			return err
+		})
+		res := httptest.NewRecorder()
+		d.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+		return res.Code, err
+	}
+
+	for _, tc := range []struct {
+		handler doris.HandlerFunc
+		claims  jwt.Claims
+		expCode int
+	}{
+		{handler: RequireRoles("admin"), claims: jwt.MapClaims{"roles": []interface{}{"user", "admin"}}, expCode: http.StatusOK},
+		{handler: RequireRoles("admin", "ops"), claims: jwt.MapClaims{"roles": "ops"}, expCode: http.StatusOK},
+		{handler: RequireRoles("admin"), claims: jwt.MapClaims{"roles": "user,editor"}, expCode: http.StatusForbidden},
+		{handler: RequireRoles("admin"), claims: jwt.MapClaims{}, expCode: http.StatusForbidden},
+		{handler: RequireRoles("admin"), expCode: http.StatusUnauthorized},
+		{handler: RequireScopes("orders:read", "orders:write"), claims: jwt.MapClaims{"scope": "orders:read orders:write"}, expCode: http.StatusOK},
+		{handler: RequireScopes("orders:read", "orders:write"), claims: jwt.MapClaims{"scope": "orders:read"}, expCode: http.StatusForbidden},
+		{
+			handler: RequireScopesWithConfig(AuthorizationConfig{ScopesClaim: "scp"}, "orders:write"),
+			claims:  jwt.MapClaims{"scp": []interface{}{"orders:write"}},
+			expCode: http.StatusOK,
+		},
+	} {
+		code, err := serve(tc.handler, tc.claims)
+		assert.Equal(t, tc.expCode, code)
+		switch tc.expCode {
+		case http.StatusForbidden:
+			assert.Equal(t, doris.PermissionDeniedErr, err)
+		case http.StatusUnauthorized:
+			assert.Equal(t, doris.JWTMissingErr, err)
+		default:
+			assert.NoError(t, err)
+		}
+	}
+}
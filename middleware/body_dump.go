@@ -0,0 +1,198 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/leaderwolfpipi/doris"
+)
+
+type (
+	// BodyDumpConfig defines the config for BodyDump middleware.
+	BodyDumpConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Handler receives request and response payloads.
+		// Required.
+		Handler BodyDumpHandler
+
+		// MaxBodySize is the maximum number of bytes captured from each body.
+		// Larger bodies are truncated in the dump but sent in full.
+		// Optional. Default value 64 KB.
+		MaxBodySize int
+
+		// ContentTypes lists the media type prefixes of the bodies to capture,
+		// such as "application/json" or "text/". Bodies of other types are
+		// passed to the handler as nil.
+		// Optional. Default value nil (capture all).
+		ContentTypes []string
+	}
+
+	// BodyDumpHandler receives the request and response payload.
+	BodyDumpHandler func(c *doris.Context, reqBody, resBody []byte)
+
+	// limitedBuffer keeps up to max bytes written to it.
+	limitedBuffer struct {
+		bytes.Buffer
+		max int
+	}
+
+	// bodyDumpReader captures the request body while it is read.
+	bodyDumpReader struct {
+		io.ReadCloser
+		buf *limitedBuffer
+	}
+
+	// bodyDumpWriter captures the response body while it is written.
+	bodyDumpWriter struct {
+		http.ResponseWriter
+		buf     *limitedBuffer
+		types   []string
+		checked bool
+		capture bool
+	}
+)
+
+var (
+	// DefaultBodyDumpConfig is the default BodyDump middleware config.
+	DefaultBodyDumpConfig = BodyDumpConfig{
+		Skipper:     DefaultSkipper,
+		MaxBodySize: 64 << 10,
+	}
+)
+
+// BodyDump returns a BodyDump middleware.
+//
+// BodyDump middleware captures the request and response payload and calls
+// the registered handler. Bodies are captured as they are read and written,
+// so streaming handlers keep working.
+func BodyDump(handler BodyDumpHandler) doris.HandlerFunc {
+	c := DefaultBodyDumpConfig
+	c.Handler = handler
+	return BodyDumpWithConfig(c)
+}
+
+// BodyDumpWithConfig returns a BodyDump middleware with config.
+// See: `BodyDump()`.
+func BodyDumpWithConfig(config BodyDumpConfig) doris.HandlerFunc {
+	// Defaults
+	if config.Handler == nil {
+		panic("doris: body-dump middleware requires a handler function")
+	}
+	if config.Skipper == nil {
+		config.Skipper = DefaultBodyDumpConfig.Skipper
+	}
+	if config.MaxBodySize <= 0 {
+		config.MaxBodySize = DefaultBodyDumpConfig.MaxBodySize
+	}
+
+	return func(c *doris.Context) error {
+		if config.Skipper(c) {
+			c.Next()
+			return nil
+		}
+
+		// 请求体在读取时同步捕获
+		var reqBuf *limitedBuffer
+		if c.Request.Body != nil && matchContentType(c.Request.Header.Get(doris.HeaderContentType), config.ContentTypes) {
+			reqBuf = &limitedBuffer{max: config.MaxBodySize}
+			c.Request.Body = &bodyDumpReader{ReadCloser: c.Request.Body, buf: reqBuf}
+		}
+
+		// 响应体在写入时同步捕获
+		resWriter := &bodyDumpWriter{
+			ResponseWriter: c.Response.Writer,
+			buf:            &limitedBuffer{max: config.MaxBodySize},
+			types:          config.ContentTypes,
+		}
+		c.Response.Writer = resWriter
+		defer func() {
+			c.Response.Writer = resWriter.ResponseWriter
+		}()
+
+		c.Next()
+
+		var reqBody, resBody []byte
+		if reqBuf != nil {
+			reqBody = reqBuf.Bytes()
+		}
+		if resWriter.capture {
+			resBody = resWriter.buf.Bytes()
+		}
+		config.Handler(c, reqBody, resBody)
+
+		return nil
+	}
+}
+
+// matchContentType reports whether the content type is to be captured.
+func matchContentType(contentType string, types []string) bool {
+	if len(types) == 0 {
+		return true
+	}
+	for _, t := range types {
+		if strings.HasPrefix(contentType, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if n := b.max - b.Len(); n > 0 {
+		if len(p) > n {
+			b.Buffer.Write(p[:n])
+		} else {
+			b.Buffer.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+func (r *bodyDumpReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.buf.Write(p[:n])
+	}
+	return n, err
+}
+
+func (w *bodyDumpWriter) Write(p []byte) (int, error) {
+	// 首次写入时根据响应类型决定是否捕获
+	if !w.checked {
+		w.checked = true
+		w.capture = matchContentType(w.Header().Get(doris.HeaderContentType), w.types)
+	}
+	if w.capture {
+		w.buf.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush implements the http.Flusher interface.
+func (w *bodyDumpWriter) Flush() {
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+// Hijack implements the http.Hijacker interface.
+func (w *bodyDumpWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+// CloseNotify implements the http.CloseNotifier interface.
+func (w *bodyDumpWriter) CloseNotify() <-chan bool {
+	return w.ResponseWriter.(http.CloseNotifier).CloseNotify()
+}
+
+// Push implements the http.Pusher interface.
+func (w *bodyDumpWriter) Push(target string, opts *http.PushOptions) error {
+	if pusher, ok := w.ResponseWriter.(http.Pusher); ok {
+		return pusher.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
@@ -0,0 +1,78 @@
+// body dump test file
+package middleware
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/leaderwolfpipi/doris"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBodyDump(t *testing.T) {
+	var reqDump, resDump []byte
+	d := doris.New()
+	mw := BodyDump(func(c *doris.Context, reqBody, resBody []byte) {
+		reqDump, resDump = reqBody, resBody
+	})
+	d.POST("/", mw, func(c *doris.Context) error {
+		body, _ := ioutil.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, "echo:"+string(body))
+		return nil
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("hello"))
+	res := httptest.NewRecorder()
+	d.ServeHTTP(res, req)
+	assert.Equal(t, "echo:hello", res.Body.String())
+	assert.Equal(t, "hello", string(reqDump))
+	assert.Equal(t, "echo:hello", string(resDump))
+}
+
+func TestBodyDumpLimits(t *testing.T) {
+	var reqDump, resDump []byte
+	d := doris.New()
+	mw := BodyDumpWithConfig(BodyDumpConfig{
+		MaxBodySize:  4,
+		ContentTypes: []string{"application/json"},
+		Handler: func(c *doris.Context, reqBody, resBody []byte) {
+			reqDump, resDump = reqBody, resBody
+		},
+	})
+	d.POST("/", mw, func(c *doris.Context) error {
+		ioutil.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, "plain text")
+		return nil
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"doris"}`))
+	req.Header.Set(doris.HeaderContentType, "application/json")
+	res := httptest.NewRecorder()
+	d.ServeHTTP(res, req)
+	assert.Equal(t, "plain text", res.Body.String())
+	assert.Equal(t, `{"na`, string(reqDump))
+	assert.Nil(t, resDump)
+}
+
+func TestBodyDumpStreaming(t *testing.T) {
+	var resDump []byte
+	d := doris.New()
+	mw := BodyDump(func(c *doris.Context, reqBody, resBody []byte) {
+		resDump = resBody
+	})
+	d.GET("/", mw, func(c *doris.Context) error {
+		c.Response.WriteString("a")
+		c.Response.Flush()
+		c.Response.WriteString("b")
+		return nil
+	})
+
+	res := httptest.NewRecorder()
+	d.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.True(t, res.Flushed)
+	assert.Equal(t, "ab", res.Body.String())
+	assert.Equal(t, "ab", string(resDump))
+}
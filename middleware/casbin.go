@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/leaderwolfpipi/doris"
+)
+
+type (
+	// Enforcer is the enforcement API of casbin, implemented by *casbin.Enforcer.
+	Enforcer interface {
+		Enforce(rvals ...interface{}) (bool, error)
+	}
+
+	// CasbinConfig defines the config for Casbin middleware.
+	CasbinConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Enforcer enforces the policies.
+		// Required.
+		Enforcer Enforcer
+
+		// SubjectFunc returns the subject of the request.
+		// Optional. Default reads the `sub` claim of the token stored by the JWT
+		// middleware, then the username stored by the BasicAuth middleware.
+		SubjectFunc func(c *doris.Context) (string, error)
+
+		// DefaultSubject is enforced for requests without subject, e.g. "anonymous".
+		// Optional. Requests without subject are rejected with 401 when empty.
+		DefaultSubject string
+	}
+)
+
+var (
+	// DefaultCasbinConfig is the default Casbin middleware config.
+	DefaultCasbinConfig = CasbinConfig{
+		Skipper:     DefaultSkipper,
+		SubjectFunc: defaultCasbinSubject,
+	}
+)
+
+// Casbin returns a middleware enforcing (subject, path, method) with the enforcer.
+// It must be used after the authentication middleware.
+//
+// Usage: d.Use(middleware.JWT(key), middleware.Casbin(enforcer))
+func Casbin(enforcer Enforcer) doris.HandlerFunc {
+	c := DefaultCasbinConfig
+	c.Enforcer = enforcer
+	return CasbinWithConfig(c)
+}
+
+// CasbinWithConfig returns a Casbin middleware with config.
+// See `Casbin()`.
+func CasbinWithConfig(config CasbinConfig) doris.HandlerFunc {
+	// Defaults
+	if config.Enforcer == nil {
+		panic("doris: casbin middleware requires an enforcer")
+	}
+	if config.Skipper == nil {
+		config.Skipper = DefaultCasbinConfig.Skipper
+	}
+	if config.SubjectFunc == nil {
+		config.SubjectFunc = DefaultCasbinConfig.SubjectFunc
+	}
+
+	return func(c *doris.Context) error {
+		if config.Skipper(c) {
+			c.Next()
+			return nil
+		}
+
+		subject, err := config.SubjectFunc(c)
+		if err != nil {
+			c.ErrorJson(http.StatusUnauthorized, http.StatusUnauthorized, err.Error())
+			c.Abort()
+			return err
+		}
+		if subject == "" {
+			subject = config.DefaultSubject
+		}
+		if subject == "" {
+			c.ErrorJson(doris.JWTMissingErr.Status, doris.JWTMissingErr.Code, "JWT ERR: "+doris.JWTMissingErr.Error())
+			c.Abort()
+			return doris.JWTMissingErr
+		}
+
+		allowed, err := config.Enforcer.Enforce(subject, c.Request.URL.Path, c.Request.Method)
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			c.ErrorJson(doris.PermissionDeniedErr.Status, doris.PermissionDeniedErr.Code, doris.PermissionDeniedErr.Error())
+			c.Abort()
+			return doris.PermissionDeniedErr
+		}
+
+		c.Next()
+		return nil
+	}
+}
+
+// defaultCasbinSubject reads the subject stored by the JWT or BasicAuth middleware.
+func defaultCasbinSubject(c *doris.Context) (string, error) {
+	if token, err := TokenFromContext(c); err == nil {
+		claims, err := claimsMap(token.Claims)
+		if err != nil {
+			return "", err
+		}
+		sub, _ := claims[ClaimSubject].(string)
+		return sub, nil
+	}
+	user, _ := c.Param(DefaultBasicAuthConfig.ContextKey).(string)
+	return user, nil
+}
@@ -0,0 +1,63 @@
+// casbin test file
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/leaderwolfpipi/doris"
+	"github.com/stretchr/testify/assert"
+)
+
+// policyEnforcer allows the (subject, path, method) tuples of its policies.
+type policyEnforcer map[[3]string]bool
+
+func (e policyEnforcer) Enforce(rvals ...interface{}) (bool, error) {
+	if rvals[0] == "broken" {
+		return false, errors.New("policy backend down")
+	}
+	return e[[3]string{rvals[0].(string), rvals[1].(string), rvals[2].(string)}], nil
+}
+
+func TestCasbin(t *testing.T) {
+	enforcer := policyEnforcer{
+		{"alice", "/orders", http.MethodGet}:     true,
+		{"anonymous", "/orders", http.MethodGet}: true,
+	}
+	serve := func(h doris.HandlerFunc, method string, user interface{}) int {
+		return serveCasbin(h, method, user).Code
+	}
+	token := func(sub string) *jwt.Token {
+		return &jwt.Token{Claims: jwt.MapClaims{ClaimSubject: sub}}
+	}
+
+	assert.Equal(t, http.StatusOK, serve(Casbin(enforcer), http.MethodGet, token("alice")))
+	assert.Equal(t, http.StatusForbidden, serve(Casbin(enforcer), http.MethodPost, token("alice")))
+	assert.Equal(t, http.StatusForbidden, serve(Casbin(enforcer), http.MethodGet, token("bob")))
+	assert.Equal(t, http.StatusUnauthorized, serve(Casbin(enforcer), http.MethodGet, nil))
+	res := serveCasbin(Casbin(enforcer), http.MethodGet, token("broken"))
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+	assert.NotContains(t, res.Body.String(), "policy backend down")
+
+	anonymous := CasbinWithConfig(CasbinConfig{Enforcer: enforcer, DefaultSubject: "anonymous"})
+	assert.Equal(t, http.StatusOK, serve(anonymous, http.MethodGet, nil))
+}
+
+// serveCasbin serves a request of the user through the casbin middleware.
+func serveCasbin(h doris.HandlerFunc, method string, user interface{}) *httptest.ResponseRecorder {
+	d := doris.New()
+	handler := func(c *doris.Context) error {
+		if user != nil {
+			c.SetParam("user", user)
+		}
+		return h(c)
+	}
+	d.GET("/orders", handler)
+	d.POST("/orders", handler)
+	res := httptest.NewRecorder()
+	d.ServeHTTP(res, httptest.NewRequest(method, "/orders", nil))
+	return res
+}
@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"crypto/x509"
+	"net/http"
+
+	"github.com/leaderwolfpipi/doris"
+)
+
+type (
+	// ClientCertConfig defines the config for ClientCert middleware.
+	// A certificate is authorized when it matches any of the allowed values
+	// or the Validator. Only certificates whose chain was verified by the TLS
+	// server are considered, see `Doris.TLSClientCAs`.
+	ClientCertConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// AllowedSubjects are the accepted subject common names.
+		// Optional.
+		AllowedSubjects []string
+
+		// AllowedDNSNames are the accepted DNS subject alternative names.
+		// Optional.
+		AllowedDNSNames []string
+
+		// AllowedURIs are the accepted URI subject alternative names,
+		// e.g. SPIFFE ids "spiffe://example.com/partner".
+		// Optional.
+		AllowedURIs []string
+
+		// AllowedEmails are the accepted email subject alternative names.
+		// Optional.
+		AllowedEmails []string
+
+		// Validator authorizes the certificate with custom rules.
+		// Optional.
+		Validator func(cert *x509.Certificate, c *doris.Context) (bool, error)
+
+		// ContextKey is the context key to store the client certificate.
+		// Optional. Default value "client_cert".
+		ContextKey string
+	}
+)
+
+var (
+	// DefaultClientCertConfig is the default ClientCert middleware config.
+	DefaultClientCertConfig = ClientCertConfig{
+		Skipper:    DefaultSkipper,
+		ContextKey: "client_cert",
+	}
+)
+
+// ClientCert returns a middleware authorizing mutual TLS clients by the
+// subject common name of their certificate.
+//
+// Usage: partners := d.Group("/partners", middleware.ClientCert("partner-a", "partner-b"))
+func ClientCert(subjects ...string) doris.HandlerFunc {
+	c := DefaultClientCertConfig
+	c.AllowedSubjects = subjects
+	return ClientCertWithConfig(c)
+}
+
+// ClientCertWithConfig returns a ClientCert middleware with config.
+// See `ClientCert()`.
+func ClientCertWithConfig(config ClientCertConfig) doris.HandlerFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultClientCertConfig.Skipper
+	}
+	if config.ContextKey == "" {
+		config.ContextKey = DefaultClientCertConfig.ContextKey
+	}
+
+	return func(c *doris.Context) error {
+		if config.Skipper(c) {
+			c.Next()
+			return nil
+		}
+
+		cert := c.ClientCertificate()
+		if cert == nil {
+			c.ErrorJson(http.StatusUnauthorized, http.StatusUnauthorized, doris.ClientCertMissingErr.Error())
+			c.Abort()
+			return doris.ClientCertMissingErr
+		}
+		allowed, err := config.allowed(cert, c)
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			c.ErrorJson(doris.PermissionDeniedErr.Status, doris.PermissionDeniedErr.Code, doris.PermissionDeniedErr.Error())
+			c.Abort()
+			return doris.PermissionDeniedErr
+		}
+
+		c.SetParam(config.ContextKey, cert)
+		c.Next()
+		return nil
+	}
+}
+
+// allowed reports whether the certificate matches the config.
+func (config *ClientCertConfig) allowed(cert *x509.Certificate, c *doris.Context) (bool, error) {
+	if doris.InSlice(cert.Subject.CommonName, config.AllowedSubjects) {
+		return true, nil
+	}
+	for _, name := range cert.DNSNames {
+		if doris.InSlice(name, config.AllowedDNSNames) {
+			return true, nil
+		}
+	}
+	for _, uri := range cert.URIs {
+		if doris.InSlice(uri.String(), config.AllowedURIs) {
+			return true, nil
+		}
+	}
+	for _, email := range cert.EmailAddresses {
+		if doris.InSlice(email, config.AllowedEmails) {
+			return true, nil
+		}
+	}
+	if config.Validator != nil {
+		return config.Validator(cert, c)
+	}
+	return false, nil
+}
@@ -0,0 +1,74 @@
+// client certificate test file
+package middleware
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/leaderwolfpipi/doris"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientCert(t *testing.T) {
+	spiffe, _ := url.Parse("spiffe://example.com/partner")
+	partner := &x509.Certificate{Subject: pkix.Name{CommonName: "partner-a"}}
+	service := &x509.Certificate{Subject: pkix.Name{CommonName: "svc"}, DNSNames: []string{"svc.internal"}, URIs: []*url.URL{spiffe}}
+
+	serveState := func(h doris.HandlerFunc, cert *x509.Certificate, state *tls.ConnectionState) int {
+		d := doris.New()
+		d.GET("/", h, func(c *doris.Context) error {
+			assert.Equal(t, cert, c.Param("client_cert"))
+			c.String(http.StatusOK, "ok")
+			return nil
+		})
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.TLS = state
+		res := httptest.NewRecorder()
+		d.ServeHTTP(res, req)
+		return res.Code
+	}
+	serve := func(h doris.HandlerFunc, cert *x509.Certificate) int {
+		if cert == nil {
+			return serveState(h, nil, nil)
+		}
+		return serveState(h, cert, &tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{cert},
+			VerifiedChains:   [][]*x509.Certificate{{cert}},
+		})
+	}
+
+	assert.Equal(t, http.StatusOK, serve(ClientCert("partner-a"), partner))
+	assert.Equal(t, http.StatusForbidden, serve(ClientCert("partner-b"), partner))
+	assert.Equal(t, http.StatusUnauthorized, serve(ClientCert("partner-a"), nil))
+	assert.Equal(t, http.StatusOK, serve(ClientCertWithConfig(ClientCertConfig{AllowedDNSNames: []string{"svc.internal"}}), service))
+	assert.Equal(t, http.StatusOK, serve(ClientCertWithConfig(ClientCertConfig{AllowedURIs: []string{"spiffe://example.com/partner"}}), service))
+	assert.Equal(t, http.StatusOK, serve(ClientCertWithConfig(ClientCertConfig{
+		Validator: func(cert *x509.Certificate, c *doris.Context) (bool, error) {
+			return cert.Subject.CommonName == "svc", nil
+		},
+	}), service))
+
+	// validator errors are not exposed to the client
+	d := doris.New()
+	d.GET("/", ClientCertWithConfig(ClientCertConfig{
+		Validator: func(cert *x509.Certificate, c *doris.Context) (bool, error) {
+			return false, errors.New("crl fetch failed")
+		},
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{service}}}
+	res := httptest.NewRecorder()
+	d.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+	assert.NotContains(t, res.Body.String(), "crl fetch failed")
+
+	// unverified certificates, e.g. with tls.RequireAnyClientCert, are ignored
+	unverified := &tls.ConnectionState{PeerCertificates: []*x509.Certificate{partner}}
+	assert.Equal(t, http.StatusUnauthorized, serveState(ClientCert("partner-a"), nil, unverified))
+}
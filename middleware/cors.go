@@ -2,22 +2,257 @@
 package middleware
 
 import (
+	"net/http"
+	"strconv"
+	"strings"
+
 	"github.com/leaderwolfpipi/doris"
 )
 
+type (
+	// CorsConfig defines the config for Cors middleware.
+	CorsConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// AllowOrigins defines a list of origins that may access the resource.
+		// A leading "*." in the host matches any subdomain with the same scheme
+		// and port, e.g. "https://*.example.com" matches "https://a.example.com"
+		// but neither "https://example.com" nor "http://a.example.com".
+		// Optional. Default value []string{"*"}.
+		AllowOrigins []string
+
+		// AllowOriginFunc is a custom function to validate the origin. It takes the
+		// origin as an argument and returns true if allowed or false otherwise. If
+		// an error is returned, it is returned by the handler. If this option is
+		// set, AllowOrigins is ignored.
+		// Optional.
+		AllowOriginFunc func(origin string) (bool, error)
+
+		// AllowMethods defines a list methods allowed when accessing the resource.
+		// This is used in response to a preflight request.
+		// Optional. Default value "POST, OPTIONS, GET, PUT, DELETE".
+		AllowMethods []string
+
+		// AllowHeaders defines a list of request headers that can be used when
+		// making the actual request. This is in response to a preflight request.
+		// Optional. Default value DefaultCorsConfig.AllowHeaders.
+		AllowHeaders []string
+
+		// AllowCredentials indicates whether or not the response to the request
+		// can be exposed when the credentials flag is true. It requires explicit
+		// AllowOrigins or an AllowOriginFunc, combining it with the "*" origin
+		// panics.
+		// Optional. Default value false.
+		AllowCredentials bool
+
+		// ExposeHeaders defines a whitelist headers that clients are allowed to
+		// access, e.g. "X-Total-Count".
+		// Optional. Default value []string{}.
+		ExposeHeaders []string
+
+		// MaxAge indicates how long (in seconds) the results of a preflight request
+		// can be cached.
+		// Optional. Default value 0, the header is not sent.
+		MaxAge int
+	}
+
+	// originPattern is a compiled "<scheme>://*.<domain>" origin.
+	originPattern struct {
+		prefix string // "<scheme>://"
+		suffix string // ".<domain>[:port]"
+	}
+)
+
+var (
+	// DefaultCorsConfig is the default Cors middleware config.
+	DefaultCorsConfig = CorsConfig{
+		Skipper:      DefaultSkipper,
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{http.MethodPost, http.MethodOptions, http.MethodGet, http.MethodPut, http.MethodDelete},
+		AllowHeaders: []string{
+			"Content-Type", "Content-Length", "Accept-Encoding", "X-CSRF-Token", "Authorization", "accept",
+			"origin", "Cache-Control", "X-Requested-With", "Token", "Language", "From",
+		},
+	}
+)
+
+// Cors returns a Cross-Origin Resource Sharing (CORS) middleware
+// allowing any origin without credentials.
 func Cors() doris.HandlerFunc {
+	return CorsWithConfig(DefaultCorsConfig)
+}
+
+// CorsWithConfig returns a CORS middleware with config.
+// See: `Cors()`.
+func CorsWithConfig(config CorsConfig) doris.HandlerFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultCorsConfig.Skipper
+	}
+	if len(config.AllowOrigins) == 0 {
+		config.AllowOrigins = DefaultCorsConfig.AllowOrigins
+	}
+	if len(config.AllowMethods) == 0 {
+		config.AllowMethods = DefaultCorsConfig.AllowMethods
+	}
+	if len(config.AllowHeaders) == 0 {
+		config.AllowHeaders = DefaultCorsConfig.AllowHeaders
+	}
+	// Any site could make credentialed requests and read the responses
+	if config.AllowCredentials && config.AllowOriginFunc == nil {
+		for _, o := range config.AllowOrigins {
+			if o == "*" {
+				panic("doris: cors middleware can't allow credentials for the \"*\" origin")
+			}
+		}
+	}
+
+	var patterns []originPattern
+	for _, o := range config.AllowOrigins {
+		if p, ok := parseOriginPattern(o); ok {
+			patterns = append(patterns, p)
+		}
+	}
+
+	// Header values are computed once and assigned to the canonical keys
+	// directly, saving the canonicalization and allocation of Header.Set
+	allowMethods := headerValue(strings.Join(config.AllowMethods, ", "))
+	allowHeaders := headerValue(strings.Join(config.AllowHeaders, ", "))
+	exposeHeaders := headerValue(strings.Join(config.ExposeHeaders, ", "))
+	maxAge := headerValue(strconv.Itoa(config.MaxAge))
+	allowCredentials := headerValue("true")
+	allowAny := headerValue("*")
+
 	return func(c *doris.Context) error {
-		c.Response.Writer.Header().Set(doris.HeaderAccessControlAllowOrigin, "*")
-		c.Response.Writer.Header().Set(doris.HeaderAccessControlAllowCredentials, "true")
-		c.Response.Writer.Header().Set(doris.HeaderAccessControlAllowHeaders, "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, Token, Language, From")
-		c.Response.Writer.Header().Set(doris.HeaderAccessControlAllowMethods, "POST, OPTIONS, GET, PUT, DELETE")
+		if config.Skipper(c) {
+			c.Next()
+			return nil
+		}
 
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
+		origin := c.Request.Header.Get(doris.HeaderOrigin)
+		allowOrigin, err := config.allowOrigin(origin, patterns)
+		if err != nil {
+			return err
+		}
+
+		header := c.Response.Header()
+		// The response depends on the origin, shared caches must key on it
+		vary := append(header[doris.HeaderVary], doris.HeaderOrigin)
+		if c.Request.Method == http.MethodOptions {
+			vary = append(vary, doris.HeaderAccessControlRequestMethod, doris.HeaderAccessControlRequestHeaders)
+		}
+		header[doris.HeaderVary] = vary
+		if allowOrigin != "" {
+			if allowOrigin == "*" {
+				header[doris.HeaderAccessControlAllowOrigin] = allowAny
+			} else {
+				header[doris.HeaderAccessControlAllowOrigin] = []string{allowOrigin}
+			}
+			if config.AllowCredentials {
+				header[doris.HeaderAccessControlAllowCredentials] = allowCredentials
+			}
+			header[doris.HeaderAccessControlAllowHeaders] = allowHeaders
+			header[doris.HeaderAccessControlAllowMethods] = allowMethods
+		}
+
+		if c.Request.Method == http.MethodOptions {
+			if allowOrigin != "" && config.MaxAge > 0 {
+				header[doris.HeaderAccessControlMaxAge] = maxAge
+			}
+			c.AbortWithStatus(http.StatusNoContent)
 			return nil
 		}
 
+		if allowOrigin != "" && exposeHeaders[0] != "" {
+			header[doris.HeaderAccessControlExposeHeaders] = exposeHeaders
+		}
+
 		c.Next()
 		return nil
 	}
 }
+
+// CorsGroup attaches a CORS policy to a route group, e.g. a strict policy for
+// the admin endpoints next to a public API. It registers a catch-all OPTIONS
+// route under the group so preflight requests reach the group policy, routes
+// of the group must therefore not handle OPTIONS themselves. Don't combine it
+// with a global Cors middleware.
+//
+// Usage:
+//
+//	admin := d.Group("/admin")
+//	middleware.CorsGroup(admin, middleware.CorsConfig{AllowOrigins: []string{"https://console.internal"}})
+func CorsGroup(group *doris.RouteGroup, config CorsConfig) {
+	group.Use(CorsWithConfig(config))
+	group.OPTIONS("/*path", func(c *doris.Context) error {
+		return nil
+	})
+}
+
+// headerValue returns a precomputed header value shared by all responses.
+// The slice has no spare capacity, so a later Header.Add copies it instead
+// of appending to the shared array.
+func headerValue(v string) []string {
+	return []string{v}
+}
+
+// allowOrigin returns the value of the Access-Control-Allow-Origin header
+// for the origin, or "" when the origin is not allowed.
+func (config *CorsConfig) allowOrigin(origin string, patterns []originPattern) (string, error) {
+	if config.AllowOriginFunc != nil {
+		if origin == "" {
+			return "", nil
+		}
+		allowed, err := config.AllowOriginFunc(origin)
+		if err != nil || !allowed {
+			return "", err
+		}
+		return origin, nil
+	}
+	for _, o := range config.AllowOrigins {
+		if o == "*" {
+			return "*", nil
+		}
+		if o == origin {
+			return origin, nil
+		}
+	}
+	for _, p := range patterns {
+		if p.match(origin) {
+			return origin, nil
+		}
+	}
+	return "", nil
+}
+
+// parseOriginPattern compiles a wildcard subdomain origin.
+func parseOriginPattern(origin string) (originPattern, bool) {
+	i := strings.Index(origin, "://*.")
+	if i <= 0 {
+		return originPattern{}, false
+	}
+	return originPattern{
+		prefix: strings.ToLower(origin[:i+3]),
+		suffix: strings.ToLower(origin[i+4:]),
+	}, true
+}
+
+// match reports whether the origin is a subdomain matching the pattern.
+func (p originPattern) match(origin string) bool {
+	origin = strings.ToLower(origin)
+	if !strings.HasPrefix(origin, p.prefix) || !strings.HasSuffix(origin, p.suffix) {
+		return false
+	}
+	sub := origin[len(p.prefix) : len(origin)-len(p.suffix)]
+	if sub == "" || sub[0] == '.' || sub[len(sub)-1] == '.' {
+		return false
+	}
+	// The subdomain may only contain host name characters
+	for _, r := range sub {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
@@ -2,10 +2,14 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/leaderwolfpipi/doris"
+	"github.com/stretchr/testify/assert"
 )
 
 // Test cors in doris
@@ -26,5 +30,273 @@ func TestDorisCors(t *testing.T) {
 
 	d.GET("/", handler)
 
-	d.Run("localhost:9528")
+	// listen on an ephemeral port so tests can run in parallel
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() {
+		result <- d.RunWithContext(ctx, "localhost:0")
+	}()
+	<-d.Started()
+
+	res, err := http.Get("http://" + d.Addr().String() + "/")
+	assert.Nil(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	cancel()
+	assert.Nil(t, <-result)
+}
+
+// serveCors serves a request from the origin through the cors middleware.
+func serveCors(config CorsConfig, method, origin string) *httptest.ResponseRecorder {
+	d := doris.New()
+	handler := func(c *doris.Context) error {
+		c.String(http.StatusOK, "test")
+		return nil
+	}
+	d.GET("/", CorsWithConfig(config), handler)
+	d.OPTIONS("/", CorsWithConfig(config), handler)
+	req := httptest.NewRequest(method, "/", nil)
+	if origin != "" {
+		req.Header.Set(doris.HeaderOrigin, origin)
+	}
+	res := httptest.NewRecorder()
+	d.ServeHTTP(res, req)
+	return res
+}
+
+func TestCorsAllowOrigins(t *testing.T) {
+	config := CorsConfig{AllowOrigins: []string{"https://a.example.com"}}
+	res := serveCors(config, http.MethodGet, "https://a.example.com")
+	assert.Equal(t, "https://a.example.com", res.Header().Get(doris.HeaderAccessControlAllowOrigin))
+	res = serveCors(config, http.MethodGet, "https://b.example.com")
+	assert.Equal(t, "", res.Header().Get(doris.HeaderAccessControlAllowOrigin))
+
+	res = serveCors(DefaultCorsConfig, http.MethodOptions, "https://b.example.com")
+	assert.Equal(t, http.StatusNoContent, res.Code)
+	assert.Equal(t, "*", res.Header().Get(doris.HeaderAccessControlAllowOrigin))
+}
+
+func TestCorsAllowOriginFunc(t *testing.T) {
+	tenants := map[string]bool{"https://tenant.example.com": true}
+	config := CorsConfig{AllowOriginFunc: func(origin string) (bool, error) {
+		if origin == "https://broken.example.com" {
+			return false, errors.New("registry down")
+		}
+		return tenants[origin], nil
+	}}
+
+	res := serveCors(config, http.MethodGet, "https://tenant.example.com")
+	assert.Equal(t, "https://tenant.example.com", res.Header().Get(doris.HeaderAccessControlAllowOrigin))
+	res = serveCors(config, http.MethodGet, "https://other.example.com")
+	assert.Equal(t, "", res.Header().Get(doris.HeaderAccessControlAllowOrigin))
+	assert.Equal(t, http.StatusOK, res.Code)
+	res = serveCors(config, http.MethodGet, "https://broken.example.com")
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+	assert.NotContains(t, res.Body.String(), "registry down")
+}
+
+func TestCorsMaxAgeAndExposeHeaders(t *testing.T) {
+	config := CorsConfig{MaxAge: 600, ExposeHeaders: []string{"X-Total-Count", "X-Request-ID"}}
+	res := serveCors(config, http.MethodOptions, "https://a.example.com")
+	assert.Equal(t, "600", res.Header().Get(doris.HeaderAccessControlMaxAge))
+	assert.Equal(t, "", res.Header().Get(doris.HeaderAccessControlExposeHeaders))
+
+	res = serveCors(config, http.MethodGet, "https://a.example.com")
+	assert.Equal(t, "", res.Header().Get(doris.HeaderAccessControlMaxAge))
+	assert.Equal(t, "X-Total-Count, X-Request-ID", res.Header().Get(doris.HeaderAccessControlExposeHeaders))
+
+	res = serveCors(DefaultCorsConfig, http.MethodOptions, "https://a.example.com")
+	assert.Equal(t, "", res.Header().Get(doris.HeaderAccessControlMaxAge))
+}
+
+func TestCorsWildcardOrigins(t *testing.T) {
+	config := CorsConfig{AllowOrigins: []string{"https://*.example.com", "http://*.local.test:8080"}}
+	for origin, allowed := range map[string]bool{
+		"https://a.example.com":            true,
+		"https://a.b.example.com":          true,
+		"https://A.Example.com":            true,
+		"http://dev.local.test:8080":       true,
+		"https://example.com":              false,
+		"http://a.example.com":             false,
+		"https://a.example.com.evil.com":   false,
+		"https://evilexample.com":          false,
+		"https://evil.com/.example.com":    false,
+		"https://evil.com?.example.com":    false,
+		"https://.example.com":             false,
+		"http://dev.local.test":            false,
+		"http://dev.local.test:8081":       false,
+		"https://a.example.com:8443":       false,
+		"https://user@a.example.com":       false,
+		"https://evil.com#x.example.com":   false,
+		"https://a.example.com.example.co": false,
+		"https://a%2eevil.com.example.com": false,
+	} {
+		res := serveCors(config, http.MethodGet, origin)
+		expected := ""
+		if allowed {
+			expected = origin
+		}
+		assert.Equal(t, expected, res.Header().Get(doris.HeaderAccessControlAllowOrigin), origin)
+	}
+}
+
+func TestCorsCredentials(t *testing.T) {
+	config := CorsConfig{AllowOrigins: []string{"https://a.example.com"}, AllowCredentials: true}
+	res := serveCors(config, http.MethodGet, "https://a.example.com")
+	assert.Equal(t, "https://a.example.com", res.Header().Get(doris.HeaderAccessControlAllowOrigin))
+	assert.Equal(t, "true", res.Header().Get(doris.HeaderAccessControlAllowCredentials))
+	assert.Equal(t, []string{doris.HeaderOrigin}, res.Header()[doris.HeaderVary])
+
+	res = serveCors(config, http.MethodOptions, "https://a.example.com")
+	assert.Equal(t, "https://a.example.com", res.Header().Get(doris.HeaderAccessControlAllowOrigin))
+	assert.Equal(t, []string{
+		doris.HeaderOrigin,
+		doris.HeaderAccessControlRequestMethod,
+		doris.HeaderAccessControlRequestHeaders,
+	}, res.Header()[doris.HeaderVary])
+
+	// not allowed origins get no credentials either
+	res = serveCors(config, http.MethodGet, "https://b.example.com")
+	assert.Equal(t, "", res.Header().Get(doris.HeaderAccessControlAllowOrigin))
+	assert.Equal(t, "", res.Header().Get(doris.HeaderAccessControlAllowCredentials))
+	assert.Equal(t, []string{doris.HeaderOrigin}, res.Header()[doris.HeaderVary])
+
+	// origins validated by AllowOriginFunc are echoed
+	res = serveCors(CorsConfig{
+		AllowOriginFunc: func(origin string) (bool, error) {
+			return origin == "https://b.example.com", nil
+		},
+		AllowCredentials: true,
+	}, http.MethodGet, "https://b.example.com")
+	assert.Equal(t, "https://b.example.com", res.Header().Get(doris.HeaderAccessControlAllowOrigin))
+	assert.Equal(t, "true", res.Header().Get(doris.HeaderAccessControlAllowCredentials))
+
+	// the wildcard never allows credentials
+	assert.Panics(t, func() {
+		CorsWithConfig(CorsConfig{AllowCredentials: true})
+	})
+	assert.Panics(t, func() {
+		CorsWithConfig(CorsConfig{AllowOrigins: []string{"https://a.example.com", "*"}, AllowCredentials: true})
+	})
+
+	// the default middleware doesn't allow credentials
+	d := doris.New()
+	d.GET("/", Cors(), func(c *doris.Context) error { return nil })
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(doris.HeaderOrigin, "https://evil.example.com")
+	res = httptest.NewRecorder()
+	d.ServeHTTP(res, req)
+	assert.Equal(t, "*", res.Header().Get(doris.HeaderAccessControlAllowOrigin))
+	assert.Equal(t, "", res.Header().Get(doris.HeaderAccessControlAllowCredentials))
 }
+
+func TestCorsGroup(t *testing.T) {
+	d := doris.New()
+	handler := func(c *doris.Context) error {
+		c.String(http.StatusOK, "test")
+		return nil
+	}
+	public := d.Group("/api")
+	CorsGroup(public, CorsConfig{})
+	public.GET("/items", handler)
+	admin := d.Group("/admin")
+	CorsGroup(admin, CorsConfig{AllowOrigins: []string{"https://console.internal"}, AllowCredentials: true})
+	admin.GET("/users", handler)
+	d.GET("/health", handler)
+
+	serve := func(method, path, origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set(doris.HeaderOrigin, origin)
+		res := httptest.NewRecorder()
+		d.ServeHTTP(res, req)
+		return res
+	}
+
+	res := serve(http.MethodGet, "/api/items", "https://a.example.com")
+	assert.Equal(t, "*", res.Header().Get(doris.HeaderAccessControlAllowOrigin))
+	res = serve(http.MethodOptions, "/api/items", "https://a.example.com")
+	assert.Equal(t, http.StatusNoContent, res.Code)
+	assert.Equal(t, "*", res.Header().Get(doris.HeaderAccessControlAllowOrigin))
+
+	res = serve(http.MethodOptions, "/admin/users", "https://a.example.com")
+	assert.Equal(t, "", res.Header().Get(doris.HeaderAccessControlAllowOrigin))
+	res = serve(http.MethodGet, "/admin/users", "https://console.internal")
+	assert.Equal(t, "https://console.internal", res.Header().Get(doris.HeaderAccessControlAllowOrigin))
+
+	res = serve(http.MethodGet, "/health", "https://a.example.com")
+	assert.Equal(t, "", res.Header().Get(doris.HeaderAccessControlAllowOrigin))
+}
+
+func TestCorsSharedHeaderValues(t *testing.T) {
+	d := doris.New()
+	d.GET("/", CorsWithConfig(CorsConfig{ExposeHeaders: []string{"X-Total-Count"}}), func(c *doris.Context) error {
+		// Appending to a precomputed value must not leak into other responses
+		c.Response.Header().Add(doris.HeaderAccessControlExposeHeaders, "X-Page")
+		c.String(http.StatusOK, "test")
+		return nil
+	})
+	for i := 0; i < 2; i++ {
+		res := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(doris.HeaderOrigin, "https://a.example.com")
+		d.ServeHTTP(res, req)
+		assert.Equal(t, []string{"X-Total-Count", "X-Page"}, res.Header()[doris.HeaderAccessControlExposeHeaders])
+		assert.Equal(t, []string{"*"}, res.Header()[doris.HeaderAccessControlAllowOrigin])
+	}
+}
+
+func BenchmarkCors(b *testing.B) {
+	cors := CorsWithConfig(CorsConfig{
+		AllowOrigins:     []string{"https://*.example.com"},
+		AllowCredentials: true,
+		ExposeHeaders:    []string{"X-Total-Count"},
+		MaxAge:           600,
+	})
+	d := doris.New()
+	d.GET("/", cors, func(c *doris.Context) error { return nil })
+	d.OPTIONS("/", cors)
+	for _, method := range []string{http.MethodGet, http.MethodOptions} {
+		b.Run(method, func(b *testing.B) {
+			req := httptest.NewRequest(method, "/", nil)
+			req.Header.Set(doris.HeaderOrigin, "https://a.example.com")
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				d.ServeHTTP(&benchmarkWriter{header: http.Header{}}, req)
+			}
+		})
+	}
+}
+
+// BenchmarkHeaderWrite compares Header.Set with assigning a precomputed
+// value to the canonical key, as done by the Cors middleware.
+func BenchmarkHeaderWrite(b *testing.B) {
+	const methods = "POST, OPTIONS, GET, PUT, DELETE"
+	b.Run("Set", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			header := http.Header{}
+			header.Set(doris.HeaderAccessControlAllowMethods, methods)
+			header.Set(doris.HeaderAccessControlAllowCredentials, "true")
+		}
+	})
+	b.Run("Precomputed", func(b *testing.B) {
+		allowMethods, allowCredentials := headerValue(methods), headerValue("true")
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			header := http.Header{}
+			header[doris.HeaderAccessControlAllowMethods] = allowMethods
+			header[doris.HeaderAccessControlAllowCredentials] = allowCredentials
+		}
+	})
+}
+
+// benchmarkWriter is a ResponseWriter discarding the body.
+type benchmarkWriter struct {
+	header http.Header
+}
+
+func (w *benchmarkWriter) Header() http.Header         { return w.header }
+func (w *benchmarkWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *benchmarkWriter) WriteHeader(int)             {}
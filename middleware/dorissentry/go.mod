@@ -0,0 +1,10 @@
+module github.com/leaderwolfpipi/doris/middleware/dorissentry
+
+go 1.13
+
+require (
+	github.com/getsentry/sentry-go v0.5.1
+	github.com/leaderwolfpipi/doris v0.0.0-00010101000000-000000000000
+)
+
+replace github.com/leaderwolfpipi/doris => ../..
@@ -0,0 +1,37 @@
+// Package dorissentry reports the panics recovered by the Recovery
+// middleware to Sentry:
+//
+//	d.Use(middleware.RecoveryWithConfig(middleware.RecoveryConfig{
+//		ReportFunc: dorissentry.Report(nil),
+//	}))
+package dorissentry
+
+import (
+	"github.com/getsentry/sentry-go"
+	"github.com/leaderwolfpipi/doris"
+	"github.com/leaderwolfpipi/doris/middleware"
+)
+
+// Report returns a RecoveryReportFunc sending panics to the hub, along with
+// the request, the request ID, the route and the stack. A nil hub reports
+// to a clone of the current hub.
+func Report(hub *sentry.Hub) middleware.RecoveryReportFunc {
+	return func(c *doris.Context, err interface{}, stack []byte) {
+		h := hub
+		if h == nil {
+			h = sentry.CurrentHub().Clone()
+		}
+		h.WithScope(func(scope *sentry.Scope) {
+			scope.SetRequest(sentry.Request{}.FromHTTPRequest(c.Request))
+			if id := c.RequestID(); id != "" {
+				scope.SetTag("request_id", id)
+			}
+			if route := c.FullPath(); route != "" {
+				scope.SetTag("route", route)
+			}
+			scope.SetExtra("handler", c.MainHandlerName())
+			scope.SetExtra("stack", string(stack))
+			h.RecoverWithContext(c.Request.Context(), err)
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package middleware
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type (
+	// jwks caches the keys of a JSON Web Key Set endpoint.
+	jwks struct {
+		url         string
+		client      *http.Client
+		minInterval time.Duration // minimal interval between two refreshes
+		fetching    sync.Mutex    // serializes the fetches, held without lock
+		lock        sync.RWMutex
+		keys        map[string]interface{}
+		nextRefresh time.Time // earliest time of the next refresh
+	}
+
+	// jsonWebKey is a single key of a JSON Web Key Set.
+	// See: https://tools.ietf.org/html/rfc7517
+	jsonWebKey struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Use string `json:"use"`
+		Alg string `json:"alg"`
+		// RSA
+		N string `json:"n"`
+		E string `json:"e"`
+		// EC
+		Crv string `json:"crv"`
+		X   string `json:"x"`
+		Y   string `json:"y"`
+	}
+)
+
+// Default JWKS options
+const (
+	defaultJWKSRefreshInterval = 5 * time.Minute
+	defaultJWKSTimeout         = 10 * time.Second
+	// jwksRetryInterval is the minimal interval between a failed fetch and the next one
+	jwksRetryInterval = 5 * time.Second
+)
+
+// newJWKS returns a JWKS cache for the given url.
+// Keys are fetched lazily on first use.
+func newJWKS(url string, minInterval time.Duration, client *http.Client) *jwks {
+	if minInterval <= 0 {
+		minInterval = defaultJWKSRefreshInterval
+	}
+	if client == nil {
+		client = &http.Client{Timeout: defaultJWKSTimeout}
+	}
+	return &jwks{
+		url:         url,
+		client:      client,
+		minInterval: minInterval,
+	}
+}
+
+// key returns the public key for kid, refreshing the key set when kid is unknown.
+// Refreshes are rate limited by minInterval to protect the identity provider.
+func (j *jwks) key(kid string) (interface{}, error) {
+	j.lock.RLock()
+	key, ok := j.keys[kid]
+	canRefresh := !time.Now().Before(j.nextRefresh)
+	j.lock.RUnlock()
+	if ok {
+		return key, nil
+	}
+	if !canRefresh {
+		return nil, fmt.Errorf("unknown jwt key id=%v", kid)
+	}
+	if err := j.refresh(); err != nil {
+		return nil, err
+	}
+	j.lock.RLock()
+	key, ok = j.keys[kid]
+	j.lock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown jwt key id=%v", kid)
+	}
+	return key, nil
+}
+
+// refresh fetches the key set and replaces the cached keys.
+// The key set is fetched without holding the lock, so lookups of known keys
+// don't wait for a slow identity provider. A failed fetch is retried after
+// jwksRetryInterval instead of minInterval.
+func (j *jwks) refresh() error {
+	j.fetching.Lock()
+	defer j.fetching.Unlock()
+	// Another goroutine may have refreshed meanwhile
+	j.lock.RLock()
+	refreshed := time.Now().Before(j.nextRefresh)
+	j.lock.RUnlock()
+	if refreshed {
+		return nil
+	}
+
+	keys, err := j.fetch()
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	if err != nil {
+		retry := jwksRetryInterval
+		if retry > j.minInterval {
+			retry = j.minInterval
+		}
+		j.nextRefresh = time.Now().Add(retry)
+		return err
+	}
+	j.keys = keys
+	j.nextRefresh = time.Now().Add(j.minInterval)
+	return nil
+}
+
+// fetch downloads and decodes the key set.
+func (j *jwks) fetch() (map[string]interface{}, error) {
+	res, err := j.client.Get(j.url)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jwks: unexpected status code=%d", res.StatusCode)
+	}
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&set); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]interface{}, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			// Skip keys we don't understand
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	return keys, nil
+}
+
+// publicKey converts the JWK into a *rsa.PublicKey or *ecdsa.PublicKey.
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("jwks: unsupported curve=%s", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, errors.New("jwks: unsupported key type=" + k.Kty)
+}
+
+// decodeBigInt decodes a base64url encoded big-endian integer.
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
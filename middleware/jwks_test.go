@@ -0,0 +1,93 @@
+// jwks test file
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJWKS(t *testing.T) {
+	private, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.NoError(t, err)
+	n := base64.RawURLEncoding.EncodeToString(private.N.Bytes())
+	e := base64.RawURLEncoding.EncodeToString(big.NewInt(int64(private.E)).Bytes())
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		fmt.Fprintf(w, `{"keys":[{"kty":"RSA","kid":"k1","use":"sig","n":"%s","e":"%s"},{"kty":"oct","kid":"k2"}]}`, n, e)
+	}))
+	defer server.Close()
+
+	set := newJWKS(server.URL, time.Hour, nil)
+	key, err := set.key("k1")
+	if assert.NoError(t, err) {
+		assert.Equal(t, &private.PublicKey, key)
+	}
+	// cached
+	_, err = set.key("k1")
+	assert.NoError(t, err)
+	// unsupported key type is skipped and refresh is rate limited
+	_, err = set.key("k2")
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
+
+func TestJWKSRefresh(t *testing.T) {
+	private, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.NoError(t, err)
+	n := base64.RawURLEncoding.EncodeToString(private.N.Bytes())
+	e := base64.RawURLEncoding.EncodeToString(big.NewInt(int64(private.E)).Bytes())
+
+	var fail int32 = 1
+	block := make(chan struct{})
+	blocked := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&fail) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.URL.Query().Get("slow") != "" {
+			blocked <- struct{}{}
+			<-block
+		}
+		fmt.Fprintf(w, `{"keys":[{"kty":"RSA","kid":"k1","use":"sig","n":"%s","e":"%s"}]}`, n, e)
+	}))
+	defer server.Close()
+
+	// a failed fetch is retried soon instead of after minInterval
+	set := newJWKS(server.URL, time.Hour, nil)
+	_, err = set.key("k1")
+	assert.Error(t, err)
+	assert.True(t, time.Until(set.nextRefresh) <= jwksRetryInterval)
+	atomic.StoreInt32(&fail, 0)
+	set.nextRefresh = time.Time{}
+	_, err = set.key("k1")
+	assert.NoError(t, err)
+	assert.True(t, time.Until(set.nextRefresh) > jwksRetryInterval)
+
+	// known keys are served while a refresh is in flight
+	set.url = server.URL + "?slow=1"
+	set.nextRefresh = time.Time{}
+	done := make(chan error, 1)
+	go func() {
+		_, err := set.key("k2")
+		done <- err
+	}()
+	<-blocked
+	key, err := set.key("k1")
+	assert.NoError(t, err)
+	assert.Equal(t, &private.PublicKey, key)
+	close(block)
+	assert.Error(t, <-done)
+}
@@ -1,10 +1,12 @@
 package middleware
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/leaderwolfpipi/doris"
@@ -16,7 +18,13 @@ type (
 		// Skipper defines a function to skip middleware.
 		Skipper Skipper
 
+		// BeforeFunc defines a function which is executed just before the middleware
+		// extracts the token.
+		BeforeFunc BeforeFunc
+
 		// SuccessHandler defines a function which is executed for a valid token.
+		// It receives the parsed token, a *jwt.Token unless ParseTokenFunc
+		// returns another type.
 		SuccessHandler JWTSuccessHandler
 
 		// ErrorHandler defines a function which is executed for an invalid token.
@@ -27,13 +35,45 @@ type (
 		ErrorHandlerWithContext JWTErrorHandlerWithContext
 
 		// Signing key to validate token. Used as fallback if SigningKeys has length 0.
+		// []byte for HS*, *rsa.PublicKey for RS*/PS*, *ecdsa.PublicKey for ES* and
+		// ed25519.PublicKey for EdDSA. Private keys are accepted as well, their public
+		// part is used. See LoadPublicKeyFromPEM to load keys from PEM data.
 		// Required. This or SigningKeys.
 		SigningKey interface{}
 
+		// Map of signing keys to validate token with kid field usage.
+		// The key is selected by the `kid` header of the token.
+		// Required. This or SigningKey.
+		SigningKeys map[string]interface{}
+
+		// JWKSURL is the url of a JSON Web Key Set used to validate RS*/ES* tokens.
+		// The key set is cached and refreshed when a token has an unknown `kid`.
+		// Optional. Used when SigningKey and SigningKeys are empty.
+		// SigningMethod defaults to RS256 when JWKSURL is set.
+		JWKSURL string
+
+		// JWKSRefreshInterval is the minimal interval between two refreshes of the key set.
+		// Optional. Default value 5 minutes.
+		JWKSRefreshInterval time.Duration
+
+		// OIDCIssuer is the issuer url of an OpenID Connect provider. The JWKS url is
+		// discovered from "<issuer>/.well-known/openid-configuration" and the `iss`
+		// claim must match the issuer. Set ExpectedAudience to the client id or API
+		// identifier registered at the provider to validate the audience as well.
+		// Optional. Used when SigningKey, SigningKeys and JWKSURL are empty.
+		// SigningMethod defaults to RS256 when OIDCIssuer is set.
+		OIDCIssuer string
+
 		// Signing method, used to check token signing method.
 		// Optional. Default value HS256.
 		SigningMethod string
 
+		// Leeway is the tolerated clock skew applied to the `exp`, `nbf` and `iat`
+		// claims. When set, the time claims are checked by the middleware instead
+		// of the claims' own Valid method.
+		// Optional. Default value 0.
+		Leeway time.Duration
+
 		// Context key to store user information from the token into context.
 		// Optional. Default value "user".
 		ContextKey string
@@ -43,20 +83,79 @@ type (
 		// for expand
 		Claims jwt.Claims
 
-		// TokenLookup is a string in the form of "<source>:<name>" that is used
-		// to extract token from the request.
+		// TokenLookup is a string in the form of "<source>:<name>" or
+		// "<source>:<name>,<source>:<name>" that is used to extract token from the request.
+		// Multiple sources are tried in order until one of them yields a token.
 		// Optional. Default value "header:Authorization".
 		// Possible values:
 		// - "header:<name>"
 		// - "query:<name>"
 		// - "param:<name>"
 		// - "cookie:<name>"
+		// - "form:<name>"
 		TokenLookup string
 
 		// AuthScheme to be used in the Authorization header.
 		// Optional. Default value "Bearer".
 		AuthScheme string
 
+		// AuthSchemes are the accepted schemes of the Authorization header,
+		// e.g. []string{"Bearer", "Token"}. Schemes are matched case-insensitively.
+		// Optional. Default value []string{AuthScheme}.
+		AuthSchemes []string
+
+		// ParseTokenFunc defines a user-defined function that parses token from given auth.
+		// Returns the token or error if the token is invalid. It may be used to plug
+		// alternative JWT libraries or custom validation (e.g. introspection endpoints).
+		// When set, the signing key options are ignored. ExpectedIssuer,
+		// ExpectedAudience and RequiredClaims are checked on the returned token,
+		// which must then be a *jwt.Token, jwt.Claims or map[string]interface{}.
+		// Optional. Default is parsing with jwt-go and the signing key options.
+		ParseTokenFunc func(auth string, c *doris.Context) (interface{}, error)
+
+		// RevocationChecker is invoked after the token has been validated.
+		// Returning an error rejects the token, e.g. for logged-out or banned tokens.
+		// See RedisRevocationChecker for a Redis backed implementation.
+		// It can't be combined with ParseTokenFunc, which has to check the
+		// revocation itself.
+		// Optional.
+		RevocationChecker func(c *doris.Context, token *jwt.Token) error
+
+		// ExpectedIssuer is the required value of the `iss` claim.
+		// Optional. Not checked when empty.
+		ExpectedIssuer string
+
+		// ExpectedAudience is a value the `aud` claim must contain.
+		// Optional. Not checked when empty.
+		ExpectedAudience string
+
+		// RequiredClaims are claims which must be present in the token, e.g. "sub".
+		// Optional.
+		RequiredClaims []string
+
+		// Optional enables anonymous access: a missing or invalid token sets no user
+		// in the context and the next handler is still called, so endpoints can
+		// serve anonymous and authenticated users differently.
+		// Optional. Default value false.
+		Optional bool
+
+		// Metrics records the outcome of every token validation, see JWTOutcomeValid etc.
+		// Optional.
+		Metrics JWTMetrics
+
+		// RefreshClaimKey is the claim marking refresh tokens, which are rejected
+		// by the middleware so they can't be used as access tokens.
+		// Optional. Default value "auth_type".
+		RefreshClaimKey string
+
+		// RefreshClaimValue is the value of RefreshClaimKey marking refresh tokens.
+		// Optional. Default value "refresh".
+		RefreshClaimValue string
+
+		// DisableRefreshCheck disables the rejection of refresh tokens.
+		// Optional. Default value false.
+		DisableRefreshCheck bool
+
 		// Get SigningKey func
 		keyFunc jwt.Keyfunc
 	}
@@ -65,8 +164,11 @@ type (
 	// the middleware.
 	Skipper func(*doris.Context) bool
 
+	// BeforeFunc defines a function which is executed just before the middleware.
+	BeforeFunc func(*doris.Context)
+
 	// JWTSuccessHandler defines a function which is executed for a valid token.
-	JWTSuccessHandler func(*doris.Context)
+	JWTSuccessHandler func(c *doris.Context, token interface{})
 
 	// JWTErrorHandler defines a function which is executed for an invalid token.
 	JWTErrorHandler func(error) error
@@ -77,9 +179,39 @@ type (
 	jwtExtractor func(*doris.Context) (string, error)
 )
 
+// Claim keys and values shared by the middleware and the token helpers
+const (
+	// Registered claim names (RFC 7519)
+	ClaimIssuer    = "iss"
+	ClaimSubject   = "sub"
+	ClaimAudience  = "aud"
+	ClaimExpiresAt = "exp"
+	ClaimNotBefore = "nbf"
+	ClaimIssuedAt  = "iat"
+	ClaimID        = "jti"
+
+	// ClaimAuthType is the claim distinguishing access tokens from refresh tokens.
+	ClaimAuthType = "auth_type"
+	// ClaimFamily is the claim linking rotated refresh tokens of one login.
+	ClaimFamily = "fam"
+
+	AuthTypeAccess  = "access"
+	AuthTypeRefresh = "refresh"
+)
+
 // Default Algorithms
 const (
 	AlgorithmHS256 = "HS256"
+	AlgorithmHS384 = "HS384"
+	AlgorithmHS512 = "HS512"
+	AlgorithmRS256 = "RS256"
+	AlgorithmRS384 = "RS384"
+	AlgorithmRS512 = "RS512"
+	AlgorithmPS256 = "PS256"
+	AlgorithmES256 = "ES256"
+	AlgorithmES384 = "ES384"
+	AlgorithmES512 = "ES512"
+	AlgorithmEdDSA = "EdDSA"
 	// DefaultSigningKey = "secret"
 )
 
@@ -92,14 +224,16 @@ var (
 		TokenLookup:   "header:" + doris.Authorization,
 		AuthScheme:    "Bearer",
 		Claims:        jwt.MapClaims{},
+
+		RefreshClaimKey:   ClaimAuthType,
+		RefreshClaimValue: AuthTypeRefresh,
 	}
 )
 
 // JWT returns a JSON Web Token (JWT) auth middleware.
 //
 // For valid token, it sets the user in context and calls next handler.
-// For invalid token, it returns "401 - Unauthorized" error.
-// For missing token, it returns "400 - Bad Request" error.
+// For invalid or missing token, it returns "401 - Unauthorized" error.
 //
 // See: https://jwt.io/introduction
 // See `JWTConfig.TokenLookup`
@@ -116,11 +250,17 @@ func JWTWithConfig(config JWTConfig) doris.HandlerFunc {
 	if config.Skipper == nil {
 		config.Skipper = DefaultJWTConfig.Skipper
 	}
-	if config.SigningKey == nil {
+	if config.ParseTokenFunc == nil && config.SigningKey == nil && len(config.SigningKeys) == 0 && config.JWKSURL == "" && config.OIDCIssuer == "" {
 		panic("doris: jwt middleware requires signing key")
 	}
+	if config.ParseTokenFunc != nil && config.RevocationChecker != nil {
+		panic("doris: jwt middleware revocation checker requires the default token parser")
+	}
 	if config.SigningMethod == "" {
 		config.SigningMethod = DefaultJWTConfig.SigningMethod
+		if config.JWKSURL != "" || config.OIDCIssuer != "" {
+			config.SigningMethod = AlgorithmRS256
+		}
 	}
 	if config.ContextKey == "" {
 		config.ContextKey = DefaultJWTConfig.ContextKey
@@ -134,40 +274,86 @@ func JWTWithConfig(config JWTConfig) doris.HandlerFunc {
 	if config.AuthScheme == "" {
 		config.AuthScheme = DefaultJWTConfig.AuthScheme
 	}
+	if config.RefreshClaimKey == "" {
+		config.RefreshClaimKey = DefaultJWTConfig.RefreshClaimKey
+	}
+	if config.RefreshClaimValue == "" {
+		config.RefreshClaimValue = DefaultJWTConfig.RefreshClaimValue
+	}
+	if config.SigningKey != nil {
+		if err := checkSigningKey(config.SigningMethod, config.SigningKey); err != nil {
+			panic("doris: " + err.Error())
+		}
+	}
+	if config.OIDCIssuer != "" && config.ExpectedIssuer == "" {
+		config.ExpectedIssuer = config.OIDCIssuer
+	}
+	// 远程密钥来源: JWKS或OIDC发现
+	var keySet interface {
+		key(kid string) (interface{}, error)
+	}
+	if config.JWKSURL != "" {
+		keySet = newJWKS(config.JWKSURL, config.JWKSRefreshInterval, nil)
+	} else if config.OIDCIssuer != "" {
+		keySet = newOIDCProvider(config.OIDCIssuer, config.JWKSRefreshInterval, nil)
+	}
 	config.keyFunc = func(t *jwt.Token) (interface{}, error) {
 		// Check the signing method
 		if t.Method.Alg() != config.SigningMethod {
 			return nil, fmt.Errorf("unexpected jwt signing method=%v", t.Header["alg"])
 		}
+		// Select the key by kid when multiple keys are configured
+		if len(config.SigningKeys) > 0 {
+			if kid, ok := t.Header["kid"].(string); ok {
+				if key, ok := config.SigningKeys[kid]; ok {
+					return verificationKey(key), nil
+				}
+			}
+			return nil, fmt.Errorf("unexpected jwt key id=%v", t.Header["kid"])
+		}
+		// Fetch the key from the JSON Web Key Set or the OIDC provider
+		if keySet != nil && config.SigningKey == nil {
+			kid, _ := t.Header["kid"].(string)
+			return keySet.key(kid)
+		}
+
+		return verificationKey(config.SigningKey), nil
+	}
 
-		return config.SigningKey, nil
+	if config.ParseTokenFunc == nil {
+		config.ParseTokenFunc = config.defaultParseToken
 	}
 
 	// Initialize
-	parts := strings.Split(config.TokenLookup, ":")
-	extractor := jwtFromHeader(parts[1], config.AuthScheme)
-	switch parts[0] {
-	case "query":
-		extractor = jwtFromQuery(parts[1])
-	case "param":
-		extractor = jwtFromParam(parts[1])
-	case "cookie":
-		extractor = jwtFromCookie(parts[1])
+	if len(config.AuthSchemes) == 0 {
+		config.AuthSchemes = []string{config.AuthScheme}
 	}
+	extractors := createExtractors(config.TokenLookup, config.AuthSchemes)
 
 	// Return the middleware
 	return func(c *doris.Context) error {
 		// init param
 		var code int = http.StatusUnauthorized
-		var errMsg error = nil
+		var errMsg *doris.CodeError
 
 		if config.Skipper(c) {
 			c.Next()
+			return nil
+		}
+
+		if config.BeforeFunc != nil {
+			config.BeforeFunc(c)
 		}
 
-		auth, err := extractor(c)
+		auth, err := extractToken(c, extractors)
 
 		if err != nil {
+			config.observe(c, JWTOutcomeMissing)
+			if config.Optional {
+				c.Next()
+				return nil
+			}
+
 			if config.ErrorHandler != nil {
 				return config.ErrorHandler(err)
 			}
@@ -177,56 +363,109 @@ func JWTWithConfig(config JWTConfig) doris.HandlerFunc {
 			}
 
 			// Render error json and abort
-			c.Json(http.StatusUnauthorized, doris.D{"code": http.StatusUnauthorized, "message": "JWT ERR: " + err.Error()})
+			c.ErrorJson(http.StatusUnauthorized, http.StatusUnauthorized, "JWT ERR: "+err.Error())
 			c.Abort()
 			return err
 		}
-		token := new(jwt.Token)
-		// Issue #647, #656
-		if _, ok := config.Claims.(jwt.MapClaims); ok {
-			token, err = jwt.Parse(auth, config.keyFunc)
-		} else {
-			t := reflect.ValueOf(config.Claims).Type().Elem()
-			claims := reflect.New(t).Interface().(jwt.Claims)
-			token, err = jwt.ParseWithClaims(auth, claims, config.keyFunc)
-		}
+		token, err := config.ParseTokenFunc(auth, c)
 
 		// 判断claims
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if ok && claims["auth_type"].(string) == "refresh" {
-			// 说明来自刷新token
-			code = doris.TokenRefresh
-			errMsg = doris.TokenRefreshErr
-			c.Json(http.StatusUnauthorized, doris.D{"code": code, "message": "Invalid or Expired JWT: " + errMsg.Error()})
-			c.Abort()
-			return errMsg
+		if t, ok := token.(*jwt.Token); ok && err == nil && !config.DisableRefreshCheck {
+			claims, _ := claimsMap(t.Claims)
+			if authType, ok := claims[config.RefreshClaimKey].(string); ok && authType == config.RefreshClaimValue {
+				config.observe(c, JWTOutcomeRefresh)
+				if config.Optional {
+					c.Next()
+					return nil
+				}
+				// 说明来自刷新token
+				code = doris.TokenRefreshErr.Code
+				errMsg = doris.TokenRefreshErr
+				c.ErrorJson(http.StatusUnauthorized, code, "Invalid or Expired JWT: "+errMsg.Error())
+				c.Abort()
+				return errMsg
+			}
 		}
 
-		if err == nil && token.Valid {
+		// 检查iss/aud及必需的claims
+		if err == nil {
+			if code, cerr := config.validateClaims(token); cerr != nil {
+				config.observe(c, JWTOutcomeInvalid)
+				if config.Optional {
+					c.Next()
+					return nil
+				}
+				if config.ErrorHandler != nil {
+					return config.ErrorHandler(cerr)
+				}
+				if config.ErrorHandlerWithContext != nil {
+					return config.ErrorHandlerWithContext(cerr, c)
+				}
+				c.ErrorJson(http.StatusUnauthorized, code, "Invalid or Expired JWT: "+cerr.Error())
+				c.Abort()
+				return cerr
+			}
+		}
+
+		// 检查token是否已被吊销
+		if t, ok := token.(*jwt.Token); ok && err == nil && config.RevocationChecker != nil {
+			if rerr := config.RevocationChecker(c, t); rerr != nil {
+				config.observe(c, JWTOutcomeRevoked)
+				if config.Optional {
+					c.Next()
+					return nil
+				}
+				if config.ErrorHandler != nil {
+					return config.ErrorHandler(rerr)
+				}
+				if config.ErrorHandlerWithContext != nil {
+					return config.ErrorHandlerWithContext(rerr, c)
+				}
+				c.ErrorJson(http.StatusUnauthorized, doris.TokenRevokedErr.Code, "Invalid or Expired JWT: "+doris.TokenRevokedErr.Error())
+				c.Abort()
+				return rerr
+			}
+		}
+
+		if err == nil {
+			config.observe(c, JWTOutcomeValid)
 			// Store user information from token into context.
 			c.SetParam(config.ContextKey, token)
 			if config.SuccessHandler != nil {
-				config.SuccessHandler(c)
+				config.SuccessHandler(c, token)
 			}
 			c.Next()
 			return nil
 		}
 
 		// check err type of jwt
+		outcome := JWTOutcomeInvalid
 		if ve, ok := err.(*jwt.ValidationError); ok {
 			if ve.Errors&jwt.ValidationErrorMalformed != 0 {
-				code = doris.TokenMalformed
+				code = doris.TokenMalformedErr.Code
 				errMsg = doris.TokenMalformedErr
+				outcome = JWTOutcomeMalformed
 			} else if ve.Errors&jwt.ValidationErrorExpired != 0 {
-				code = doris.TokenExpired
+				code = doris.TokenExpiredErr.Code
 				errMsg = doris.TokenExpiredErr
+				outcome = JWTOutcomeExpired
 			} else if ve.Errors&jwt.ValidationErrorNotValidYet != 0 {
-				code = doris.TokenNotValidYet
+				code = doris.TokenNotValidYetErr.Code
 				errMsg = doris.TokenNotValidYetErr
+				outcome = JWTOutcomeNotValidYet
 			} else {
-				code = doris.TokenInvalid
+				code = doris.TokenInvalidErr.Code
 				errMsg = doris.TokenInvalidErr
 			}
+		} else {
+			code = doris.TokenInvalidErr.Code
+			errMsg = doris.TokenInvalidErr
+		}
+		config.observe(c, outcome)
+
+		if config.Optional {
+			c.Next()
+			return nil
 		}
 
 		if config.ErrorHandler != nil {
@@ -238,19 +477,198 @@ func JWTWithConfig(config JWTConfig) doris.HandlerFunc {
 		}
 
 		// Render error json
-		c.Json(http.StatusUnauthorized, doris.D{"code": code, "message": "Invalid or Expired JWT: " + errMsg.Error() + " [ origin err: " + err.Error() + " ] "})
+		c.ErrorJson(http.StatusUnauthorized, code, "Invalid or Expired JWT: "+errMsg.Error()+" [ origin err: "+err.Error()+" ] ")
 		c.Abort()
-		return err
+		// 返回业务错误，原始错误可通过errors.As获取
+		return errMsg.WithInternal(err)
 	}
 }
 
+// defaultParseToken parses and validates the token with jwt-go.
+func (config *JWTConfig) defaultParseToken(auth string, c *doris.Context) (interface{}, error) {
+	var token *jwt.Token
+	var err error
+	parser := &jwt.Parser{SkipClaimsValidation: config.Leeway > 0}
+	// Issue #647, #656
+	if _, ok := config.Claims.(jwt.MapClaims); ok {
+		token, err = parser.Parse(auth, config.keyFunc)
+	} else {
+		t := reflect.ValueOf(config.Claims).Type().Elem()
+		claims := reflect.New(t).Interface().(jwt.Claims)
+		token, err = parser.ParseWithClaims(auth, claims, config.keyFunc)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if config.Leeway > 0 {
+		if err = validateTimeClaims(token.Claims, config.Leeway); err != nil {
+			return nil, err
+		}
+	}
+	if !token.Valid {
+		return nil, doris.TokenInvalidErr
+	}
+	return token, nil
+}
+
+// validateClaims checks the issuer, the audience and the required claims
+// of the token returned by ParseTokenFunc.
+// It returns the error code and the error of the first failed check.
+func (config *JWTConfig) validateClaims(token interface{}) (int, error) {
+	if config.ExpectedIssuer == "" && config.ExpectedAudience == "" && len(config.RequiredClaims) == 0 {
+		return 0, nil
+	}
+	claims, err := tokenClaims(token)
+	if err != nil {
+		return doris.TokenInvalidErr.Code, doris.TokenInvalidErr.WithInternal(err)
+	}
+	if config.ExpectedIssuer != "" {
+		if iss, _ := claims[ClaimIssuer].(string); iss != config.ExpectedIssuer {
+			return doris.TokenIssuerErr.Code, doris.TokenIssuerErr
+		}
+	}
+	if config.ExpectedAudience != "" && !hasAudience(claims[ClaimAudience], config.ExpectedAudience) {
+		return doris.TokenAudienceErr.Code, doris.TokenAudienceErr
+	}
+	for _, name := range config.RequiredClaims {
+		if v, ok := claims[name]; !ok || v == nil || v == "" {
+			return doris.TokenClaimErr.Code, doris.TokenClaimErr
+		}
+	}
+	return 0, nil
+}
+
+// tokenClaims returns the claims of a token returned by ParseTokenFunc,
+// which may be a *jwt.Token, jwt.Claims or a claims map.
+func tokenClaims(token interface{}) (map[string]interface{}, error) {
+	switch t := token.(type) {
+	case *jwt.Token:
+		return claimsMap(t.Claims)
+	case jwt.Claims:
+		return claimsMap(t)
+	case map[string]interface{}:
+		return t, nil
+	}
+	return nil, fmt.Errorf("jwt claims of %T can't be validated", token)
+}
+
+// claimsMap returns the claims as a map, custom claims are converted by
+// their json representation.
+func claimsMap(claims jwt.Claims) (map[string]interface{}, error) {
+	if m, ok := claims.(jwt.MapClaims); ok {
+		return m, nil
+	}
+	b, err := json.Marshal(claims)
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]interface{}{}
+	err = json.Unmarshal(b, &m)
+	return m, err
+}
+
+// hasAudience reports whether the `aud` claim, a string or an array of
+// strings, contains the audience.
+func hasAudience(aud interface{}, audience string) bool {
+	switch v := aud.(type) {
+	case string:
+		return v == audience
+	case []interface{}:
+		for _, a := range v {
+			if a == audience {
+				return true
+			}
+		}
+	case []string:
+		for _, a := range v {
+			if a == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// timeClaims is implemented by jwt.MapClaims and *jwt.StandardClaims,
+// including custom claims embedding jwt.StandardClaims.
+type timeClaims interface {
+	VerifyExpiresAt(cmp int64, req bool) bool
+	VerifyIssuedAt(cmp int64, req bool) bool
+	VerifyNotBefore(cmp int64, req bool) bool
+}
+
+// validateTimeClaims validates the time claims tolerating the leeway.
+// Claims without time claims are validated by their Valid method.
+func validateTimeClaims(claims jwt.Claims, leeway time.Duration) error {
+	tc, ok := claims.(timeClaims)
+	if !ok {
+		return claims.Valid()
+	}
+	now := time.Now()
+	before := now.Add(-leeway).Unix()
+	after := now.Add(leeway).Unix()
+	if !tc.VerifyExpiresAt(before, false) {
+		return jwt.NewValidationError("token is expired", jwt.ValidationErrorExpired)
+	}
+	if !tc.VerifyIssuedAt(after, false) {
+		return jwt.NewValidationError("token used before issued", jwt.ValidationErrorIssuedAt)
+	}
+	if !tc.VerifyNotBefore(after, false) {
+		return jwt.NewValidationError("token is not valid yet", jwt.ValidationErrorNotValidYet)
+	}
+	return nil
+}
+
+// createExtractors creates a `jwtExtractor` for every source of the lookup string.
+func createExtractors(lookups string, authSchemes []string) []jwtExtractor {
+	var extractors []jwtExtractor
+	for _, lookup := range strings.Split(lookups, ",") {
+		parts := strings.Split(strings.TrimSpace(lookup), ":")
+		if len(parts) != 2 {
+			panic("doris: jwt middleware invalid token lookup " + lookup)
+		}
+		switch parts[0] {
+		case "query":
+			extractors = append(extractors, jwtFromQuery(parts[1]))
+		case "param":
+			extractors = append(extractors, jwtFromParam(parts[1]))
+		case "cookie":
+			extractors = append(extractors, jwtFromCookie(parts[1]))
+		case "form":
+			extractors = append(extractors, jwtFromForm(parts[1]))
+		default:
+			extractors = append(extractors, jwtFromHeader(parts[1], authSchemes))
+		}
+	}
+	return extractors
+}
+
+// extractToken tries the extractors in order and returns the first token found.
+func extractToken(c *doris.Context, extractors []jwtExtractor) (auth string, err error) {
+	for _, extractor := range extractors {
+		if auth, err = extractor(c); err == nil {
+			return auth, nil
+		}
+	}
+	return "", err
+}
+
 // jwtFromHeader returns a `jwtExtractor` that extracts token from the request header.
-func jwtFromHeader(header string, authScheme string) jwtExtractor {
+// The auth scheme is matched case-insensitively.
+func jwtFromHeader(header string, authSchemes []string) jwtExtractor {
 	return func(c *doris.Context) (string, error) {
 		auth := c.Request.Header.Get(header)
-		l := len(authScheme)
-		if len(auth) > l+1 && auth[:l] == authScheme {
-			return auth[l+1:], nil
+		for _, authScheme := range authSchemes {
+			if authScheme == "" {
+				if auth != "" {
+					return auth, nil
+				}
+				continue
+			}
+			l := len(authScheme)
+			if len(auth) > l+1 && auth[l] == ' ' && strings.EqualFold(auth[:l], authScheme) {
+				return auth[l+1:], nil
+			}
 		}
 		return "", doris.JWTMissingErr
 	}
@@ -270,11 +688,11 @@ func jwtFromQuery(param string) jwtExtractor {
 // jwtFromParam returns a `jwtExtractor` that extracts token from the url param string.
 func jwtFromParam(param string) jwtExtractor {
 	return func(c *doris.Context) (string, error) {
-		token := c.Param(param)
-		if token == "" {
+		token, ok := c.Param(param).(string)
+		if !ok || token == "" {
 			return "", doris.JWTMissingErr
 		}
-		return token.(string), nil
+		return token, nil
 	}
 }
 
@@ -289,6 +707,17 @@ func jwtFromCookie(name string) jwtExtractor {
 	}
 }
 
+// jwtFromForm returns a `jwtExtractor` that extracts token from the form field.
+func jwtFromForm(name string) jwtExtractor {
+	return func(c *doris.Context) (string, error) {
+		token := c.FormParam(name)
+		if token == "" {
+			return "", doris.JWTMissingErr
+		}
+		return token, nil
+	}
+}
+
 // DefaultSkipper returns false which processes the middleware.
 func DefaultSkipper(*doris.Context) bool {
 	return false
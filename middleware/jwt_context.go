@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/leaderwolfpipi/doris"
+)
+
+// TokenFromContext returns the token stored by the JWT middleware
+// under the default context key.
+func TokenFromContext(c *doris.Context) (*jwt.Token, error) {
+	return TokenFromContextKey(c, DefaultJWTConfig.ContextKey)
+}
+
+// TokenFromContextKey returns the token stored by the JWT middleware under the key.
+func TokenFromContextKey(c *doris.Context, key string) (*jwt.Token, error) {
+	token, ok := c.Param(key).(*jwt.Token)
+	if !ok {
+		return nil, doris.JWTMissingErr
+	}
+	return token, nil
+}
+
+// ClaimsFromContext stores the claims of the token stored by the JWT middleware
+// under the default context key in the value pointed to by claims.
+//
+// Usage:
+//
+//	claims := &jwtCustomClaims{}
+//	if err := middleware.ClaimsFromContext(c, claims); err != nil {
+//		return err
+//	}
+func ClaimsFromContext(c *doris.Context, claims interface{}) error {
+	return ClaimsFromContextKey(c, DefaultJWTConfig.ContextKey, claims)
+}
+
+// ClaimsFromContextKey is ClaimsFromContext with the context key of the token.
+// Claims of the same type are copied, other claims (e.g. jwt.MapClaims into
+// a struct) are converted by their json representation.
+func ClaimsFromContextKey(c *doris.Context, key string, claims interface{}) error {
+	token, err := TokenFromContextKey(c, key)
+	if err != nil {
+		return err
+	}
+	target := reflect.ValueOf(claims)
+	if target.Kind() != reflect.Ptr || target.IsNil() {
+		return errors.New("doris: claims must be a non-nil pointer")
+	}
+	source := reflect.ValueOf(token.Claims)
+	switch {
+	case source.Type() == target.Type():
+		target.Elem().Set(source.Elem())
+		return nil
+	case source.Type() == target.Elem().Type():
+		target.Elem().Set(source)
+		return nil
+	}
+	b, err := json.Marshal(token.Claims)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, claims)
+}
@@ -0,0 +1,35 @@
+// jwt context helpers test file
+package middleware
+
+import (
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/leaderwolfpipi/doris"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClaimsFromContext(t *testing.T) {
+	c := &doris.Context{}
+	_, err := TokenFromContext(c)
+	assert.Equal(t, doris.JWTMissingErr, err)
+	assert.Equal(t, doris.JWTMissingErr, ClaimsFromContext(c, &jwtCustomClaims{}))
+
+	// same type
+	c.SetParam("user", &jwt.Token{Claims: &jwtCustomClaims{jwtCustomInfo: jwtCustomInfo{Name: "John Doe", Admin: true}}})
+	claims := &jwtCustomClaims{}
+	assert.NoError(t, ClaimsFromContext(c, claims))
+	assert.Equal(t, "John Doe", claims.Name)
+	assert.True(t, claims.Admin)
+
+	// map claims
+	c.SetParam("auth", &jwt.Token{Claims: jwt.MapClaims{"name": "Jane", "admin": true}})
+	info := jwtCustomInfo{}
+	assert.NoError(t, ClaimsFromContextKey(c, "auth", &info))
+	assert.Equal(t, jwtCustomInfo{Name: "Jane", Admin: true}, info)
+	mapClaims := jwt.MapClaims{}
+	assert.NoError(t, ClaimsFromContextKey(c, "auth", &mapClaims))
+	assert.Equal(t, "Jane", mapClaims["name"])
+
+	assert.Error(t, ClaimsFromContext(c, jwtCustomClaims{}))
+}
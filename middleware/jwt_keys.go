@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// SigningMethodEdDSA implements the EdDSA (Ed25519) signing method
+// which is not provided by jwt-go v3.
+type SigningMethodEdDSA struct{}
+
+// SigningMethodEd25519 is the EdDSA signing method instance.
+var SigningMethodEd25519 = &SigningMethodEdDSA{}
+
+func init() {
+	jwt.RegisterSigningMethod(AlgorithmEdDSA, func() jwt.SigningMethod {
+		return SigningMethodEd25519
+	})
+}
+
+// Alg returns the name of the signing method.
+func (m *SigningMethodEdDSA) Alg() string {
+	return AlgorithmEdDSA
+}
+
+// Verify checks the signature with an ed25519.PublicKey.
+func (m *SigningMethodEdDSA) Verify(signingString, signature string, key interface{}) error {
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return jwt.ErrInvalidKeyType
+	}
+	sig, err := jwt.DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(publicKey, []byte(signingString), sig) {
+		return jwt.ErrSignatureInvalid
+	}
+	return nil
+}
+
+// Sign signs the string with an ed25519.PrivateKey.
+func (m *SigningMethodEdDSA) Sign(signingString string, key interface{}) (string, error) {
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return "", jwt.ErrInvalidKeyType
+	}
+	return jwt.EncodeSegment(ed25519.Sign(privateKey, []byte(signingString))), nil
+}
+
+// LoadPublicKeyFromPEM parses a PEM encoded public key or certificate.
+// Supported keys: *rsa.PublicKey, *ecdsa.PublicKey and ed25519.PublicKey.
+func LoadPublicKeyFromPEM(data []byte) (interface{}, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, jwt.ErrKeyMustBePEMEncoded
+	}
+	switch block.Type {
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return cert.PublicKey, nil
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+// LoadPrivateKeyFromPEM parses a PEM encoded private key in PKCS#1, PKCS#8 or SEC 1 form.
+// Supported keys: *rsa.PrivateKey, *ecdsa.PrivateKey and ed25519.PrivateKey.
+func LoadPrivateKeyFromPEM(data []byte) (interface{}, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, jwt.ErrKeyMustBePEMEncoded
+	}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	}
+	return x509.ParsePKCS8PrivateKey(block.Bytes)
+}
+
+// LoadPublicKeyFromPEMFile reads and parses a PEM encoded public key file.
+func LoadPublicKeyFromPEMFile(path string) (interface{}, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return LoadPublicKeyFromPEM(data)
+}
+
+// LoadPrivateKeyFromPEMFile reads and parses a PEM encoded private key file.
+func LoadPrivateKeyFromPEMFile(path string) (interface{}, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return LoadPrivateKeyFromPEM(data)
+}
+
+// verificationKey returns the public part of a private key so the same
+// key can be configured for issuing and verifying tokens.
+func verificationKey(key interface{}) interface{} {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return &k.PublicKey
+	case *ecdsa.PrivateKey:
+		return &k.PublicKey
+	case ed25519.PrivateKey:
+		return k.Public()
+	case crypto.Signer:
+		return k.Public()
+	}
+	return key
+}
+
+// checkSigningKey checks that the key type matches the signing method.
+func checkSigningKey(method string, key interface{}) error {
+	key = verificationKey(key)
+	var ok bool
+	switch {
+	case strings.HasPrefix(method, "HS"):
+		_, ok = key.([]byte)
+	case strings.HasPrefix(method, "RS"), strings.HasPrefix(method, "PS"):
+		_, ok = key.(*rsa.PublicKey)
+	case strings.HasPrefix(method, "ES"):
+		_, ok = key.(*ecdsa.PublicKey)
+	case method == AlgorithmEdDSA:
+		_, ok = key.(ed25519.PublicKey)
+	default:
+		return errors.New("unsupported jwt signing method=" + method)
+	}
+	if !ok {
+		return fmt.Errorf("jwt signing key of type %T does not match signing method=%s", key, method)
+	}
+	return nil
+}
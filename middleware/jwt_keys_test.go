@@ -0,0 +1,59 @@
+// jwt keys test file
+package middleware
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadKeysFromPEM(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 1024)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	edPublic, edPrivate, _ := ed25519.GenerateKey(rand.Reader)
+
+	for _, tc := range []struct {
+		private interface{}
+		public  interface{}
+		method  string
+	}{
+		{rsaKey, &rsaKey.PublicKey, AlgorithmRS256},
+		{ecKey, &ecKey.PublicKey, AlgorithmES256},
+		{edPrivate, edPublic, AlgorithmEdDSA},
+	} {
+		der, err := x509.MarshalPKIXPublicKey(tc.public)
+		assert.NoError(t, err, tc.method)
+		public, err := LoadPublicKeyFromPEM(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+		assert.NoError(t, err, tc.method)
+		assert.Equal(t, tc.public, public, tc.method)
+
+		der, err = x509.MarshalPKCS8PrivateKey(tc.private)
+		assert.NoError(t, err, tc.method)
+		private, err := LoadPrivateKeyFromPEM(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+		assert.NoError(t, err, tc.method)
+		assert.Equal(t, tc.public, verificationKey(private), tc.method)
+
+		assert.NoError(t, checkSigningKey(tc.method, private), tc.method)
+		assert.NoError(t, checkSigningKey(tc.method, public), tc.method)
+		assert.Error(t, checkSigningKey(AlgorithmHS256, public), tc.method)
+	}
+
+	_, err := LoadPublicKeyFromPEM([]byte("not a pem"))
+	assert.Error(t, err)
+}
+
+func TestSigningMethodEdDSA(t *testing.T) {
+	public, private, _ := ed25519.GenerateKey(rand.Reader)
+	sig, err := SigningMethodEd25519.Sign("header.payload", private)
+	assert.NoError(t, err)
+	assert.NoError(t, SigningMethodEd25519.Verify("header.payload", sig, public))
+	assert.Error(t, SigningMethodEd25519.Verify("header.other", sig, public))
+	assert.Error(t, SigningMethodEd25519.Verify("header.payload", sig, []byte("secret")))
+}
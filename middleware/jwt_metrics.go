@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"sync"
+
+	"github.com/leaderwolfpipi/doris"
+)
+
+// Outcomes of the token validation reported to JWTMetrics.
+const (
+	JWTOutcomeValid       = "valid"
+	JWTOutcomeMissing     = "missing"
+	JWTOutcomeMalformed   = "malformed"
+	JWTOutcomeExpired     = "expired"
+	JWTOutcomeNotValidYet = "not_valid_yet"
+	JWTOutcomeInvalid     = "invalid"
+	JWTOutcomeRefresh     = "refresh"
+	JWTOutcomeRevoked     = "revoked"
+)
+
+type (
+	// JWTMetrics records the outcomes of the JWT middleware.
+	JWTMetrics interface {
+		Observe(c *doris.Context, outcome string)
+	}
+
+	// JWTMetricsFunc is an adapter to use a function as JWTMetrics, e.g. to
+	// increment a Prometheus counter vector labeled by outcome:
+	//
+	//	middleware.JWTMetricsFunc(func(c *doris.Context, outcome string) {
+	//		jwtTokens.WithLabelValues(outcome).Inc()
+	//	})
+	JWTMetricsFunc func(c *doris.Context, outcome string)
+
+	// JWTCounter is an in-memory JWTMetrics counting every outcome.
+	JWTCounter struct {
+		lock   sync.RWMutex
+		counts map[string]uint64
+	}
+)
+
+// Observe implements JWTMetrics.
+func (f JWTMetricsFunc) Observe(c *doris.Context, outcome string) {
+	f(c, outcome)
+}
+
+// NewJWTCounter returns an in-memory outcome counter.
+func NewJWTCounter() *JWTCounter {
+	return &JWTCounter{counts: make(map[string]uint64)}
+}
+
+// Observe implements JWTMetrics.
+func (m *JWTCounter) Observe(c *doris.Context, outcome string) {
+	m.lock.Lock()
+	m.counts[outcome]++
+	m.lock.Unlock()
+}
+
+// Count returns the number of observations of the outcome.
+func (m *JWTCounter) Count(outcome string) uint64 {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.counts[outcome]
+}
+
+// Counts returns a snapshot of all counters.
+func (m *JWTCounter) Counts() map[string]uint64 {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	counts := make(map[string]uint64, len(m.counts))
+	for k, v := range m.counts {
+		counts[k] = v
+	}
+	return counts
+}
+
+// observe reports the outcome to the configured metrics.
+func (config *JWTConfig) observe(c *doris.Context, outcome string) {
+	if config.Metrics != nil {
+		config.Metrics.Observe(c, outcome)
+	}
+}
@@ -0,0 +1,317 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/leaderwolfpipi/doris"
+)
+
+type (
+	// RefreshConfig defines the config for issuing and refreshing token pairs.
+	RefreshConfig struct {
+		// Key used to sign new tokens. Private key for asymmetric methods.
+		// Required.
+		SigningKey interface{}
+
+		// Signing method of new tokens.
+		// Optional. Default value HS256.
+		SigningMethod string
+
+		// Lifetime of access tokens.
+		// Optional. Default value 15 minutes.
+		AccessTTL time.Duration
+
+		// Lifetime of refresh tokens.
+		// Optional. Default value 7 days.
+		RefreshTTL time.Duration
+
+		// Claim distinguishing access tokens from refresh tokens.
+		// Must match the claim checked by the JWT middleware.
+		// Optional. Default value "auth_type".
+		AuthTypeClaim string
+
+		// Store records issued refresh tokens for rotation and replay detection.
+		// Optional. Default value an in-memory store (single instance only).
+		Store RefreshStore
+
+		// TokenLookup defines where the refresh handler reads the refresh token,
+		// same format as `JWTConfig.TokenLookup`.
+		// Optional. Default value "form:refresh_token,header:Authorization".
+		TokenLookup string
+
+		// AuthScheme to be used in the Authorization header.
+		// Optional. Default value "Bearer".
+		AuthScheme string
+
+		extractors []jwtExtractor
+	}
+
+	// TokenPair is an access token with its refresh token.
+	TokenPair struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		TokenType    string `json:"token_type"`
+		ExpiresIn    int64  `json:"expires_in"`
+	}
+
+	// RefreshStore records the refresh tokens of every token family.
+	// A family is the chain of refresh tokens rotated from one login.
+	RefreshStore interface {
+		// Save records a newly issued refresh token.
+		Save(family, id string, ttl time.Duration) error
+		// Use consumes a refresh token. It returns false when the token is
+		// unknown or was already used, which indicates a replay.
+		Use(family, id string) (bool, error)
+		// RevokeFamily invalidates every refresh token of the family.
+		RevokeFamily(family string) error
+	}
+
+	// MemoryRefreshStore is an in-memory `RefreshStore`.
+	MemoryRefreshStore struct {
+		lock     sync.Mutex
+		families map[string]*refreshFamily
+		sweep    sweepSchedule
+	}
+
+	refreshFamily struct {
+		current   string    // the only refresh token id that may be used
+		expiresAt time.Time // expiry of the current token
+	}
+
+	// sweepSchedule amortizes the removal of expired entries of the
+	// in-memory stores: after sweeping n entries the next sweep runs n
+	// inserts later, so an insert costs O(1) on average.
+	sweepSchedule struct {
+		inserts int
+		next    int
+	}
+)
+
+var (
+	// DefaultRefreshConfig is the default refresh token config.
+	DefaultRefreshConfig = RefreshConfig{
+		SigningMethod: AlgorithmHS256,
+		AccessTTL:     15 * time.Minute,
+		RefreshTTL:    7 * 24 * time.Hour,
+		AuthTypeClaim: ClaimAuthType,
+		TokenLookup:   "form:refresh_token,header:" + doris.Authorization,
+		AuthScheme:    "Bearer",
+	}
+
+	// reservedClaims are managed by the token helpers and never copied
+	// from a refresh token into the new pair.
+	reservedClaims = []string{ClaimExpiresAt, ClaimIssuedAt, ClaimNotBefore, ClaimID, ClaimFamily}
+)
+
+// NewMemoryRefreshStore returns an in-memory refresh token store.
+func NewMemoryRefreshStore() *MemoryRefreshStore {
+	return &MemoryRefreshStore{families: make(map[string]*refreshFamily)}
+}
+
+// Save implements `RefreshStore`.
+func (s *MemoryRefreshStore) Save(family, id string, ttl time.Duration) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	now := time.Now()
+	// Drop expired families
+	if s.sweep.due(len(s.families)) {
+		for name, f := range s.families {
+			if now.After(f.expiresAt) {
+				delete(s.families, name)
+			}
+		}
+	}
+	s.families[family] = &refreshFamily{current: id, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+// Use implements `RefreshStore`.
+func (s *MemoryRefreshStore) Use(family, id string) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	f, ok := s.families[family]
+	if !ok || f.current != id || time.Now().After(f.expiresAt) {
+		return false, nil
+	}
+	f.current = ""
+	return true, nil
+}
+
+// RevokeFamily implements `RefreshStore`.
+func (s *MemoryRefreshStore) RevokeFamily(family string) error {
+	s.lock.Lock()
+	delete(s.families, family)
+	s.lock.Unlock()
+	return nil
+}
+
+// due records an insert into a store of size entries and reports whether
+// the expired entries should be swept.
+func (s *sweepSchedule) due(size int) bool {
+	s.inserts++
+	if s.inserts < s.next {
+		return false
+	}
+	s.inserts = 0
+	s.next = size
+	return true
+}
+
+// NewRefreshConfig fills the defaults of the config.
+func NewRefreshConfig(config RefreshConfig) RefreshConfig {
+	if config.SigningKey == nil {
+		panic("doris: refresh token requires signing key")
+	}
+	if config.SigningMethod == "" {
+		config.SigningMethod = DefaultRefreshConfig.SigningMethod
+	}
+	if config.AccessTTL == 0 {
+		config.AccessTTL = DefaultRefreshConfig.AccessTTL
+	}
+	if config.RefreshTTL == 0 {
+		config.RefreshTTL = DefaultRefreshConfig.RefreshTTL
+	}
+	if config.AuthTypeClaim == "" {
+		config.AuthTypeClaim = DefaultRefreshConfig.AuthTypeClaim
+	}
+	if config.Store == nil {
+		config.Store = NewMemoryRefreshStore()
+	}
+	if config.TokenLookup == "" {
+		config.TokenLookup = DefaultRefreshConfig.TokenLookup
+	}
+	if config.AuthScheme == "" {
+		config.AuthScheme = DefaultRefreshConfig.AuthScheme
+	}
+	config.extractors = createExtractors(config.TokenLookup, []string{config.AuthScheme})
+	return config
+}
+
+// IssueTokenPair issues an access token and a refresh token of a new family
+// with the given claims, e.g. after a successful login.
+func (config RefreshConfig) IssueTokenPair(claims Claims) (*TokenPair, error) {
+	family, err := randomID()
+	if err != nil {
+		return nil, err
+	}
+	return config.issue(claims, family)
+}
+
+// issue signs a token pair of the family and records the refresh token.
+func (config RefreshConfig) issue(claims Claims, family string) (*TokenPair, error) {
+	access := copyClaims(claims).Set(config.AuthTypeClaim, AuthTypeAccess)
+	accessToken, err := NewToken(access, config.SigningKey, config.SigningMethod, config.AccessTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := randomID()
+	if err != nil {
+		return nil, err
+	}
+	refresh := copyClaims(claims).Set(config.AuthTypeClaim, AuthTypeRefresh).Set(ClaimFamily, family).ID(id)
+	refreshToken, err := NewToken(refresh, config.SigningKey, config.SigningMethod, config.RefreshTTL)
+	if err != nil {
+		return nil, err
+	}
+	if err := config.Store.Save(family, id, config.RefreshTTL); err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(config.AccessTTL / time.Second),
+	}, nil
+}
+
+// Refresh validates a refresh token and rotates it into a new token pair.
+// Reusing an already rotated refresh token revokes the whole family.
+func (config RefreshConfig) Refresh(refreshToken string) (*TokenPair, error) {
+	token, err := jwt.Parse(refreshToken, func(t *jwt.Token) (interface{}, error) {
+		if t.Method.Alg() != config.SigningMethod {
+			return nil, errors.New("unexpected jwt signing method")
+		}
+		return verificationKey(config.SigningKey), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, doris.TokenInvalidErr
+	}
+	if authType, _ := claims[config.AuthTypeClaim].(string); authType != AuthTypeRefresh {
+		return nil, doris.TokenInvalidErr
+	}
+	family, _ := claims[ClaimFamily].(string)
+	id, _ := claims[ClaimID].(string)
+	if family == "" || id == "" {
+		return nil, doris.TokenInvalidErr
+	}
+	valid, err := config.Store.Use(family, id)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		// Replay detected: the token was rotated before, so it may be stolen
+		if err := config.Store.RevokeFamily(family); err != nil {
+			return nil, err
+		}
+		return nil, doris.TokenReusedErr
+	}
+	return config.issue(Claims(claims), family)
+}
+
+// RefreshHandler returns a handler exchanging a refresh token for a new token pair.
+//
+// Usage: d.POST("/token/refresh", middleware.RefreshHandler(config))
+func RefreshHandler(config RefreshConfig) doris.HandlerFunc {
+	config = NewRefreshConfig(config)
+	return func(c *doris.Context) error {
+		auth, err := extractToken(c, config.extractors)
+		if err != nil {
+			c.ErrorJson(http.StatusBadRequest, doris.JWTMissingErr.Code, "JWT ERR: "+err.Error())
+			return err
+		}
+		pair, err := config.Refresh(auth)
+		if err != nil {
+			code := doris.TokenInvalidErr.Code
+			if err == doris.TokenReusedErr {
+				code = doris.TokenReusedErr.Code
+			}
+			c.ErrorJson(http.StatusUnauthorized, code, "Invalid refresh token: "+err.Error())
+			return err
+		}
+		c.Json(http.StatusOK, pair)
+		return nil
+	}
+}
+
+// copyClaims copies the claims without the reserved ones.
+func copyClaims(claims Claims) Claims {
+	cp := make(Claims, len(claims)+5)
+	for k, v := range claims {
+		cp[k] = v
+	}
+	for _, k := range reservedClaims {
+		delete(cp, k)
+	}
+	return cp
+}
+
+// randomID returns a random 128 bit hex string.
+func randomID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
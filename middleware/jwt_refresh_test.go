@@ -0,0 +1,57 @@
+// jwt refresh token test file
+package middleware
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/leaderwolfpipi/doris"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefreshTokenRotation(t *testing.T) {
+	config := NewRefreshConfig(RefreshConfig{SigningKey: []byte("secret")})
+
+	pair, err := config.IssueTokenPair(NewClaims().Set("uid", "1"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer", pair.TokenType)
+	assert.Equal(t, int64(900), pair.ExpiresIn)
+
+	// access tokens are not accepted as refresh tokens
+	_, err = config.Refresh(pair.AccessToken)
+	assert.Equal(t, doris.TokenInvalidErr, err)
+
+	// rotation keeps custom claims
+	next, err := config.Refresh(pair.RefreshToken)
+	assert.NoError(t, err)
+	token, err := jwt.Parse(next.AccessToken, func(*jwt.Token) (interface{}, error) { return []byte("secret"), nil })
+	assert.NoError(t, err)
+	claims := token.Claims.(jwt.MapClaims)
+	assert.Equal(t, "1", claims["uid"])
+	assert.Equal(t, AuthTypeAccess, claims[ClaimAuthType])
+
+	// replaying the rotated token revokes the family
+	_, err = config.Refresh(pair.RefreshToken)
+	assert.Equal(t, doris.TokenReusedErr, err)
+	_, err = config.Refresh(next.RefreshToken)
+	assert.Equal(t, doris.TokenReusedErr, err)
+}
+
+func TestMemoryRefreshStore(t *testing.T) {
+	store := NewMemoryRefreshStore()
+	assert.NoError(t, store.Save("f1", "t1", time.Hour))
+	ok, _ := store.Use("f1", "t1")
+	assert.True(t, ok)
+	ok, _ = store.Use("f1", "t1")
+	assert.False(t, ok)
+
+	// expired families are swept over time instead of on every save
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, store.Save(strconv.Itoa(i), "t", -time.Second))
+	}
+	assert.True(t, len(store.families) < 100)
+	ok, _ = store.Use("99", "t")
+	assert.False(t, ok)
+}
@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/leaderwolfpipi/doris"
+)
+
+type (
+	// RedisClient is the subset of a Redis client used for token revocation.
+	// Adapters for popular clients are one-liners, e.g. for go-redis:
+	//
+	//	type goRedis struct{ *redis.Client }
+	//	func (r goRedis) Exists(key string) (bool, error) { n, err := r.Client.Exists(key).Result(); return n > 0, err }
+	//	func (r goRedis) Set(key, value string, ttl time.Duration) error { return r.Client.Set(key, value, ttl).Err() }
+	RedisClient interface {
+		Exists(key string) (bool, error)
+		Set(key, value string, ttl time.Duration) error
+	}
+
+	// RedisRevocation stores revoked tokens in Redis until they expire.
+	RedisRevocation struct {
+		Client RedisClient
+		// Prefix of the Redis keys.
+		// Optional. Default value "doris:jwt:revoked:".
+		Prefix string
+	}
+)
+
+// Default Redis key prefix of revoked tokens
+const defaultRevocationPrefix = "doris:jwt:revoked:"
+
+// NewRedisRevocation returns a Redis backed token revocation store.
+func NewRedisRevocation(client RedisClient) *RedisRevocation {
+	return &RedisRevocation{
+		Client: client,
+		Prefix: defaultRevocationPrefix,
+	}
+}
+
+// Revoke marks the token as revoked until it expires.
+// Tokens without `exp` claim are revoked forever (ttl 0).
+func (r *RedisRevocation) Revoke(token *jwt.Token) error {
+	var ttl time.Duration
+	if expiresAt := tokenExpiresAt(token); !expiresAt.IsZero() {
+		if ttl = time.Until(expiresAt); ttl <= 0 {
+			// Already expired, nothing to revoke
+			return nil
+		}
+	}
+	return r.Client.Set(r.Prefix+tokenID(token), "1", ttl)
+}
+
+// Checker returns a `JWTConfig.RevocationChecker` that rejects revoked tokens.
+func (r *RedisRevocation) Checker() func(*doris.Context, *jwt.Token) error {
+	return func(c *doris.Context, token *jwt.Token) error {
+		revoked, err := r.Client.Exists(r.Prefix + tokenID(token))
+		if err != nil {
+			return err
+		}
+		if revoked {
+			return doris.TokenRevokedErr
+		}
+		return nil
+	}
+}
+
+// RedisRevocationChecker returns a `JWTConfig.RevocationChecker` backed by Redis.
+func RedisRevocationChecker(client RedisClient) func(*doris.Context, *jwt.Token) error {
+	return NewRedisRevocation(client).Checker()
+}
+
+// tokenID returns the `jti` claim of the token or a hash of the raw token.
+func tokenID(token *jwt.Token) string {
+	switch claims := token.Claims.(type) {
+	case jwt.MapClaims:
+		if jti, ok := claims["jti"].(string); ok && jti != "" {
+			return jti
+		}
+	case *jwt.StandardClaims:
+		if claims.Id != "" {
+			return claims.Id
+		}
+	}
+	sum := sha256.Sum256([]byte(token.Raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// tokenExpiresAt returns the `exp` claim of the token.
+func tokenExpiresAt(token *jwt.Token) time.Time {
+	switch claims := token.Claims.(type) {
+	case jwt.MapClaims:
+		if exp, ok := claims["exp"].(float64); ok {
+			return time.Unix(int64(exp), 0)
+		}
+	case *jwt.StandardClaims:
+		if claims.ExpiresAt > 0 {
+			return time.Unix(claims.ExpiresAt, 0)
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,67 @@
+// jwt revocation test file
+package middleware
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/leaderwolfpipi/doris"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryRedis is an in-memory RedisClient for tests.
+type memoryRedis map[string]time.Duration
+
+func (m memoryRedis) Exists(key string) (bool, error) {
+	_, ok := m[key]
+	return ok, nil
+}
+
+func (m memoryRedis) Set(key, value string, ttl time.Duration) error {
+	m[key] = ttl
+	return nil
+}
+
+func TestRedisRevocation(t *testing.T) {
+	client := memoryRedis{}
+	revocation := NewRedisRevocation(client)
+	checker := revocation.Checker()
+
+	token := &jwt.Token{Raw: "raw", Claims: jwt.MapClaims{"jti": "id-1", "exp": float64(time.Now().Add(time.Hour).Unix())}}
+	other := &jwt.Token{Raw: "other", Claims: jwt.MapClaims{}}
+	expired := &jwt.Token{Raw: "expired", Claims: jwt.MapClaims{"exp": float64(time.Now().Add(-time.Hour).Unix())}}
+
+	assert.NoError(t, checker(nil, token))
+	assert.NoError(t, revocation.Revoke(token))
+	assert.Equal(t, doris.TokenRevokedErr, checker(nil, token))
+	assert.True(t, client[defaultRevocationPrefix+"id-1"] > 0)
+
+	// tokens without exp are revoked forever
+	assert.NoError(t, revocation.Revoke(other))
+	assert.Equal(t, doris.TokenRevokedErr, checker(nil, other))
+	assert.Equal(t, time.Duration(0), client[defaultRevocationPrefix+tokenID(other)])
+
+	// expired tokens are not stored
+	assert.NoError(t, revocation.Revoke(expired))
+	assert.NoError(t, checker(nil, expired))
+}
+
+func TestJWTRevocationChecker(t *testing.T) {
+	key := []byte("secret")
+	token, _ := NewToken(NewClaims().Set(ClaimID, "id-1"), key, AlgorithmHS256, time.Hour)
+	client := memoryRedis{defaultRevocationPrefix + "id-1": time.Hour}
+
+	err := serveJWT(JWTConfig{SigningKey: key, RevocationChecker: RedisRevocationChecker(client)}, token)
+	assert.Equal(t, doris.TokenRevokedErr, err)
+
+	// custom parsers would bypass the checker
+	assert.Panics(t, func() {
+		JWTWithConfig(JWTConfig{
+			ParseTokenFunc: func(auth string, c *doris.Context) (interface{}, error) {
+				return auth, nil
+			},
+			RevocationChecker: RedisRevocationChecker(client),
+		})
+	})
+}
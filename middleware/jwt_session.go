@@ -0,0 +1,160 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/leaderwolfpipi/doris"
+)
+
+type (
+	// JWTSessionConfig defines the config for cookie based JWT sessions.
+	JWTSessionConfig struct {
+		// JWTConfig configures the validation of the session token.
+		// SigningKey is required and used to issue tokens as well, a private key
+		// for asymmetric signing methods. TokenLookup is ignored.
+		JWTConfig
+
+		// Name of the session cookie.
+		// Optional. Default value "session".
+		CookieName string
+
+		// Lifetime of session tokens.
+		// Optional. Default value 30 minutes.
+		TTL time.Duration
+
+		// RefreshWithin re-issues the token when its remaining lifetime is shorter,
+		// which slides the expiration of active sessions.
+		// Optional. Default value TTL / 2.
+		RefreshWithin time.Duration
+
+		// Path of the session cookie.
+		// Optional. Default value "/".
+		CookiePath string
+
+		// Domain of the session cookie.
+		// Optional.
+		CookieDomain string
+
+		// CookieSameSite of the session cookie.
+		// Optional. Default value http.SameSiteLaxMode.
+		CookieSameSite http.SameSite
+
+		// CookieInsecure disables the Secure flag of the cookie, for local
+		// development over plain http only.
+		// Optional. Default value false.
+		CookieInsecure bool
+	}
+
+	// JWTSession issues, validates and slides cookie based JWT sessions
+	// without a server-side session store.
+	JWTSession struct {
+		config JWTSessionConfig
+	}
+)
+
+var (
+	// DefaultJWTSessionConfig is the default JWT session config.
+	DefaultJWTSessionConfig = JWTSessionConfig{
+		CookieName:     "session",
+		TTL:            30 * time.Minute,
+		CookiePath:     "/",
+		CookieSameSite: http.SameSiteLaxMode,
+	}
+)
+
+// NewJWTSession returns a JWT session with config.
+//
+// Usage:
+//
+//	session := middleware.NewJWTSession(middleware.JWTSessionConfig{JWTConfig: middleware.JWTConfig{SigningKey: key}})
+//	d.POST("/login", func(c *doris.Context) error { ...; return session.Login(c, middleware.NewClaims().Subject(id)) })
+//	app := d.Group("/app", session.Middleware())
+func NewJWTSession(config JWTSessionConfig) *JWTSession {
+	if config.SigningKey == nil {
+		panic("doris: jwt session requires signing key")
+	}
+	if config.CookieName == "" {
+		config.CookieName = DefaultJWTSessionConfig.CookieName
+	}
+	if config.TTL == 0 {
+		config.TTL = DefaultJWTSessionConfig.TTL
+	}
+	if config.RefreshWithin == 0 {
+		config.RefreshWithin = config.TTL / 2
+	}
+	if config.CookiePath == "" {
+		config.CookiePath = DefaultJWTSessionConfig.CookiePath
+	}
+	if config.CookieSameSite == 0 {
+		config.CookieSameSite = DefaultJWTSessionConfig.CookieSameSite
+	}
+	if config.SigningMethod == "" {
+		config.SigningMethod = DefaultJWTConfig.SigningMethod
+	}
+	return &JWTSession{config: config}
+}
+
+// Middleware returns the JWT middleware reading the token from the session cookie.
+// Tokens close to expiry are re-issued and the new cookie is set on the response.
+func (s *JWTSession) Middleware() doris.HandlerFunc {
+	config := s.config.JWTConfig
+	config.TokenLookup = "cookie:" + s.config.CookieName
+	successHandler := config.SuccessHandler
+	config.SuccessHandler = func(c *doris.Context, token interface{}) {
+		if t, ok := token.(*jwt.Token); ok {
+			s.slide(c, t)
+		}
+		if successHandler != nil {
+			successHandler(c, token)
+		}
+	}
+	return JWTWithConfig(config)
+}
+
+// Login issues a session token with the claims and sets the session cookie.
+func (s *JWTSession) Login(c *doris.Context, claims Claims) error {
+	token, err := NewToken(copyClaims(claims), s.config.SigningKey, s.config.SigningMethod, s.config.TTL)
+	if err != nil {
+		return err
+	}
+	c.SetCookie(s.cookie(token, int(s.config.TTL/time.Second)))
+	return nil
+}
+
+// Logout removes the session cookie.
+func (s *JWTSession) Logout(c *doris.Context) {
+	c.SetCookie(s.cookie("", -1))
+}
+
+// slide re-issues the token when it expires within RefreshWithin.
+func (s *JWTSession) slide(c *doris.Context, t *jwt.Token) {
+	claims, err := claimsMap(t.Claims)
+	if err != nil {
+		return
+	}
+	exp, ok := claims[ClaimExpiresAt].(float64)
+	if !ok {
+		if v, isInt := claims[ClaimExpiresAt].(int64); isInt {
+			exp, ok = float64(v), true
+		}
+	}
+	if !ok || time.Until(time.Unix(int64(exp), 0)) >= s.config.RefreshWithin {
+		return
+	}
+	// 失败时保留旧token, 下次请求再重试
+	s.Login(c, Claims(claims))
+}
+
+// cookie returns the session cookie with the token.
+func (s *JWTSession) cookie(token string, maxAge int) *http.Cookie {
+	return doris.NewCookie(s.config.CookieName, token,
+		doris.CookiePath(s.config.CookiePath),
+		doris.CookieDomain(s.config.CookieDomain),
+		doris.CookieMaxAge(maxAge),
+		doris.CookieSecure(!s.config.CookieInsecure),
+		doris.CookieHttpOnly(true),
+		doris.CookieSameSite(s.config.CookieSameSite),
+	)
+}
@@ -0,0 +1,69 @@
+// jwt session test file
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/leaderwolfpipi/doris"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJWTSession(t *testing.T) {
+	key := []byte("secret")
+	session := NewJWTSession(JWTSessionConfig{JWTConfig: JWTConfig{SigningKey: key}, TTL: time.Hour})
+
+	d := doris.New()
+	d.POST("/login", func(c *doris.Context) error {
+		return session.Login(c, NewClaims().Subject("42"))
+	})
+	d.POST("/logout", func(c *doris.Context) error {
+		session.Logout(c)
+		return nil
+	})
+	d.GET("/me", session.Middleware(), func(c *doris.Context) error {
+		c.String(http.StatusOK, "ok")
+		return nil
+	})
+	serve := func(method, path string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		res := httptest.NewRecorder()
+		d.ServeHTTP(res, req)
+		return res
+	}
+
+	res := serve(http.MethodPost, "/login", nil)
+	cookies := res.Result().Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, "session", cookies[0].Name)
+		assert.True(t, cookies[0].HttpOnly)
+		assert.True(t, cookies[0].Secure)
+		assert.Equal(t, 3600, cookies[0].MaxAge)
+	}
+
+	// fresh token is not re-issued
+	res = serve(http.MethodGet, "/me", cookies[0])
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Len(t, res.Result().Cookies(), 0)
+
+	// token close to expiry slides
+	old, _ := NewToken(NewClaims().Subject("42"), key, AlgorithmHS256, 10*time.Minute)
+	res = serve(http.MethodGet, "/me", &http.Cookie{Name: "session", Value: old})
+	assert.Equal(t, http.StatusOK, res.Code)
+	if renewed := res.Result().Cookies(); assert.Len(t, renewed, 1) {
+		assert.NotEqual(t, old, renewed[0].Value)
+	}
+
+	res = serve(http.MethodGet, "/me", nil)
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+
+	res = serve(http.MethodPost, "/logout", nil)
+	if cookies := res.Result().Cookies(); assert.Len(t, cookies, 1) {
+		assert.Equal(t, -1, cookies[0].MaxAge)
+	}
+}
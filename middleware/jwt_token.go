@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Claims is a set of JWT claims built with chained setters.
+//
+// Usage:
+//
+//	token, err := middleware.NewToken(
+//		middleware.NewClaims().Subject("42").Set("role", "admin"),
+//		key, middleware.AlgorithmHS256, time.Hour,
+//	)
+type Claims map[string]interface{}
+
+// NewClaims returns an empty claims set.
+func NewClaims() Claims {
+	return Claims{}
+}
+
+// Set sets a custom claim.
+func (c Claims) Set(key string, value interface{}) Claims {
+	c[key] = value
+	return c
+}
+
+// Issuer sets the `iss` claim.
+func (c Claims) Issuer(iss string) Claims {
+	return c.Set(ClaimIssuer, iss)
+}
+
+// Subject sets the `sub` claim.
+func (c Claims) Subject(sub string) Claims {
+	return c.Set(ClaimSubject, sub)
+}
+
+// Audience sets the `aud` claim.
+func (c Claims) Audience(aud string) Claims {
+	return c.Set(ClaimAudience, aud)
+}
+
+// ID sets the `jti` claim.
+func (c Claims) ID(jti string) Claims {
+	return c.Set(ClaimID, jti)
+}
+
+// NotBefore sets the `nbf` claim.
+func (c Claims) NotBefore(t time.Time) Claims {
+	return c.Set(ClaimNotBefore, t.Unix())
+}
+
+// AuthType sets the claim distinguishing access tokens from refresh tokens.
+func (c Claims) AuthType(authType string) Claims {
+	return c.Set(ClaimAuthType, authType)
+}
+
+// NewToken signs the claims with the key and the signing method.
+// `iat` is set to now and `exp` to now + ttl, a zero ttl issues a token
+// without expiry. The claims are not modified.
+func NewToken(claims Claims, key interface{}, method string, ttl time.Duration) (string, error) {
+	signingMethod := jwt.GetSigningMethod(method)
+	if signingMethod == nil {
+		return "", errors.New("unsupported jwt signing method=" + method)
+	}
+	if err := checkSigningKey(method, key); err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	mapClaims := make(jwt.MapClaims, len(claims)+2)
+	for k, v := range claims {
+		mapClaims[k] = v
+	}
+	mapClaims[ClaimIssuedAt] = now.Unix()
+	if ttl > 0 {
+		mapClaims[ClaimExpiresAt] = now.Add(ttl).Unix()
+	}
+	return jwt.NewWithClaims(signingMethod, mapClaims).SignedString(key)
+}
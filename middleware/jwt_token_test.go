@@ -0,0 +1,39 @@
+// jwt token issuance test file
+package middleware
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewToken(t *testing.T) {
+	key := []byte("secret")
+	claims := NewClaims().Subject("42").Issuer("doris").Set("role", "admin")
+
+	signed, err := NewToken(claims, key, AlgorithmHS256, time.Hour)
+	assert.NoError(t, err)
+	token, err := jwt.Parse(signed, func(*jwt.Token) (interface{}, error) { return key, nil })
+	assert.NoError(t, err)
+	mapClaims := token.Claims.(jwt.MapClaims)
+	assert.Equal(t, "42", mapClaims[ClaimSubject])
+	assert.Equal(t, "admin", mapClaims["role"])
+	assert.NotNil(t, mapClaims[ClaimExpiresAt])
+	_, ok := claims[ClaimExpiresAt]
+	assert.False(t, ok)
+
+	// zero ttl issues a token without expiry
+	signed, err = NewToken(NewClaims(), key, AlgorithmHS256, 0)
+	assert.NoError(t, err)
+	token, _ = jwt.Parse(signed, func(*jwt.Token) (interface{}, error) { return key, nil })
+	_, ok = token.Claims.(jwt.MapClaims)[ClaimExpiresAt]
+	assert.False(t, ok)
+
+	// key must match the method
+	_, err = NewToken(claims, key, AlgorithmRS256, time.Hour)
+	assert.Error(t, err)
+	_, err = NewToken(claims, key, "none", time.Hour)
+	assert.Error(t, err)
+}
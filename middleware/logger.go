@@ -1,40 +1,570 @@
 package middleware
 
 import (
-	"fmt"
+	"bytes"
+	"io"
+	"math/rand"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/leaderwolfpipi/doris"
 )
 
-// 中间件函数的格式
+type (
+	// LoggerConfig defines the config for Logger middleware.
+	LoggerConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// SkipPaths lists the request paths which are not logged, such as
+		// health checks and metrics scrapes.
+		// Optional. Default value nil.
+		SkipPaths []string
+
+		// Format is the log line template. Tags are written as ${tag}:
+		//
+		// - time_rfc3339
+		// - time_unix
+		// - time_custom (see CustomTimeFormat)
+		// - id (request id)
+		// - remote_ip (client ip, see Context.RealIP)
+		// - remote_addr (address of the connection)
+		// - host
+		// - method
+		// - uri
+		// - path
+		// - protocol
+		// - referer
+		// - user_agent
+		// - route (matched route template, such as /users/:id)
+		// - handler (name of the route handler)
+		// - level (level the request is logged at)
+		// - status
+		// - status_text
+		// - error
+		// - latency (in nanoseconds)
+		// - latency_human (human readable)
+		// - bytes_in (request content length)
+		// - bytes_out (response size)
+		// - header:<NAME>
+		// - query:<NAME>
+		// - form:<NAME>
+		//
+		// The following tags render values the way the Common Log Format
+		// does, with "-" for empty values and quotes escaped:
+		//
+		// - remote_user (basic auth user)
+		// - time_clf (such as 10/Oct/2000:13:55:36 -0700)
+		// - request_clf (quoted request line)
+		// - bytes_out_clf
+		// - referer_clf (quoted)
+		// - user_agent_clf (quoted)
+		//
+		// Errors collected by the handlers are appended to the line when the
+		// format has no ${error} tag.
+		// Optional. Default value DefaultLoggerConfig.Format.
+		Format string
+
+		// Output is the writer the log lines are written to, such as a
+		// RotateFile or any lumberjack-compatible writer. Writes are
+		// serialized by the middleware.
+		// Optional. Default value nil (log to the doris logger).
+		Output io.Writer
+
+		// SlowThreshold is the latency above which a request is considered
+		// slow. Slow requests are logged at Warn level with the route
+		// template and the handler name.
+		// Optional. Default value 0 (disabled).
+		SlowThreshold time.Duration
+
+		// SampleRates maps a status class (2 for 2xx, 5 for 5xx...) to the
+		// fraction of the requests of that class which are logged, between
+		// 0 and 1. Classes missing from the map and slow requests are always
+		// logged, e.g. map[int]float64{2: 0.01} logs 1% of 2xx responses and
+		// every error.
+		// Optional. Default value nil (log every request).
+		SampleRates map[int]float64
+
+		// RouteLevels overrides the log level of routes, keyed by route
+		// template such as "/users/:id". Levels set with the RouteLogLevel
+		// middleware on a route or a group take precedence.
+		// Optional. Default value nil.
+		RouteLevels map[string]LogLevel
+
+		// DisableSuffix stops appending handler errors and the slow request
+		// note to the log line, so it can be read by log analyzers. It is
+		// always set for LogFormatCommon and LogFormatCombined.
+		// Optional. Default value false.
+		DisableSuffix bool
+
+		// CustomTimeFormat is the layout of the ${time_custom} tag.
+		// Optional. Default value "2006-01-02 15:04:05.00000".
+		CustomTimeFormat string
+
+		template  *logTemplate
+		skipPaths map[string]struct{}
+		mu        *sync.Mutex
+		random    func() float64
+	}
+
+	// logTemplate is a compiled log format.
+	logTemplate struct {
+		parts    []logPart
+		hasError bool
+	}
+
+	// logPart writes a literal or a tag of the log line.
+	logPart func(buf *bytes.Buffer, c *doris.Context, e *logEntry)
+
+	// logEntry holds the values measured around the request.
+	logEntry struct {
+		start   time.Time
+		latency time.Duration
+		level   LogLevel
+	}
+
+	// LogLevel is the level requests are logged at.
+	LogLevel uint8
+)
+
+// Log levels. Requests failing with a 4xx or 5xx status are logged at
+// LogLevelError and slow requests at LogLevelWarn or above, whatever the
+// level of the route, unless the route is silenced with LogLevelOff.
+const (
+	LogLevelDefault LogLevel = iota
+	LogLevelDebug
+	LogLevelInfo
+	LogLevelWarn
+	LogLevelError
+	LogLevelOff
+)
+
+// logLevelKey is the context key of the level set by RouteLogLevel.
+const logLevelKey = "doris.log_level"
+
+const (
+	// LogFormatCommon is the Common Log Format of the Apache and NGINX
+	// access logs.
+	LogFormatCommon = `${remote_ip} - ${remote_user} [${time_clf}] ${request_clf} ${status} ${bytes_out_clf}`
+
+	// LogFormatCombined is the Combined Log Format, the Common Log Format
+	// followed by the referer and the user agent. It can be read by log
+	// analyzers such as GoAccess and AWStats.
+	LogFormatCombined = LogFormatCommon + ` ${referer_clf} ${user_agent_clf}`
+
+	// timeFormatCLF is the time layout of the Common Log Format.
+	timeFormatCLF = "02/Jan/2006:15:04:05 -0700"
+)
+
+var (
+	// DefaultLoggerConfig is the default Logger middleware config.
+	DefaultLoggerConfig = LoggerConfig{
+		Skipper:          DefaultSkipper,
+		Format:           "${status} | ${status_text} | ${latency_human} | ${host} | ${remote_addr} | ${user_agent} | ${method} | ${uri}",
+		CustomTimeFormat: "2006-01-02 15:04:05.00000",
+	}
+
+	logBufferPool = sync.Pool{
+		New: func() interface{} {
+			return bytes.NewBuffer(make([]byte, 256))
+		},
+	}
+)
+
+// Logger returns a middleware that logs HTTP requests.
 func Logger() doris.HandlerFunc {
+	return LoggerWithConfig(DefaultLoggerConfig)
+}
+
+// LoggerWithConfig returns a Logger middleware with config.
+// See: `Logger()`.
+func LoggerWithConfig(config LoggerConfig) doris.HandlerFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultLoggerConfig.Skipper
+	}
+	if config.Format == "" {
+		config.Format = DefaultLoggerConfig.Format
+	}
+	if config.CustomTimeFormat == "" {
+		config.CustomTimeFormat = DefaultLoggerConfig.CustomTimeFormat
+	}
+	if config.Format == LogFormatCommon || config.Format == LogFormatCombined {
+		config.DisableSuffix = true
+	}
+	config.mu = new(sync.Mutex)
+	if config.random == nil {
+		config.random = rand.Float64
+	}
+	config.template = compileLogTemplate(config.Format, config.CustomTimeFormat)
+	if config.DisableSuffix {
+		config.template.hasError = true
+	}
+	if len(config.SkipPaths) > 0 {
+		config.skipPaths = make(map[string]struct{}, len(config.SkipPaths))
+		for _, p := range config.SkipPaths {
+			config.skipPaths[p] = struct{}{}
+		}
+	}
+
 	return func(c *doris.Context) error {
+		if config.Skipper(c) {
+			c.Next()
+			return nil
+		}
+		if _, ok := config.skipPaths[c.Request.URL.Path]; ok {
+			c.Next()
+			return nil
+		}
+
 		// 前向执行部分
-		begin := time.Now()
+		entry := &logEntry{start: time.Now()}
 		c.Next()
 
 		// 计算处理时间
-		elapsed := time.Since(begin)
-
-		// 获取请求信息
-		logs := strconv.Itoa(c.Response.Status()) + " | " +
-			doris.HTTPErrorMessages[c.Response.Status()].Error() + " | " +
-			fmt.Sprint(elapsed) + " | " +
-			c.Request.Host + " | " +
-			c.Request.RemoteAddr + " | " +
-			c.Request.UserAgent() + " | " +
-			c.Request.Method + " | " +
-			c.Request.RequestURI
-		l := c.Doris.Logger
+		entry.latency = time.Since(entry.start)
+
+		slow := config.SlowThreshold > 0 && entry.latency >= config.SlowThreshold
+		entry.level = config.level(c, slow)
+		if entry.level == LogLevelOff {
+			return nil
+		}
+		if !slow && !config.sampled(c.Response.Status()) {
+			return nil
+		}
 
-		if c.Response.Status() >= 400 {
-			l.Error(logs)
-		} else {
-			l.Info(logs)
+		buf := logBufferPool.Get().(*bytes.Buffer)
+		buf.Reset()
+		defer logBufferPool.Put(buf)
+		config.template.execute(buf, c, entry)
+		if slow && !config.DisableSuffix {
+			buf.WriteString(" | slow request (> ")
+			buf.WriteString(config.SlowThreshold.String())
+			buf.WriteString(") | ")
+			buf.WriteString(c.FullPath())
+			buf.WriteString(" | ")
+			buf.WriteString(c.MainHandlerName())
 		}
 
+		if config.Output != nil {
+			buf.WriteByte('\n')
+			config.mu.Lock()
+			_, err := config.Output.Write(buf.Bytes())
+			config.mu.Unlock()
+			if err != nil {
+				c.Doris.Logger.Error("logger: write output: " + err.Error())
+			}
+			return nil
+		}
+
+		l := c.Doris.Logger
+		switch entry.level {
+		case LogLevelDebug:
+			l.Debug(buf.String())
+		case LogLevelWarn:
+			l.Warn(buf.String())
+		case LogLevelError:
+			l.Error(buf.String())
+		default:
+			l.Info(buf.String())
+		}
+
+		return nil
+	}
+}
+
+// RouteLogLevel returns a middleware which sets the level the Logger middleware
+// logs the requests of a route or a group at, e.g. LogLevelOff to silence a
+// polling endpoint or LogLevelDebug for a new flow:
+//
+//	d.GET("/poll", middleware.RouteLogLevel(middleware.LogLevelOff), poll)
+func RouteLogLevel(level LogLevel) doris.HandlerFunc {
+	return func(c *doris.Context) error {
+		c.SetParam(logLevelKey, level)
+		c.Next()
 		return nil
 	}
 }
+
+// level returns the level the request is logged at.
+func (config *LoggerConfig) level(c *doris.Context, slow bool) LogLevel {
+	level, _ := c.Param(logLevelKey).(LogLevel)
+	if level == LogLevelDefault {
+		level = config.RouteLevels[c.FullPath()]
+	}
+	switch {
+	case level == LogLevelOff:
+		return LogLevelOff
+	case c.Response.Status() >= 400:
+		return LogLevelError
+	case slow && level < LogLevelWarn:
+		return LogLevelWarn
+	case level == LogLevelDefault:
+		return LogLevelInfo
+	}
+	return level
+}
+
+// String returns the name of the level.
+func (l LogLevel) String() string {
+	switch l {
+	case LogLevelDebug:
+		return "DEBUG"
+	case LogLevelInfo:
+		return "INFO"
+	case LogLevelWarn:
+		return "WARN"
+	case LogLevelError:
+		return "ERROR"
+	case LogLevelOff:
+		return "OFF"
+	}
+	return "DEFAULT"
+}
+
+// sampled reports whether a request with the status is logged.
+func (config *LoggerConfig) sampled(status int) bool {
+	rate, ok := config.SampleRates[status/100]
+	if !ok || rate >= 1 {
+		return true
+	}
+	return rate > 0 && config.random() < rate
+}
+
+// compileLogTemplate compiles the format into its parts.
+func compileLogTemplate(format, timeFormat string) *logTemplate {
+	t := &logTemplate{}
+	for {
+		i := strings.Index(format, "${")
+		if i < 0 {
+			break
+		}
+		j := strings.IndexByte(format[i:], '}')
+		if j < 0 {
+			break
+		}
+		t.literal(format[:i])
+		tag := format[i+2 : i+j]
+		if tag == "error" {
+			t.hasError = true
+		}
+		t.parts = append(t.parts, logTag(tag, timeFormat))
+		format = format[i+j+1:]
+	}
+	t.literal(format)
+	return t
+}
+
+// literal appends a literal part.
+func (t *logTemplate) literal(s string) {
+	if s == "" {
+		return
+	}
+	t.parts = append(t.parts, func(buf *bytes.Buffer, c *doris.Context, e *logEntry) {
+		buf.WriteString(s)
+	})
+}
+
+// execute writes the log line of the request.
+func (t *logTemplate) execute(buf *bytes.Buffer, c *doris.Context, e *logEntry) {
+	for _, part := range t.parts {
+		part(buf, c, e)
+	}
+	// 追加处理链中收集的错误信息
+	if !t.hasError && len(c.Errors) > 0 {
+		buf.WriteString(" | ")
+		buf.WriteString(strings.Join(c.Errors.Errors(), "; "))
+	}
+}
+
+// logTag returns the part writing the tag.
+func logTag(tag, timeFormat string) logPart {
+	switch tag {
+	case "time_rfc3339":
+		return func(buf *bytes.Buffer, c *doris.Context, e *logEntry) {
+			buf.WriteString(e.start.Format(time.RFC3339))
+		}
+	case "time_unix":
+		return func(buf *bytes.Buffer, c *doris.Context, e *logEntry) {
+			buf.WriteString(strconv.FormatInt(e.start.Unix(), 10))
+		}
+	case "time_custom":
+		return func(buf *bytes.Buffer, c *doris.Context, e *logEntry) {
+			buf.WriteString(e.start.Format(timeFormat))
+		}
+	case "id":
+		return func(buf *bytes.Buffer, c *doris.Context, e *logEntry) {
+			buf.WriteString(c.RequestID())
+		}
+	case "remote_ip":
+		return func(buf *bytes.Buffer, c *doris.Context, e *logEntry) {
+			buf.WriteString(c.RealIP())
+		}
+	case "remote_addr":
+		return func(buf *bytes.Buffer, c *doris.Context, e *logEntry) {
+			buf.WriteString(c.Request.RemoteAddr)
+		}
+	case "host":
+		return func(buf *bytes.Buffer, c *doris.Context, e *logEntry) {
+			buf.WriteString(c.Request.Host)
+		}
+	case "method":
+		return func(buf *bytes.Buffer, c *doris.Context, e *logEntry) {
+			buf.WriteString(c.Request.Method)
+		}
+	case "uri":
+		return func(buf *bytes.Buffer, c *doris.Context, e *logEntry) {
+			buf.WriteString(c.Request.RequestURI)
+		}
+	case "path":
+		return func(buf *bytes.Buffer, c *doris.Context, e *logEntry) {
+			p := c.Request.URL.Path
+			if p == "" {
+				p = "/"
+			}
+			buf.WriteString(p)
+		}
+	case "protocol":
+		return func(buf *bytes.Buffer, c *doris.Context, e *logEntry) {
+			buf.WriteString(c.Request.Proto)
+		}
+	case "referer":
+		return func(buf *bytes.Buffer, c *doris.Context, e *logEntry) {
+			buf.WriteString(c.Request.Referer())
+		}
+	case "user_agent":
+		return func(buf *bytes.Buffer, c *doris.Context, e *logEntry) {
+			buf.WriteString(c.Request.UserAgent())
+		}
+	case "route":
+		return func(buf *bytes.Buffer, c *doris.Context, e *logEntry) {
+			buf.WriteString(c.FullPath())
+		}
+	case "handler":
+		return func(buf *bytes.Buffer, c *doris.Context, e *logEntry) {
+			buf.WriteString(c.MainHandlerName())
+		}
+	case "remote_user":
+		return func(buf *bytes.Buffer, c *doris.Context, e *logEntry) {
+			user, _, _ := c.Request.BasicAuth()
+			writeCLF(buf, user, false)
+		}
+	case "time_clf":
+		return func(buf *bytes.Buffer, c *doris.Context, e *logEntry) {
+			buf.WriteString(e.start.Format(timeFormatCLF))
+		}
+	case "request_clf":
+		return func(buf *bytes.Buffer, c *doris.Context, e *logEntry) {
+			writeCLF(buf, c.Request.Method+" "+c.Request.RequestURI+" "+c.Request.Proto, true)
+		}
+	case "bytes_out_clf":
+		return func(buf *bytes.Buffer, c *doris.Context, e *logEntry) {
+			if size := c.Response.Size(); size > 0 {
+				buf.WriteString(strconv.Itoa(size))
+			} else {
+				buf.WriteByte('-')
+			}
+		}
+	case "referer_clf":
+		return func(buf *bytes.Buffer, c *doris.Context, e *logEntry) {
+			writeCLF(buf, c.Request.Referer(), true)
+		}
+	case "user_agent_clf":
+		return func(buf *bytes.Buffer, c *doris.Context, e *logEntry) {
+			writeCLF(buf, c.Request.UserAgent(), true)
+		}
+	case "level":
+		return func(buf *bytes.Buffer, c *doris.Context, e *logEntry) {
+			buf.WriteString(e.level.String())
+		}
+	case "status":
+		return func(buf *bytes.Buffer, c *doris.Context, e *logEntry) {
+			buf.WriteString(strconv.Itoa(c.Response.Status()))
+		}
+	case "status_text":
+		return func(buf *bytes.Buffer, c *doris.Context, e *logEntry) {
+			buf.WriteString(doris.StatusMessage(c.Response.Status()))
+		}
+	case "error":
+		return func(buf *bytes.Buffer, c *doris.Context, e *logEntry) {
+			buf.WriteString(strings.Join(c.Errors.Errors(), "; "))
+		}
+	case "latency":
+		return func(buf *bytes.Buffer, c *doris.Context, e *logEntry) {
+			buf.WriteString(strconv.FormatInt(int64(e.latency), 10))
+		}
+	case "latency_human":
+		return func(buf *bytes.Buffer, c *doris.Context, e *logEntry) {
+			buf.WriteString(e.latency.String())
+		}
+	case "bytes_in":
+		return func(buf *bytes.Buffer, c *doris.Context, e *logEntry) {
+			cl := c.Request.Header.Get(doris.HeaderContentLength)
+			if cl == "" {
+				cl = "0"
+			}
+			buf.WriteString(cl)
+		}
+	case "bytes_out":
+		return func(buf *bytes.Buffer, c *doris.Context, e *logEntry) {
+			size := c.Response.Size()
+			if size < 0 {
+				size = 0
+			}
+			buf.WriteString(strconv.Itoa(size))
+		}
+	}
+	switch {
+	case strings.HasPrefix(tag, "header:"):
+		name := tag[7:]
+		return func(buf *bytes.Buffer, c *doris.Context, e *logEntry) {
+			buf.WriteString(c.Request.Header.Get(name))
+		}
+	case strings.HasPrefix(tag, "query:"):
+		name := tag[6:]
+		return func(buf *bytes.Buffer, c *doris.Context, e *logEntry) {
+			buf.WriteString(c.QueryParam(name))
+		}
+	case strings.HasPrefix(tag, "form:"):
+		name := tag[5:]
+		return func(buf *bytes.Buffer, c *doris.Context, e *logEntry) {
+			buf.WriteString(c.FormParam(name))
+		}
+	}
+	// 未知标签原样输出
+	unknown := "${" + tag + "}"
+	return func(buf *bytes.Buffer, c *doris.Context, e *logEntry) {
+		buf.WriteString(unknown)
+	}
+}
+
+// writeCLF writes the value the way the Common Log Format does: "-" for
+// empty values, quotes and control characters escaped.
+func writeCLF(buf *bytes.Buffer, value string, quoted bool) {
+	if quoted {
+		buf.WriteByte('"')
+	}
+	if value == "" {
+		buf.WriteByte('-')
+	}
+	for i := 0; i < len(value); i++ {
+		switch ch := value[i]; {
+		case ch == '"' || ch == '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(ch)
+		case ch < 0x20 || ch == 0x7f:
+			buf.WriteString(`\x`)
+			buf.WriteByte(hexDigits[ch>>4])
+			buf.WriteByte(hexDigits[ch&0xf])
+		default:
+			buf.WriteByte(ch)
+		}
+	}
+	if quoted {
+		buf.WriteByte('"')
+	}
+}
+
+const hexDigits = "0123456789abcdef"
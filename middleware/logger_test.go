@@ -0,0 +1,249 @@
+// logger test file
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leaderwolfpipi/doris"
+	"github.com/stretchr/testify/assert"
+)
+
+// renderLog serves the request and renders the log line with the format
+func renderLog(format string, req *http.Request, h doris.HandlerFunc) string {
+	t := compileLogTemplate(format, DefaultLoggerConfig.CustomTimeFormat)
+	line := ""
+	render := func(c *doris.Context) error {
+		e := &logEntry{start: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)}
+		c.Next()
+		e.latency = 1500 * time.Millisecond
+		buf := new(bytes.Buffer)
+		t.execute(buf, c, e)
+		line = buf.String()
+		return nil
+	}
+	d := doris.New()
+	d.GET("/users/:id", render, h)
+	d.ServeHTTP(httptest.NewRecorder(), req)
+	return line
+}
+
+func TestLoggerFormat(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/users/1?lang=go", nil)
+	req.Header.Set("User-Agent", "doris-test")
+	req.Header.Set("X-Trace", "abc")
+	h := func(c *doris.Context) error {
+		c.String(http.StatusCreated, "hello")
+		return nil
+	}
+
+	line := renderLog("${method} ${path} ${status} ${status_text} ${latency} ${latency_human} ${bytes_out}", req, h)
+	assert.Equal(t, "GET /users/1 201 Created 1500000000 1.5s 5", line)
+
+	line = renderLog("${uri}|${user_agent}|${header:X-Trace}|${query:lang}|${unknown}", req, h)
+	assert.Equal(t, "/users/1?lang=go|doris-test|abc|go|${unknown}", line)
+
+	teapot := func(c *doris.Context) error {
+		c.String(http.StatusTeapot, "short and stout")
+		return nil
+	}
+	line = renderLog("${status} ${status_text}", req, teapot)
+	assert.Equal(t, "418 I'm a teapot", line)
+
+	line = renderLog("${time_rfc3339} ${time_unix} ${time_custom}", req, h)
+	assert.Equal(t, "2020-01-02T03:04:05Z 1577934245 2020-01-02 03:04:05.00000", line)
+}
+
+func TestLoggerFormatError(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	h := func(c *doris.Context) error {
+		c.String(http.StatusInternalServerError, "failed")
+		c.Error(errors.New("boom"))
+		return nil
+	}
+
+	assert.Equal(t, "500 | boom", renderLog("${status}", req, h))
+	assert.Equal(t, "500 [boom]", renderLog("${status} [${error}]", req, h))
+}
+
+func TestLoggerWithConfig(t *testing.T) {
+	d := doris.New()
+	d.GET("/", LoggerWithConfig(LoggerConfig{Format: "${status}"}), func(c *doris.Context) error {
+		c.String(http.StatusOK, "ok")
+		return nil
+	})
+	res := httptest.NewRecorder()
+	d.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, res.Code)
+}
+
+func TestLoggerSkipPaths(t *testing.T) {
+	config := LoggerConfig{SkipPaths: []string{"/health"}}
+	mw := LoggerWithConfig(config)
+	served := 0
+	d := doris.New()
+	d.GET("/health", mw, func(c *doris.Context) error {
+		served++
+		c.String(http.StatusOK, "ok")
+		return nil
+	})
+	res := httptest.NewRecorder()
+	d.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/health", nil))
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, 1, served)
+
+	skipped := 0
+	mw = LoggerWithConfig(LoggerConfig{Skipper: func(c *doris.Context) bool {
+		skipped++
+		return true
+	}})
+	d = doris.New()
+	d.GET("/", mw, func(c *doris.Context) error {
+		served++
+		return nil
+	})
+	d.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, 1, skipped)
+	assert.Equal(t, 2, served)
+}
+
+func TestLoggerOutput(t *testing.T) {
+	out := new(bytes.Buffer)
+	d := doris.New()
+	d.GET("/", LoggerWithConfig(LoggerConfig{Format: "${method} ${status}", Output: out}), func(c *doris.Context) error {
+		c.String(http.StatusOK, "ok")
+		return nil
+	})
+	d.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	d.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, "GET 200\nGET 200\n", out.String())
+}
+
+func slowHandler(c *doris.Context) error {
+	time.Sleep(5 * time.Millisecond)
+	c.String(http.StatusOK, "ok")
+	return nil
+}
+
+func TestLoggerSlowThreshold(t *testing.T) {
+	out := new(bytes.Buffer)
+	d := doris.New()
+	mw := LoggerWithConfig(LoggerConfig{Format: "${status}", Output: out, SlowThreshold: time.Millisecond})
+	d.GET("/users/:id", mw, slowHandler)
+	d.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	assert.Equal(t, "200 | slow request (> 1ms) | /users/:id | github.com/leaderwolfpipi/doris/middleware.slowHandler\n", out.String())
+
+	out.Reset()
+	mw = LoggerWithConfig(LoggerConfig{Format: "${route}", Output: out, SlowThreshold: time.Minute})
+	d = doris.New()
+	d.GET("/users/:id", mw, slowHandler)
+	d.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	assert.Equal(t, "/users/:id\n", out.String())
+}
+
+func TestLoggerSampling(t *testing.T) {
+	out := new(bytes.Buffer)
+	config := LoggerConfig{
+		Format:      "${status}",
+		Output:      out,
+		SampleRates: map[int]float64{2: 0.01, 4: 0},
+	}
+	n := 0
+	config.random = func() float64 {
+		n++
+		if n%100 == 0 {
+			return 0
+		}
+		return 0.5
+	}
+	status := http.StatusOK
+	d := doris.New()
+	d.GET("/", LoggerWithConfig(config), func(c *doris.Context) error {
+		c.String(status, "ok")
+		return nil
+	})
+	serve := func(s, times int) {
+		status = s
+		for i := 0; i < times; i++ {
+			d.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		}
+	}
+
+	serve(http.StatusOK, 200)
+	assert.Equal(t, "200\n200\n", out.String())
+
+	out.Reset()
+	serve(http.StatusNotFound, 3)
+	serve(http.StatusInternalServerError, 2)
+	assert.Equal(t, "500\n500\n", out.String())
+}
+
+func TestLoggerCombinedFormat(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/users/1?q=1", nil)
+	req.RemoteAddr = "10.0.0.1:5678"
+	req.SetBasicAuth("frank", "secret")
+	req.Header.Set("Referer", "http://example.com/start")
+	req.Header.Set("User-Agent", `Mozilla/5.0 "quoted"`)
+	h := func(c *doris.Context) error {
+		c.String(http.StatusOK, "hello")
+		return nil
+	}
+	line := renderLog(LogFormatCombined, req, h)
+	assert.Equal(t, `10.0.0.1 - frank [02/Jan/2020:03:04:05 +0000] "GET /users/1?q=1 HTTP/1.1" 200 5 "http://example.com/start" "Mozilla/5.0 \"quoted\""`, line)
+
+	req = httptest.NewRequest(http.MethodGet, "/users/2", nil)
+	req.RemoteAddr = "10.0.0.1:5678"
+	req.Header.Del("User-Agent")
+	noContent := func(c *doris.Context) error {
+		c.Response.WriteHeader(http.StatusNoContent)
+		c.Response.WriteHeaderNow()
+		return nil
+	}
+	line = renderLog(LogFormatCombined, req, noContent)
+	assert.Equal(t, `10.0.0.1 - - [02/Jan/2020:03:04:05 +0000] "GET /users/2 HTTP/1.1" 204 - "-" "-"`, line)
+}
+
+func TestLoggerCommonFormatSuffix(t *testing.T) {
+	out := new(bytes.Buffer)
+	d := doris.New()
+	d.GET("/", LoggerWithConfig(LoggerConfig{Format: LogFormatCommon, Output: out}), func(c *doris.Context) error {
+		c.Error(errors.New("boom"))
+		c.String(http.StatusInternalServerError, "failed")
+		return nil
+	})
+	d.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.True(t, strings.HasSuffix(out.String(), `"GET / HTTP/1.1" 500 6`+"\n"), out.String())
+}
+
+func TestLoggerRouteLevels(t *testing.T) {
+	out := new(bytes.Buffer)
+	d := doris.New()
+	d.Use(LoggerWithConfig(LoggerConfig{
+		Format:      "${level} ${path} ${status}",
+		Output:      out,
+		RouteLevels: map[string]LogLevel{"/orders/:id": LogLevelWarn},
+	}))
+	ok := func(c *doris.Context) error {
+		c.String(http.StatusOK, "ok")
+		return nil
+	}
+	d.GET("/poll", RouteLogLevel(LogLevelOff), ok)
+	d.GET("/orders/:id", ok)
+	d.GET("/users", ok)
+	checkout := d.Group("/checkout", RouteLogLevel(LogLevelDebug))
+	checkout.GET("/start", ok)
+	checkout.GET("/fail", func(c *doris.Context) error {
+		c.String(http.StatusBadRequest, "bad")
+		return nil
+	})
+
+	for _, path := range []string{"/poll", "/orders/1", "/users", "/checkout/start", "/checkout/fail"} {
+		d.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	assert.Equal(t, "WARN /orders/1 200\nINFO /users 200\nDEBUG /checkout/start 200\nERROR /checkout/fail 400\n", out.String())
+}
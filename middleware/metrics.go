@@ -0,0 +1,172 @@
+package middleware
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/leaderwolfpipi/doris"
+)
+
+type (
+	// MetricsConfig defines the config for Metrics middleware.
+	MetricsConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Recorder receives the observation of every request.
+		// Required.
+		Recorder RouteMetrics
+
+		// UnmatchedRoute is the route label of requests that matched no
+		// route, so scanners can't blow up the label cardinality.
+		// Optional. Default value "unmatched".
+		UnmatchedRoute string
+	}
+
+	// RouteMetrics records the latency of requests, labeled by the
+	// registered route pattern (e.g. "/users/:id") instead of the raw path.
+	RouteMetrics interface {
+		Observe(c *doris.Context, method, route string, status int, latency time.Duration)
+	}
+
+	// InFlightMetrics is implemented by recorders which also track the live
+	// load of the server. The Metrics middleware calls ObserveInFlight when
+	// a request starts, with the request itself counted, e.g. to set
+	// Prometheus gauges:
+	//
+	//	func (r *recorder) ObserveInFlight(c *doris.Context, requests, conns int) {
+	//		r.inFlight.Set(float64(requests))
+	//		r.openConns.Set(float64(conns))
+	//	}
+	InFlightMetrics interface {
+		ObserveInFlight(c *doris.Context, activeRequests, openConns int)
+	}
+
+	// RouteMetricsFunc is an adapter to use a function as RouteMetrics, e.g.
+	// to observe a Prometheus histogram vector:
+	//
+	//	middleware.RouteMetricsFunc(func(c *doris.Context, method, route string, status int, latency time.Duration) {
+	//		httpDuration.WithLabelValues(method, route, strconv.Itoa(status)).Observe(latency.Seconds())
+	//	})
+	RouteMetricsFunc func(c *doris.Context, method, route string, status int, latency time.Duration)
+
+	// RouteLatency is an in-memory RouteMetrics keeping count, total and
+	// maximum latency per method and route.
+	RouteLatency struct {
+		lock   sync.RWMutex
+		routes map[RouteKey]*RouteLatencyStats
+	}
+
+	// RouteKey identifies a route in RouteLatency.
+	RouteKey struct {
+		Method string `json:"method"`
+		Route  string `json:"route"`
+	}
+
+	// RouteLatencyStats is the latency summary of a route.
+	RouteLatencyStats struct {
+		RouteKey
+		Count  uint64        `json:"count"`
+		Errors uint64        `json:"errors"` // Responses with status >= 500
+		Total  time.Duration `json:"total"`
+		Max    time.Duration `json:"max"`
+	}
+)
+
+var (
+	// DefaultMetricsConfig is the default Metrics middleware config.
+	DefaultMetricsConfig = MetricsConfig{
+		Skipper:        DefaultSkipper,
+		UnmatchedRoute: "unmatched",
+	}
+)
+
+// Metrics returns a middleware which reports the latency of every request
+// to the recorder, labeled by method, route pattern and status.
+func Metrics(recorder RouteMetrics) doris.HandlerFunc {
+	config := DefaultMetricsConfig
+	config.Recorder = recorder
+	return MetricsWithConfig(config)
+}
+
+// MetricsWithConfig returns a Metrics middleware with config.
+// See: `Metrics()`.
+func MetricsWithConfig(config MetricsConfig) doris.HandlerFunc {
+	// Defaults
+	if config.Recorder == nil {
+		panic("doris: metrics middleware requires a recorder")
+	}
+	if config.Skipper == nil {
+		config.Skipper = DefaultMetricsConfig.Skipper
+	}
+	if config.UnmatchedRoute == "" {
+		config.UnmatchedRoute = DefaultMetricsConfig.UnmatchedRoute
+	}
+
+	return func(c *doris.Context) error {
+		if config.Skipper(c) {
+			c.Next()
+			return nil
+		}
+
+		if m, ok := config.Recorder.(InFlightMetrics); ok {
+			m.ObserveInFlight(c, c.Doris.ActiveRequests(), c.Doris.OpenConns())
+		}
+		start := time.Now()
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			route = config.UnmatchedRoute
+		}
+		config.Recorder.Observe(c, c.Request.Method, route, c.Response.Status(), time.Since(start))
+		return nil
+	}
+}
+
+// Observe implements RouteMetrics.
+func (f RouteMetricsFunc) Observe(c *doris.Context, method, route string, status int, latency time.Duration) {
+	f(c, method, route, status, latency)
+}
+
+// NewRouteLatency returns an in-memory route latency recorder.
+func NewRouteLatency() *RouteLatency {
+	return &RouteLatency{routes: make(map[RouteKey]*RouteLatencyStats)}
+}
+
+// Observe implements RouteMetrics.
+func (m *RouteLatency) Observe(c *doris.Context, method, route string, status int, latency time.Duration) {
+	key := RouteKey{Method: method, Route: route}
+	m.lock.Lock()
+	s, ok := m.routes[key]
+	if !ok {
+		s = &RouteLatencyStats{RouteKey: key}
+		m.routes[key] = s
+	}
+	s.Count++
+	if status >= 500 {
+		s.Errors++
+	}
+	s.Total += latency
+	if latency > s.Max {
+		s.Max = latency
+	}
+	m.lock.Unlock()
+}
+
+// Stats returns a snapshot of all routes sorted by route and method.
+func (m *RouteLatency) Stats() []RouteLatencyStats {
+	m.lock.RLock()
+	stats := make([]RouteLatencyStats, 0, len(m.routes))
+	for _, s := range m.routes {
+		stats = append(stats, *s)
+	}
+	m.lock.RUnlock()
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Route != stats[j].Route {
+			return stats[i].Route < stats[j].Route
+		}
+		return stats[i].Method < stats[j].Method
+	})
+	return stats
+}
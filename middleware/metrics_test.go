@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/leaderwolfpipi/doris"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	latency := NewRouteLatency()
+	d := doris.New()
+	d.Use(Metrics(latency))
+	d.GET("/users/:id", func(c *doris.Context) error {
+		c.String(http.StatusOK, "ok")
+		return nil
+	})
+	d.GET("/boom", func(c *doris.Context) error {
+		time.Sleep(time.Millisecond)
+		return doris.NewHTTPError(http.StatusInternalServerError)
+	})
+	for _, path := range []string{"/users/1", "/users/2", "/boom", "/no/such/path"} {
+		d.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	stats := latency.Stats()
+	assert.Len(t, stats, 3)
+	assert.Equal(t, RouteKey{Method: http.MethodGet, Route: "/boom"}, stats[0].RouteKey)
+	assert.Equal(t, uint64(1), stats[0].Errors)
+	assert.True(t, stats[0].Max >= time.Millisecond)
+	assert.Equal(t, RouteKey{Method: http.MethodGet, Route: "/users/:id"}, stats[1].RouteKey)
+	assert.Equal(t, uint64(2), stats[1].Count)
+	assert.Equal(t, uint64(0), stats[1].Errors)
+	assert.Equal(t, RouteKey{Method: http.MethodGet, Route: "unmatched"}, stats[2].RouteKey)
+}
+
+type inFlightRecorder struct {
+	*RouteLatency
+	requests []int
+}
+
+func (r *inFlightRecorder) ObserveInFlight(c *doris.Context, activeRequests, openConns int) {
+	r.requests = append(r.requests, activeRequests)
+}
+
+func TestMetricsInFlight(t *testing.T) {
+	recorder := &inFlightRecorder{RouteLatency: NewRouteLatency()}
+	d := doris.New()
+	d.Use(Metrics(recorder))
+	d.GET("/", func(c *doris.Context) error { return nil })
+	d.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, []int{1}, recorder.requests)
+}
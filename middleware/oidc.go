@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// oidcProvider resolves the signing keys of an OpenID Connect provider
+	// through the discovery document of its issuer.
+	oidcProvider struct {
+		issuer          string
+		client          *http.Client
+		refreshInterval time.Duration
+		lock            sync.Mutex
+		keySet          *jwks
+		lastAttempt     time.Time
+	}
+
+	// oidcConfiguration is the part of the discovery document used by doris.
+	// See: https://openid.net/specs/openid-connect-discovery-1_0.html
+	oidcConfiguration struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+)
+
+// oidcRetryInterval is the minimal interval between two failed discoveries.
+const oidcRetryInterval = 10 * time.Second
+
+// newOIDCProvider returns a provider for the issuer.
+// The discovery is performed lazily on first use.
+func newOIDCProvider(issuer string, refreshInterval time.Duration, client *http.Client) *oidcProvider {
+	if client == nil {
+		client = &http.Client{Timeout: defaultJWKSTimeout}
+	}
+	return &oidcProvider{
+		issuer:          issuer,
+		client:          client,
+		refreshInterval: refreshInterval,
+	}
+}
+
+// key returns the public key for kid from the JWKS of the provider.
+func (p *oidcProvider) key(kid string) (interface{}, error) {
+	keySet, err := p.jwks()
+	if err != nil {
+		return nil, err
+	}
+	return keySet.key(kid)
+}
+
+// jwks returns the key set of the provider, discovering it on first use.
+func (p *oidcProvider) jwks() (*jwks, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.keySet != nil {
+		return p.keySet, nil
+	}
+	if time.Since(p.lastAttempt) < oidcRetryInterval {
+		return nil, fmt.Errorf("oidc discovery of %s failed recently", p.issuer)
+	}
+	p.lastAttempt = time.Now()
+	conf, err := p.discover()
+	if err != nil {
+		return nil, err
+	}
+	p.keySet = newJWKS(conf.JWKSURI, p.refreshInterval, p.client)
+	return p.keySet, nil
+}
+
+// discover fetches and checks the discovery document of the issuer.
+func (p *oidcProvider) discover() (*oidcConfiguration, error) {
+	url := strings.TrimSuffix(p.issuer, "/") + "/.well-known/openid-configuration"
+	resp, err := p.client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc discovery %s returned status %d", url, resp.StatusCode)
+	}
+	conf := &oidcConfiguration{}
+	if err := json.NewDecoder(resp.Body).Decode(conf); err != nil {
+		return nil, err
+	}
+	// The issuer of the document must be identical to the configured one
+	if conf.Issuer != p.issuer {
+		return nil, fmt.Errorf("oidc issuer mismatch: expected %s got %s", p.issuer, conf.Issuer)
+	}
+	if conf.JWKSURI == "" {
+		return nil, fmt.Errorf("oidc discovery %s has no jwks_uri", url)
+	}
+	return conf, nil
+}
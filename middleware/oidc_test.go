@@ -0,0 +1,63 @@
+// oidc test file
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/leaderwolfpipi/doris"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOIDC(t *testing.T) {
+	private, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.NoError(t, err)
+	n := base64.RawURLEncoding.EncodeToString(private.N.Bytes())
+	e := base64.RawURLEncoding.EncodeToString(big.NewInt(int64(private.E)).Bytes())
+
+	var issuer string
+	discoveries := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		discoveries++
+		fmt.Fprintf(w, `{"issuer":"%s","jwks_uri":"%s/jwks"}`, issuer, issuer)
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"keys":[{"kty":"RSA","kid":"k1","use":"sig","n":"%s","e":"%s"}]}`, n, e)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	issuer = server.URL
+
+	sign := func(claims Claims) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims(claims))
+		token.Header["kid"] = "k1"
+		signed, err := token.SignedString(private)
+		assert.NoError(t, err)
+		return signed
+	}
+	exp := time.Now().Add(time.Hour).Unix()
+
+	config := JWTConfig{OIDCIssuer: issuer, ExpectedAudience: "api"}
+	assert.NoError(t, serveJWT(config, sign(NewClaims().Issuer(issuer).Audience("api").Set(ClaimExpiresAt, exp))))
+	assert.Equal(t, doris.TokenIssuerErr, serveJWT(config, sign(NewClaims().Issuer("other").Audience("api"))))
+	assert.Equal(t, doris.TokenAudienceErr, serveJWT(config, sign(NewClaims().Issuer(issuer).Audience("web"))))
+
+	// the issuer of the discovery document must match
+	discoveries = 0
+	provider := newOIDCProvider(issuer+"/", time.Hour, nil)
+	_, err = provider.key("k1")
+	assert.Error(t, err)
+	// failed discoveries are not retried immediately
+	_, err = provider.key("k1")
+	assert.Error(t, err)
+	assert.Equal(t, 1, discoveries)
+}
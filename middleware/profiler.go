@@ -0,0 +1,173 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"runtime/pprof"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/leaderwolfpipi/doris"
+)
+
+type (
+	// ProfilerConfig defines the config for Profiler middleware.
+	ProfilerConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Threshold is the duration after which a request is slow. The stack
+		// of the request goroutine is captured when the threshold passes,
+		// showing where the request is stuck (e.g. waiting for a lock).
+		// Optional. Default value 1s.
+		Threshold time.Duration
+
+		// MaxStackSize is the maximum size of the goroutine profile the
+		// request goroutine is picked out of.
+		// Optional. Default value 8 MB.
+		MaxStackSize int
+
+		// Handler receives the profile of every slow request.
+		// Optional. Default value logs a warning with the Doris logger.
+		Handler SlowRequestHandler
+	}
+
+	// SlowRequest is the profile of a request slower than the threshold.
+	SlowRequest struct {
+		Method     string
+		Route      string
+		Path       string
+		RequestID  string
+		Handler    string        // Name of the main handler
+		Total      time.Duration // Time spent in the chain after the profiler
+		Main       time.Duration // Time spent in the main handler
+		Middleware time.Duration // Total minus Main
+		Stack      []byte        // Goroutine profile records of the request at the threshold
+	}
+
+	// SlowRequestHandler receives the profile of a slow request.
+	SlowRequestHandler func(c *doris.Context, profile *SlowRequest)
+)
+
+const profilerLabel = "doris_request"
+
+var (
+	// profilerRequests numbers the profiled requests for their pprof label.
+	profilerRequests uint64
+
+	// DefaultProfilerConfig is the default Profiler middleware config.
+	DefaultProfilerConfig = ProfilerConfig{
+		Skipper:      DefaultSkipper,
+		Threshold:    time.Second,
+		MaxStackSize: 8 << 20, // 8 MB
+		Handler:      logSlowRequest,
+	}
+)
+
+// Profiler returns a middleware which profiles requests slower than the
+// threshold: the stack of the request goroutine when the threshold passes
+// and the time spent in middleware versus the main handler.
+//
+// The request goroutine is tagged with a pprof label, which costs a few
+// small allocations per request. Taking the goroutine profile stops the
+// world briefly, so only slow requests pay for it. Register it first to
+// measure all middleware.
+func Profiler(threshold time.Duration) doris.HandlerFunc {
+	config := DefaultProfilerConfig
+	config.Threshold = threshold
+	return ProfilerWithConfig(config)
+}
+
+// ProfilerWithConfig returns a Profiler middleware with config.
+// See: `Profiler()`.
+func ProfilerWithConfig(config ProfilerConfig) doris.HandlerFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultProfilerConfig.Skipper
+	}
+	if config.Threshold <= 0 {
+		config.Threshold = DefaultProfilerConfig.Threshold
+	}
+	if config.MaxStackSize <= 0 {
+		config.MaxStackSize = DefaultProfilerConfig.MaxStackSize
+	}
+	if config.Handler == nil {
+		config.Handler = DefaultProfilerConfig.Handler
+	}
+
+	return func(c *doris.Context) error {
+		if config.Skipper(c) {
+			c.Next()
+			return nil
+		}
+
+		// 用pprof标签标记请求协程，超过阈值时从协程profile中取出它的调用栈
+		label := strconv.FormatUint(atomic.AddUint64(&profilerRequests, 1), 10)
+		var stack []byte
+		captured := make(chan struct{})
+		timer := time.AfterFunc(config.Threshold, func() {
+			stack = labeledStack(label, config.MaxStackSize)
+			close(captured)
+		})
+
+		c.TimeMainHandler()
+		start := time.Now()
+		pprof.Do(c.Request.Context(), pprof.Labels(profilerLabel, label), func(context.Context) {
+			c.Next()
+		})
+		total := time.Since(start)
+
+		if timer.Stop() {
+			return nil
+		}
+		<-captured
+		main := c.MainHandlerDuration()
+		config.Handler(c, &SlowRequest{
+			Method:     c.Request.Method,
+			Route:      c.FullPath(),
+			Path:       c.Request.URL.Path,
+			RequestID:  c.RequestID(),
+			Handler:    c.MainHandlerName(),
+			Total:      total,
+			Main:       main,
+			Middleware: total - main,
+			Stack:      stack,
+		})
+		return nil
+	}
+}
+
+// logSlowRequest logs the profile as a warning.
+func logSlowRequest(c *doris.Context, profile *SlowRequest) {
+	c.Doris.Logger.Warn("[SLOW REQUEST] "+profile.Method+" "+profile.Path,
+		doris.F("request_id", profile.RequestID),
+		doris.F("route", profile.Route),
+		doris.F("handler", profile.Handler),
+		doris.F("total", profile.Total.String()),
+		doris.F("main", profile.Main.String()),
+		doris.F("middleware", profile.Middleware.String()),
+		doris.F("stack", string(profile.Stack)),
+	)
+}
+
+// labeledStack returns the records of the goroutine profile carrying the
+// profiler label: the request goroutine and the goroutines it started.
+func labeledStack(label string, maxSize int) []byte {
+	buf := &limitedBuffer{max: maxSize}
+	if err := pprof.Lookup("goroutine").WriteTo(buf, 1); err != nil {
+		return nil
+	}
+	tag := []byte(strconv.Quote(profilerLabel) + ":" + strconv.Quote(label))
+	var stack []byte
+	for _, record := range bytes.Split(buf.Bytes(), []byte("\n\n")) {
+		if !bytes.Contains(record, tag) {
+			continue
+		}
+		if stack != nil {
+			stack = append(stack, '\n', '\n')
+		}
+		stack = append(stack, record...)
+	}
+	return stack
+}
@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"runtime/pprof"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/leaderwolfpipi/doris"
+	"github.com/stretchr/testify/assert"
+)
+
+var profilerLock sync.Mutex
+
+func slowLockedHandler(c *doris.Context) error {
+	profilerLock.Lock()
+	defer profilerLock.Unlock()
+	c.String(http.StatusOK, "ok")
+	return nil
+}
+
+func TestProfiler(t *testing.T) {
+	var profiles []*SlowRequest
+	d := doris.New()
+	d.Use(ProfilerWithConfig(ProfilerConfig{
+		Threshold: 20 * time.Millisecond,
+		Handler: func(c *doris.Context, profile *SlowRequest) {
+			profiles = append(profiles, profile)
+		},
+	}))
+	d.Use(func(c *doris.Context) error {
+		if c.QueryParam("slow") == "middleware" {
+			time.Sleep(40 * time.Millisecond)
+		}
+		c.Next()
+		return nil
+	})
+	d.GET("/items/:id", slowLockedHandler)
+	serve := func(path string) {
+		d.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	serve("/items/1")
+	assert.Len(t, profiles, 0)
+
+	// 主处理函数等待锁
+	profilerLock.Lock()
+	go func() {
+		time.Sleep(60 * time.Millisecond)
+		profilerLock.Unlock()
+	}()
+	serve("/items/1")
+	assert.Len(t, profiles, 1)
+	p := profiles[0]
+	assert.Equal(t, "/items/:id", p.Route)
+	assert.Contains(t, p.Handler, "slowLockedHandler")
+	assert.True(t, p.Main >= 40*time.Millisecond)
+	assert.True(t, p.Middleware < p.Main)
+	assert.Contains(t, string(p.Stack), "slowLockedHandler")
+	assert.Contains(t, string(p.Stack), "sync.(*Mutex).Lock")
+
+	serve("/items/1?slow=middleware")
+	assert.Len(t, profiles, 2)
+	p = profiles[1]
+	assert.True(t, p.Middleware >= 40*time.Millisecond)
+	assert.True(t, p.Main < p.Middleware)
+}
+
+func TestLabeledStack(t *testing.T) {
+	started := make(chan struct{})
+	done := make(chan struct{})
+	defer close(done)
+	go pprof.Do(context.Background(), pprof.Labels(profilerLabel, "7"), func(context.Context) {
+		close(started)
+		<-done
+	})
+	<-started
+	assert.Contains(t, string(labeledStack("7", 1<<20)), "TestLabeledStack")
+	// 标签值需要完全匹配
+	assert.Nil(t, labeledStack("77", 1<<20))
+	assert.Nil(t, labeledStack("", 1<<20))
+}
+
+// BenchmarkProfiler measures what the profiler costs requests faster than
+// the threshold.
+func BenchmarkProfiler(b *testing.B) {
+	plain := doris.New()
+	plain.GET("/", func(c *doris.Context) error { return nil })
+	profiled := doris.New()
+	profiled.Use(Profiler(time.Minute))
+	profiled.GET("/", func(c *doris.Context) error { return nil })
+	for name, d := range map[string]*doris.Doris{"Off": plain, "On": profiled} {
+		b.Run(name, func(b *testing.B) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				d.ServeHTTP(&benchmarkWriter{header: http.Header{}}, req)
+			}
+		})
+	}
+}
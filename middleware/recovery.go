@@ -1,77 +1,167 @@
 package middleware
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
-	"io/ioutil"
-	"net"
+	"net/http"
 	"net/http/httputil"
 	"os"
 	"runtime"
 	"strings"
+	"syscall"
 
 	"github.com/leaderwolfpipi/doris"
 )
 
-// 全局变量定义
+type (
+	// RecoveryConfig defines the config for Recovery middleware.
+	RecoveryConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// StackSize is the size of the stack to be printed.
+		// Optional. Default value 4 KB.
+		StackSize int
+
+		// DisableStackAll disables formatting stack traces of all other
+		// goroutines into the stack.
+		// Optional. Default value false.
+		DisableStackAll bool
+
+		// DisablePrintStack disables printing the panic and the stack.
+		// Optional. Default value false.
+		DisablePrintStack bool
+
+		// ReportFunc is called with the recovered value and the stack before
+		// the response is sent, to report the panic to an error tracker such
+		// as Sentry (see the dorissentry package). Panics caused by a broken
+		// connection are not reported.
+		// Optional. Default value nil.
+		ReportFunc RecoveryReportFunc
+
+		// ProblemJSON sends the 500 response as an RFC 7807
+		// application/problem+json body, with the request ID as instance.
+		// It takes precedence over the debug page.
+		// Optional. Default value false.
+		ProblemJSON bool
+
+		// ProblemType is the type URI of the problem+json response.
+		// Optional. Default value "about:blank".
+		ProblemType string
+
+		// PanicHandler is called with the recovered value and the stack
+		// and is responsible for the response. When it writes nothing a
+		// 500 response is sent.
+		// Optional. Default value nil.
+		PanicHandler RecoveryHandler
+	}
+
+	// RecoveryHandler handles a recovered panic.
+	RecoveryHandler func(c *doris.Context, err interface{}, stack []byte)
+
+	// RecoveryReportFunc reports a recovered panic.
+	RecoveryReportFunc func(c *doris.Context, err interface{}, stack []byte)
+)
+
 var (
-	dunno     = []byte("???")
-	centerDot = []byte("·")
-	dot       = []byte(".")
-	slash     = []byte("/")
+	// DefaultRecoveryConfig is the default Recovery middleware config.
+	DefaultRecoveryConfig = RecoveryConfig{
+		Skipper:           DefaultSkipper,
+		StackSize:         4 << 10, // 4 KB
+		DisableStackAll:   false,
+		DisablePrintStack: false,
+	}
 )
 
-// 异常捕获中间件
+// Recovery returns a middleware which recovers from panics anywhere in the
+// chain and sends a 500 response. In debug mode the response is a page with
+// the panic, the stack and the request, and the logged panic includes the
+// request headers.
 func Recovery() doris.HandlerFunc {
+	return RecoveryWithConfig(DefaultRecoveryConfig)
+}
+
+// RecoveryWithConfig returns a Recovery middleware with config.
+// See: `Recovery()`.
+func RecoveryWithConfig(config RecoveryConfig) doris.HandlerFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultRecoveryConfig.Skipper
+	}
+	if config.StackSize <= 0 {
+		config.StackSize = DefaultRecoveryConfig.StackSize
+	}
+
 	return func(c *doris.Context) error {
+		if config.Skipper(c) {
+			c.Next()
+			return nil
+		}
+
 		defer func() {
 			// recover捕获panic异常
-			if err := recover(); err != nil {
-				// 判断网络连接是否断开
-				var brokenPipe bool
-				if ne, ok := err.(*net.OpError); ok {
-					if se, ok := ne.Err.(*os.SyscallError); ok {
-						if strings.Contains(strings.ToLower(se.Error()), "broken pipe") || strings.Contains(strings.ToLower(se.Error()), "connection reset by peer") {
-							brokenPipe = true
-						}
-					}
-				}
+			err := recover()
+			if err == nil {
+				return
+			}
+			// 终止执行
+			c.Abort()
 
-				// 打印请求头信息和调用栈
-				httpRequest, _ := httputil.DumpRequest(c.Request, false)
-				headers := strings.Split(string(httpRequest), "\r\n")
-				// 解析Authorization头
-				for idx, header := range headers {
-					current := strings.Split(header, ":")
-					if current[0] == "Authorization" {
-						headers[idx] = current[0] + ": *"
-					}
-				}
+			// 客户端连接已断开：响应无法送达，不再写入500响应
+			// 这类异常并非程序错误，仅以warn级别记录
+			if isBrokenPipe(err) {
+				c.Error(fmt.Errorf("%v", err))
+				c.Doris.Logger.Warn("[BROKEN PIPE] "+fmt.Sprint(err),
+					doris.F("request_id", c.RequestID()),
+					doris.F("method", c.Request.Method),
+					doris.F("path", c.Request.URL.Path),
+				)
+				return
+			}
 
-				// 组织日志信息
-				if brokenPipe {
-					// 网络断开
-					// 修改响应信息为：请求头 + 捕获异常
-					doris.HTTPErrorMessages[500] = errors.New(fmt.Sprint(err) + string(httpRequest))
-					// 终止执行
-					c.Abort()
-				} else if c.Doris.Debug {
-					// 调试模式
-					// 获取stack信息[]byte
-					stack := stack(3)
-					// 修改响应信息为：捕获异常 + 函数调用链
-					// 调用栈颜色配置：[\033[0;35m%s\033[0m]
-					fmt.Printf("\n[\033[0;35m\n%s\n\n%s\033[0m]\n\n", strings.Join(headers, "\r\n"), string(stack))
-					doris.HTTPErrorMessages[500] = errors.New(fmt.Sprint(err))
-				} else {
-					// 其他情况
-					// 修改响应信息为：捕获异常
-					doris.HTTPErrorMessages[500] = errors.New(fmt.Sprint(err))
+			// 获取调用栈
+			stack := make([]byte, config.StackSize)
+			stack = stack[:runtime.Stack(stack, !config.DisableStackAll)]
+
+			// 记录错误信息为：捕获异常
+			c.Error(fmt.Errorf("%v", err))
+			if !config.DisablePrintStack {
+				fields := []doris.Field{doris.F("request_id", c.RequestID()), doris.F("stack", string(stack))}
+				if c.Doris.Debug {
+					// 调试模式下附带请求头信息，隐藏Authorization头
+					httpRequest, _ := httputil.DumpRequest(c.Request, false)
+					headers := strings.Split(string(httpRequest), "\r\n")
+					for idx, header := range headers {
+						current := strings.Split(header, ":")
+						if current[0] == "Authorization" {
+							headers[idx] = current[0] + ": *"
+						}
+					}
+					fields = append(fields, doris.F("request", strings.Join(headers, "\r\n")))
 				}
+				c.Doris.Logger.Error("[PANIC RECOVER] "+fmt.Sprint(err), fields...)
+			}
 
-				// 修改响应码为500
-				c.Response.WriteHeader(500)
+			if config.ReportFunc != nil {
+				config.ReportFunc(c, err, stack)
+			}
+			if config.PanicHandler != nil {
+				config.PanicHandler(c, err, stack)
+			}
+			// 修改响应码为500
+			if !c.Response.Written() && config.ProblemJSON {
+				// problem+json响应与是否调试模式无关
+				c.Problem(&doris.Problem{
+					Type:     config.ProblemType,
+					Status:   http.StatusInternalServerError,
+					Instance: c.RequestID(),
+				})
+			} else if !c.Response.Written() && c.Doris.Debug {
+				// 调试模式下输出包含调用栈和请求信息的错误页
+				renderPanicPage(c, err, stack)
+			} else if !c.Response.Written() {
+				c.ErrorJson(http.StatusInternalServerError, http.StatusInternalServerError,
+					doris.StatusMessage(http.StatusInternalServerError))
 			}
 		}()
 		c.Next()
@@ -79,64 +169,20 @@ func Recovery() doris.HandlerFunc {
 	}
 }
 
-// 从调用栈的第几段开始返回：借鉴gin框架
-// 以一种相对有好的方式返回从指定的栈位开始的栈信息
-func stack(skip int) []byte {
-	buf := new(bytes.Buffer) // the returned data
-	// As we loop, we open files and read them. These variables record the currently
-	// loaded file.
-	var lines [][]byte
-	var lastFile string
-	for i := skip; ; i++ { // Skip the expected number of frames
-		pc, file, line, ok := runtime.Caller(i)
-		if !ok {
-			break
-		}
-		// Print this much at least.  If we can't find the source, it won't show.
-		fmt.Fprintf(buf, "%s:%d (0x%x)\n", file, line, pc)
-		if file != lastFile {
-			data, err := ioutil.ReadFile(file)
-			if err != nil {
-				continue
-			}
-			lines = bytes.Split(data, []byte{'\n'})
-			lastFile = file
-		}
-		fmt.Fprintf(buf, "\t%s: %s\n", function(pc), source(lines, line))
-	}
-	return buf.Bytes()
-}
-
-// 返回第n行被裁减之后的切片
-func source(lines [][]byte, n int) []byte {
-	n-- // in stack trace, lines are 1-indexed but our array is 0-indexed
-	if n < 0 || n >= len(lines) {
-		return dunno
-	}
-	return bytes.TrimSpace(lines[n])
-}
-
-// 返回包含（持有）PC的函数名
-func function(pc uintptr) []byte {
-	fn := runtime.FuncForPC(pc)
-	if fn == nil {
-		return dunno
+// isBrokenPipe reports whether the panic was caused by the client closing
+// the connection ("broken pipe" or "connection reset by peer").
+func isBrokenPipe(err interface{}) bool {
+	e, ok := err.(error)
+	if !ok {
+		return false
 	}
-	name := []byte(fn.Name())
-	// The name includes the path name to the package, which is unnecessary
-	// since the file name is already included.  Plus, it has center dots.
-	// That is, we see
-	//	runtime/debug.*T·ptrmethod
-	// and want
-	//	*T.ptrmethod
-	// Also the package path might contains dot (e.g. code.google.com/...),
-	// so first eliminate the path prefix
-	if lastSlash := bytes.LastIndex(name, slash); lastSlash >= 0 {
-		name = name[lastSlash+1:]
+	if errors.Is(e, syscall.EPIPE) || errors.Is(e, syscall.ECONNRESET) {
+		return true
 	}
-	if period := bytes.Index(name, dot); period >= 0 {
-		name = name[period+1:]
+	var se *os.SyscallError
+	if errors.As(e, &se) {
+		msg := strings.ToLower(se.Error())
+		return strings.Contains(msg, "broken pipe") || strings.Contains(msg, "connection reset by peer")
 	}
-	name = bytes.Replace(name, centerDot, dot, -1)
-	return name
+	return false
 }
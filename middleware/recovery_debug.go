@@ -0,0 +1,176 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/leaderwolfpipi/doris"
+)
+
+type (
+	// panicPage is the data of the debug panic page.
+	panicPage struct {
+		Error     string
+		Method    string
+		URL       string
+		Route     string
+		RequestID string
+		Stack     []stackLine
+		Headers   [][2]string
+		Params    [][2]string
+		Query     [][2]string
+	}
+
+	// stackLine is a line of the stack with its kind used for coloring.
+	stackLine struct {
+		Kind string // goroutine, func or file
+		Text string
+	}
+)
+
+// panicPageTemplate renders the debug panic page.
+var panicPageTemplate = template.Must(template.New("panic").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>panic: {{.Error}}</title>
+<style>
+body { font-family: -apple-system, Helvetica, Arial, sans-serif; margin: 0; color: #222; }
+header { background: #c0392b; color: #fff; padding: 16px 24px; }
+header h1 { margin: 0 0 4px; font-size: 20px; word-break: break-all; }
+section { padding: 8px 24px; }
+h2 { font-size: 16px; border-bottom: 1px solid #ddd; padding-bottom: 4px; }
+pre { background: #272822; color: #f8f8f2; padding: 12px; overflow-x: auto; font-size: 13px; }
+.goroutine { color: #fd971f; }
+.func { color: #a6e22e; }
+.file { color: #75715e; }
+table { border-collapse: collapse; font-size: 13px; }
+td { border: 1px solid #ddd; padding: 4px 8px; font-family: monospace; vertical-align: top; }
+td:first-child { font-weight: bold; white-space: nowrap; }
+</style>
+</head>
+<body>
+<header>
+<h1>panic: {{.Error}}</h1>
+<div>{{.Method}} {{.URL}}{{if .Route}} &middot; route {{.Route}}{{end}}{{if .RequestID}} &middot; request {{.RequestID}}{{end}}</div>
+</header>
+<section>
+<h2>Stack</h2>
+<pre>{{range .Stack}}<span class="{{.Kind}}">{{.Text}}</span>
+{{end}}</pre>
+</section>
+{{if .Params}}<section>
+<h2>Route params</h2>
+<table>{{range .Params}}<tr><td>{{index . 0}}</td><td>{{index . 1}}</td></tr>{{end}}</table>
+</section>{{end}}
+{{if .Query}}<section>
+<h2>Query</h2>
+<table>{{range .Query}}<tr><td>{{index . 0}}</td><td>{{index . 1}}</td></tr>{{end}}</table>
+</section>{{end}}
+<section>
+<h2>Headers</h2>
+<table>{{range .Headers}}<tr><td>{{index . 0}}</td><td>{{index . 1}}</td></tr>{{end}}</table>
+</section>
+</body>
+</html>
+`))
+
+// maskedHeaders lists the headers hidden on the debug page.
+var maskedHeaders = []string{doris.HeaderAuthorization, "Cookie", "Proxy-Authorization"}
+
+// renderPanicPage sends the debug panic page, as HTML to browsers and as
+// plain text otherwise.
+func renderPanicPage(c *doris.Context, err interface{}, stack []byte) {
+	page := newPanicPage(c, err, stack)
+	if strings.Contains(c.Request.Header.Get("Accept"), "text/html") {
+		buf := new(bytes.Buffer)
+		if e := panicPageTemplate.Execute(buf, page); e == nil {
+			c.Html(http.StatusInternalServerError, buf.String())
+			return
+		}
+	}
+	c.String(http.StatusInternalServerError, "%s", page.text())
+}
+
+// newPanicPage collects the data of the debug panic page.
+func newPanicPage(c *doris.Context, err interface{}, stack []byte) *panicPage {
+	page := &panicPage{
+		Error:     fmt.Sprint(err),
+		Method:    c.Request.Method,
+		URL:       c.Request.URL.String(),
+		Route:     c.FullPath(),
+		RequestID: c.RequestID(),
+	}
+
+	// 调用栈按行分类用于着色
+	for _, line := range strings.Split(strings.TrimRight(string(stack), "\n"), "\n") {
+		kind := "func"
+		switch {
+		case strings.HasPrefix(line, "goroutine "):
+			kind = "goroutine"
+		case strings.HasPrefix(line, "\t"):
+			kind = "file"
+		}
+		page.Stack = append(page.Stack, stackLine{Kind: kind, Text: line})
+	}
+
+	for name, values := range c.Request.Header {
+		value := strings.Join(values, ", ")
+		if doris.InSlice(http.CanonicalHeaderKey(name), maskedHeaders) {
+			value = "*"
+		}
+		page.Headers = append(page.Headers, [2]string{name, value})
+	}
+	for _, name := range c.ParamNames() {
+		page.Params = append(page.Params, [2]string{name, fmt.Sprint(c.Param(name))})
+	}
+	for name, values := range c.Request.URL.Query() {
+		page.Query = append(page.Query, [2]string{name, strings.Join(values, ", ")})
+	}
+	sortPairs(page.Headers)
+	sortPairs(page.Query)
+	return page
+}
+
+// text renders the page as plain text.
+func (page *panicPage) text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "panic: %s\n\n%s %s\n", page.Error, page.Method, page.URL)
+	if page.Route != "" {
+		fmt.Fprintf(&b, "route: %s\n", page.Route)
+	}
+	if page.RequestID != "" {
+		fmt.Fprintf(&b, "request id: %s\n", page.RequestID)
+	}
+	b.WriteString("\nStack:\n")
+	for _, line := range page.Stack {
+		b.WriteString(line.Text)
+		b.WriteByte('\n')
+	}
+	writePairs(&b, "Route params", page.Params)
+	writePairs(&b, "Query", page.Query)
+	writePairs(&b, "Headers", page.Headers)
+	return b.String()
+}
+
+// writePairs writes a titled list of name/value pairs.
+func writePairs(b *strings.Builder, title string, pairs [][2]string) {
+	if len(pairs) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n%s:\n", title)
+	for _, p := range pairs {
+		fmt.Fprintf(b, "  %s: %s\n", p[0], p[1])
+	}
+}
+
+// sortPairs sorts the pairs by name.
+func sortPairs(pairs [][2]string) {
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i][0] < pairs[j][0]
+	})
+}
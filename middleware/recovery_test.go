@@ -0,0 +1,190 @@
+// recovery test file
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/leaderwolfpipi/doris"
+	"github.com/stretchr/testify/assert"
+)
+
+func servePanic(mw doris.HandlerFunc) *httptest.ResponseRecorder {
+	d := doris.New()
+	d.GET("/", mw, func(c *doris.Context) error {
+		panic("test panic")
+	})
+	res := httptest.NewRecorder()
+	d.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+	return res
+}
+
+func TestRecovery(t *testing.T) {
+	res := servePanic(RecoveryWithConfig(RecoveryConfig{DisablePrintStack: true}))
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+	assert.True(t, strings.Contains(res.Body.String(), "Internal server error"))
+}
+
+func TestRecoveryPanicHandler(t *testing.T) {
+	var recovered interface{}
+	var trace []byte
+	res := servePanic(RecoveryWithConfig(RecoveryConfig{
+		StackSize:         512,
+		DisableStackAll:   true,
+		DisablePrintStack: true,
+		PanicHandler: func(c *doris.Context, err interface{}, stack []byte) {
+			recovered, trace = err, stack
+			c.String(http.StatusServiceUnavailable, "try later")
+		},
+	}))
+	assert.Equal(t, "test panic", recovered)
+	assert.True(t, len(trace) > 0 && len(trace) <= 512)
+	assert.True(t, strings.HasPrefix(string(trace), "goroutine "))
+	assert.Equal(t, http.StatusServiceUnavailable, res.Code)
+	assert.Equal(t, "try later", res.Body.String())
+}
+
+func TestRecoverySkipper(t *testing.T) {
+	mw := RecoveryWithConfig(RecoveryConfig{Skipper: func(c *doris.Context) bool { return true }})
+	assert.Panics(t, func() { servePanic(mw) })
+}
+
+func TestRecoveryReportFunc(t *testing.T) {
+	reported := []interface{}{}
+	route := ""
+	res := servePanic(RecoveryWithConfig(RecoveryConfig{
+		DisablePrintStack: true,
+		ReportFunc: func(c *doris.Context, err interface{}, stack []byte) {
+			reported = append(reported, err)
+			route = c.FullPath()
+		},
+	}))
+	assert.Equal(t, []interface{}{"test panic"}, reported)
+	assert.Equal(t, "/", route)
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+}
+
+func serveDebugPanic(accept string) *httptest.ResponseRecorder {
+	d := doris.New()
+	d.Debug = true
+	d.GET("/users/:id", RecoveryWithConfig(RecoveryConfig{DisablePrintStack: true}), func(c *doris.Context) error {
+		panic("<boom>")
+	})
+	req := httptest.NewRequest(http.MethodGet, "/users/42?tab=orders", nil)
+	req.Header.Set("Accept", accept)
+	req.Header.Set(doris.HeaderAuthorization, "Bearer secret-token")
+	res := httptest.NewRecorder()
+	d.ServeHTTP(res, req)
+	return res
+}
+
+func TestRecoveryDebugPage(t *testing.T) {
+	res := serveDebugPanic("text/html,application/xhtml+xml")
+	body := res.Body.String()
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+	assert.True(t, strings.HasPrefix(res.Header().Get(doris.HeaderContentType), "text/html"))
+	assert.True(t, strings.Contains(body, "panic: &lt;boom&gt;"))
+	assert.True(t, strings.Contains(body, `<span class="goroutine">goroutine `))
+	assert.True(t, strings.Contains(body, "<td>id</td><td>42</td>"))
+	assert.True(t, strings.Contains(body, "<td>tab</td><td>orders</td>"))
+	assert.False(t, strings.Contains(body, "secret-token"))
+
+	res = serveDebugPanic("*/*")
+	body = res.Body.String()
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+	assert.True(t, strings.HasPrefix(body, "panic: <boom>\n\nGET /users/42?tab=orders\nroute: /users/:id\n"))
+	assert.True(t, strings.Contains(body, "\nRoute params:\n  id: 42\n"))
+	assert.True(t, strings.Contains(body, "  Authorization: *\n"))
+}
+
+func TestRecoveryBrokenPipe(t *testing.T) {
+	reported := false
+	d := doris.New()
+	d.GET("/", RecoveryWithConfig(RecoveryConfig{
+		ReportFunc: func(c *doris.Context, err interface{}, stack []byte) {
+			reported = true
+		},
+	}), func(c *doris.Context) error {
+		panic(&net.OpError{Op: "write", Net: "tcp", Err: os.NewSyscallError("write", syscall.EPIPE)})
+	})
+	res := httptest.NewRecorder()
+	d.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.False(t, reported)
+	assert.Equal(t, "", res.Body.String())
+}
+
+func TestIsBrokenPipe(t *testing.T) {
+	assert.True(t, isBrokenPipe(&net.OpError{Op: "write", Err: os.NewSyscallError("write", syscall.ECONNRESET)}))
+	assert.True(t, isBrokenPipe(fmt.Errorf("flush: %w", syscall.EPIPE)))
+	assert.False(t, isBrokenPipe(errors.New("broken")))
+	assert.False(t, isBrokenPipe("broken pipe"))
+}
+
+// panicLogger records the messages and fields logged at Error level.
+type panicLogger struct {
+	doris.Logger
+	messages []string
+	fields   map[string]interface{}
+}
+
+func (l *panicLogger) Error(msg string, fields ...doris.Field) {
+	l.messages = append(l.messages, msg)
+	l.fields = make(map[string]interface{})
+	for _, f := range fields {
+		l.fields[f.Key] = f.Value
+	}
+}
+
+func TestRecoveryDebugLog(t *testing.T) {
+	for _, debug := range []bool{false, true} {
+		logger := &panicLogger{}
+		d := doris.New()
+		d.Debug = debug
+		d.Logger = logger
+		d.GET("/", Recovery(), func(c *doris.Context) error {
+			panic("test panic")
+		})
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(doris.HeaderAuthorization, "Bearer secret-token")
+		d.ServeHTTP(httptest.NewRecorder(), req)
+
+		assert.Equal(t, []string{"[PANIC RECOVER] test panic"}, logger.messages)
+		assert.True(t, strings.HasPrefix(logger.fields["stack"].(string), "goroutine "))
+		request, ok := logger.fields["request"].(string)
+		assert.Equal(t, debug, ok)
+		if debug {
+			assert.True(t, strings.Contains(request, "Authorization: *"))
+			assert.False(t, strings.Contains(request, "secret-token"))
+		}
+	}
+}
+
+func TestRecoveryProblemJSON(t *testing.T) {
+	// the problem+json body does not depend on debug mode
+	for _, debug := range []bool{false, true} {
+		d := doris.New()
+		d.Debug = debug
+		d.GET("/", RequestID(), RecoveryWithConfig(RecoveryConfig{
+			DisablePrintStack: true,
+			ProblemJSON:       true,
+			ProblemType:       "https://errors.example.com/internal",
+		}), func(c *doris.Context) error {
+			panic("test panic")
+		})
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(doris.HeaderXRequestID, "req-1")
+		res := httptest.NewRecorder()
+		d.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusInternalServerError, res.Code)
+		assert.Equal(t, doris.MIMEApplicationProblemJSON, res.Header().Get(doris.HeaderContentType))
+		assert.JSONEq(t, `{"type":"https://errors.example.com/internal","title":"Internal server error","status":500,"instance":"req-1"}`, res.Body.String())
+	}
+}
@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"time"
+
+	"github.com/leaderwolfpipi/doris"
+)
+
+type (
+	// RequestIDConfig defines the config for RequestID middleware.
+	RequestIDConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Generator defines a function to generate an ID.
+		// Optional. Default value GenerateUUID.
+		Generator func() string
+
+		// RequestIDHandler is called with the request ID once it is set.
+		// Optional. Default value nil.
+		RequestIDHandler func(c *doris.Context, id string)
+
+		// TargetHeader is the header the ID is read from and written to.
+		// Optional. Default value "X-Request-Id".
+		TargetHeader string
+
+		// MaxLength is the maximum length of an incoming ID. Longer IDs or
+		// IDs with non printable characters are replaced by a generated
+		// one, so clients cannot inject content into the logs.
+		// Optional. Default value 128.
+		MaxLength int
+	}
+)
+
+var (
+	// DefaultRequestIDConfig is the default RequestID middleware config.
+	DefaultRequestIDConfig = RequestIDConfig{
+		Skipper:      DefaultSkipper,
+		Generator:    GenerateUUID,
+		TargetHeader: doris.HeaderXRequestID,
+		MaxLength:    128,
+	}
+)
+
+// Crockford's base32 alphabet used by ULIDs.
+const ulidAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// RequestID returns a RequestID middleware.
+//
+// The ID is read from the X-Request-Id header or generated, stored in the
+// context (see Context.RequestID) and set on the response.
+func RequestID() doris.HandlerFunc {
+	return RequestIDWithConfig(DefaultRequestIDConfig)
+}
+
+// RequestIDWithConfig returns a RequestID middleware with config.
+// See: `RequestID()`.
+func RequestIDWithConfig(config RequestIDConfig) doris.HandlerFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultRequestIDConfig.Skipper
+	}
+	if config.Generator == nil {
+		config.Generator = DefaultRequestIDConfig.Generator
+	}
+	if config.TargetHeader == "" {
+		config.TargetHeader = DefaultRequestIDConfig.TargetHeader
+	}
+	if config.MaxLength <= 0 {
+		config.MaxLength = DefaultRequestIDConfig.MaxLength
+	}
+
+	return func(c *doris.Context) error {
+		if config.Skipper(c) {
+			c.Next()
+			return nil
+		}
+
+		rid := c.Request.Header.Get(config.TargetHeader)
+		if !validRequestID(rid, config.MaxLength) {
+			rid = config.Generator()
+		}
+		c.SetRequestID(rid)
+		c.Response.Header().Set(config.TargetHeader, rid)
+		if config.RequestIDHandler != nil {
+			config.RequestIDHandler(c, rid)
+		}
+
+		c.Next()
+		return nil
+	}
+}
+
+// validRequestID reports whether the incoming ID can be reused.
+func validRequestID(id string, maxLength int) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// GenerateUUID returns a random (version 4) UUID.
+func GenerateUUID() string {
+	var u [16]byte
+	if _, err := rand.Read(u[:]); err != nil {
+		panic("doris: generate uuid: " + err.Error())
+	}
+	u[6] = (u[6] & 0x0f) | 0x40
+	u[8] = (u[8] & 0x3f) | 0x80
+
+	var buf [36]byte
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+	return string(buf[:])
+}
+
+// GenerateULID returns a ULID, a lexicographically sortable ID made of a
+// millisecond timestamp and 80 random bits.
+func GenerateULID() string {
+	var u [16]byte
+	ms := uint64(time.Now().UnixNano() / int64(time.Millisecond))
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], ms)
+	copy(u[:6], ts[2:])
+	if _, err := rand.Read(u[6:]); err != nil {
+		panic("doris: generate ulid: " + err.Error())
+	}
+
+	// 128位按5位一组编码为26个字符，首字符仅使用高3位
+	var buf [26]byte
+	var acc uint64
+	bits := uint(2)
+	pos := 0
+	for _, b := range u {
+		acc = acc<<8 | uint64(b)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			buf[pos] = ulidAlphabet[(acc>>bits)&0x1f]
+			pos++
+		}
+	}
+	return string(buf[:])
+}
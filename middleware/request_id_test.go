@@ -0,0 +1,70 @@
+// request id test file
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/leaderwolfpipi/doris"
+	"github.com/stretchr/testify/assert"
+)
+
+func serveRequestID(mw doris.HandlerFunc, header, id string) (*httptest.ResponseRecorder, string) {
+	seen := ""
+	d := doris.New()
+	d.GET("/", mw, func(c *doris.Context) error {
+		seen = c.RequestID()
+		c.String(http.StatusOK, "ok")
+		return nil
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if id != "" {
+		req.Header.Set(header, id)
+	}
+	res := httptest.NewRecorder()
+	d.ServeHTTP(res, req)
+	return res, seen
+}
+
+func TestRequestID(t *testing.T) {
+	res, seen := serveRequestID(RequestID(), doris.HeaderXRequestID, "")
+	assert.True(t, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(seen))
+	assert.Equal(t, seen, res.Header().Get(doris.HeaderXRequestID))
+
+	res, seen = serveRequestID(RequestID(), doris.HeaderXRequestID, "upstream-1")
+	assert.Equal(t, "upstream-1", seen)
+	assert.Equal(t, "upstream-1", res.Header().Get(doris.HeaderXRequestID))
+
+	// 非法的请求ID会被替换
+	_, seen = serveRequestID(RequestID(), doris.HeaderXRequestID, "bad id\tinjected")
+	assert.NotEqual(t, "bad id\tinjected", seen)
+	_, seen = serveRequestID(RequestID(), doris.HeaderXRequestID, strings.Repeat("a", 200))
+	assert.Equal(t, 36, len(seen))
+}
+
+func TestRequestIDWithConfig(t *testing.T) {
+	handled := ""
+	mw := RequestIDWithConfig(RequestIDConfig{
+		Generator:    func() string { return "generated" },
+		TargetHeader: "X-Correlation-Id",
+		RequestIDHandler: func(c *doris.Context, id string) {
+			handled = id
+		},
+	})
+	res, seen := serveRequestID(mw, "X-Correlation-Id", "")
+	assert.Equal(t, "generated", seen)
+	assert.Equal(t, "generated", handled)
+	assert.Equal(t, "generated", res.Header().Get("X-Correlation-Id"))
+
+	_, seen = serveRequestID(mw, "X-Correlation-Id", "corr-7")
+	assert.Equal(t, "corr-7", seen)
+}
+
+func TestGenerateULID(t *testing.T) {
+	a := GenerateULID()
+	assert.True(t, regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`).MatchString(a))
+	assert.NotEqual(t, a, GenerateULID())
+}
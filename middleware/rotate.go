@@ -0,0 +1,176 @@
+package middleware
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat is the timestamp layout of rotated file names.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// RotateFile is an io.WriteCloser writing to a file which is rotated
+// when it reaches MaxSize or when Interval has elapsed. Rotated files
+// are renamed to <name>-<timestamp><ext> next to the current file.
+//
+// Any io.Writer can be used as the logger output, so writers such as
+// lumberjack.Logger may be used instead.
+type RotateFile struct {
+	// Filename is the file to write to.
+	// Required.
+	Filename string
+
+	// MaxSize is the maximum size in bytes of the file before it is rotated.
+	// Optional. Default value 0 (no size based rotation).
+	MaxSize int64
+
+	// Interval is the maximum age of the file before it is rotated.
+	// Optional. Default value 0 (no time based rotation).
+	Interval time.Duration
+
+	// MaxBackups is the maximum number of rotated files to retain.
+	// Optional. Default value 0 (retain all).
+	MaxBackups int
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+}
+
+// NewRotateFile returns a RotateFile rotated by size and interval.
+func NewRotateFile(filename string, maxSize int64, interval time.Duration) *RotateFile {
+	return &RotateFile{
+		Filename: filename,
+		MaxSize:  maxSize,
+		Interval: interval,
+	}
+}
+
+// Write implements io.Writer.
+func (r *RotateFile) Write(p []byte) (n int, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		if err = r.open(); err != nil {
+			return 0, err
+		}
+	}
+	if r.shouldRotate(int64(len(p))) {
+		if err = r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err = r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Rotate closes the current file and starts a new one.
+func (r *RotateFile) Rotate() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rotate()
+}
+
+// Close implements io.Closer.
+func (r *RotateFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.close()
+}
+
+// shouldRotate reports whether writing n bytes needs a new file.
+func (r *RotateFile) shouldRotate(n int64) bool {
+	if r.size == 0 {
+		return false
+	}
+	if r.MaxSize > 0 && r.size+n > r.MaxSize {
+		return true
+	}
+	return r.Interval > 0 && time.Since(r.openedAt) >= r.Interval
+}
+
+// open opens the file in append mode.
+func (r *RotateFile) open() error {
+	if dir := filepath.Dir(r.Filename); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(r.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.file = f
+	r.size = info.Size()
+	r.openedAt = time.Now()
+	return nil
+}
+
+// close closes the current file.
+func (r *RotateFile) close() error {
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+// rotate renames the current file and opens a new one.
+func (r *RotateFile) rotate() error {
+	if err := r.close(); err != nil {
+		return err
+	}
+	if _, err := os.Stat(r.Filename); err == nil {
+		if err = os.Rename(r.Filename, r.backupName(time.Now())); err != nil {
+			return err
+		}
+	}
+	if err := r.open(); err != nil {
+		return err
+	}
+	return r.removeBackups()
+}
+
+// backupName returns the name of the rotated file.
+func (r *RotateFile) backupName(t time.Time) string {
+	ext := filepath.Ext(r.Filename)
+	prefix := strings.TrimSuffix(r.Filename, ext)
+	return prefix + "-" + t.Format(backupTimeFormat) + ext
+}
+
+// removeBackups removes the oldest rotated files over MaxBackups.
+func (r *RotateFile) removeBackups() error {
+	if r.MaxBackups <= 0 {
+		return nil
+	}
+	ext := filepath.Ext(r.Filename)
+	prefix := strings.TrimSuffix(r.Filename, ext)
+	backups, err := filepath.Glob(prefix + "-*" + ext)
+	if err != nil {
+		return err
+	}
+	if len(backups) <= r.MaxBackups {
+		return nil
+	}
+	// 时间戳格式保证按名称排序即按时间排序
+	sort.Strings(backups)
+	for _, name := range backups[:len(backups)-r.MaxBackups] {
+		if err := os.Remove(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+// rotate test file
+package middleware
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRotateFileSize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "doris-rotate")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "logs", "access.log")
+	r := NewRotateFile(name, 10, 0)
+	defer r.Close()
+
+	_, err = r.Write([]byte("0123456\n"))
+	assert.Nil(t, err)
+	_, err = r.Write([]byte("789\n"))
+	assert.Nil(t, err)
+
+	data, err := ioutil.ReadFile(name)
+	assert.Nil(t, err)
+	assert.Equal(t, "789\n", string(data))
+
+	backups, _ := filepath.Glob(filepath.Join(dir, "logs", "access-*.log"))
+	assert.Equal(t, 1, len(backups))
+	data, _ = ioutil.ReadFile(backups[0])
+	assert.Equal(t, "0123456\n", string(data))
+}
+
+func TestRotateFileMaxBackups(t *testing.T) {
+	dir, err := ioutil.TempDir("", "doris-rotate")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "access.log")
+	old := []string{"access-2020-01-01T00-00-00.000.log", "access-2020-01-02T00-00-00.000.log"}
+	for _, b := range old {
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, b), []byte("old\n"), 0644))
+	}
+
+	r := &RotateFile{Filename: name, MaxBackups: 2}
+	defer r.Close()
+	_, err = r.Write([]byte("line\n"))
+	assert.Nil(t, err)
+	assert.Nil(t, r.Rotate())
+
+	backups, _ := filepath.Glob(filepath.Join(dir, "access-*.log"))
+	assert.Equal(t, 2, len(backups))
+	_, err = os.Stat(filepath.Join(dir, old[0]))
+	assert.True(t, os.IsNotExist(err))
+}
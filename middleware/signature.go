@@ -0,0 +1,264 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leaderwolfpipi/doris"
+)
+
+type (
+	// SignatureConfig defines the config for HMAC request signature middleware.
+	SignatureConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Secret is the shared HMAC secret.
+		// Required. This or SecretLookup.
+		Secret []byte
+
+		// SecretLookup returns the secret of the key id sent in KeyIDHeader,
+		// to use a secret per partner.
+		// Required. This or Secret.
+		SecretLookup func(keyID string, c *doris.Context) ([]byte, error)
+
+		// Hash is the hash function of the HMAC.
+		// Optional. Default value sha256.New.
+		Hash func() hash.Hash
+
+		// SignatureHeader is the header holding the hex encoded signature.
+		// An "<algorithm>=" prefix such as "sha256=" is accepted.
+		// Optional. Default value "X-Signature".
+		SignatureHeader string
+
+		// TimestampHeader is the header holding the unix time of the request.
+		// Optional. Default value "X-Timestamp".
+		TimestampHeader string
+
+		// NonceHeader is the header holding a unique value of the request.
+		// Nonces are only checked when NonceStore is set.
+		// Optional. Default value "X-Nonce".
+		NonceHeader string
+
+		// KeyIDHeader is the header holding the key id passed to SecretLookup.
+		// Optional. Default value "X-Key-Id".
+		KeyIDHeader string
+
+		// Tolerance is the accepted difference between the request timestamp
+		// and the server time.
+		// Optional. Default value 5 minutes.
+		Tolerance time.Duration
+
+		// NonceStore records used nonces for replay protection within the
+		// tolerance window. Requests without nonce are rejected when set.
+		// Optional.
+		NonceStore NonceStore
+
+		// Canonicalize returns the signed string of the request.
+		// Optional. Default value SignatureCanonical.
+		Canonicalize func(c *doris.Context, timestamp, nonce string, body []byte) string
+	}
+
+	// NonceStore records the nonces of signed requests.
+	NonceStore interface {
+		// Add records the nonce for ttl. It returns false when the nonce
+		// was already recorded.
+		Add(nonce string, ttl time.Duration) (bool, error)
+	}
+
+	// MemoryNonceStore is an in-memory `NonceStore`.
+	MemoryNonceStore struct {
+		lock   sync.Mutex
+		nonces map[string]time.Time
+		sweep  sweepSchedule
+	}
+)
+
+var (
+	// DefaultSignatureConfig is the default signature middleware config.
+	DefaultSignatureConfig = SignatureConfig{
+		Skipper:         DefaultSkipper,
+		Hash:            sha256.New,
+		SignatureHeader: "X-Signature",
+		TimestampHeader: "X-Timestamp",
+		NonceHeader:     "X-Nonce",
+		KeyIDHeader:     "X-Key-Id",
+		Tolerance:       5 * time.Minute,
+	}
+)
+
+// NewMemoryNonceStore returns an in-memory nonce store.
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{nonces: make(map[string]time.Time)}
+}
+
+// Add implements `NonceStore`.
+func (s *MemoryNonceStore) Add(nonce string, ttl time.Duration) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	now := time.Now()
+	if expiresAt, ok := s.nonces[nonce]; ok && !now.After(expiresAt) {
+		return false, nil
+	}
+	if s.sweep.due(len(s.nonces)) {
+		for n, expiresAt := range s.nonces {
+			if now.After(expiresAt) {
+				delete(s.nonces, n)
+			}
+		}
+	}
+	s.nonces[nonce] = now.Add(ttl)
+	return true, nil
+}
+
+// Signature returns a HMAC request signature middleware with the shared secret.
+//
+// Usage: d.POST("/webhooks", middleware.Signature(secret), handleWebhook)
+func Signature(secret []byte) doris.HandlerFunc {
+	c := DefaultSignatureConfig
+	c.Secret = secret
+	return SignatureWithConfig(c)
+}
+
+// SignatureWithConfig returns a HMAC request signature middleware with config.
+// See `Signature()`.
+func SignatureWithConfig(config SignatureConfig) doris.HandlerFunc {
+	// Defaults
+	if config.Secret == nil && config.SecretLookup == nil {
+		panic("doris: signature middleware requires a secret")
+	}
+	if config.Skipper == nil {
+		config.Skipper = DefaultSignatureConfig.Skipper
+	}
+	if config.Hash == nil {
+		config.Hash = DefaultSignatureConfig.Hash
+	}
+	if config.SignatureHeader == "" {
+		config.SignatureHeader = DefaultSignatureConfig.SignatureHeader
+	}
+	if config.TimestampHeader == "" {
+		config.TimestampHeader = DefaultSignatureConfig.TimestampHeader
+	}
+	if config.NonceHeader == "" {
+		config.NonceHeader = DefaultSignatureConfig.NonceHeader
+	}
+	if config.KeyIDHeader == "" {
+		config.KeyIDHeader = DefaultSignatureConfig.KeyIDHeader
+	}
+	if config.Tolerance == 0 {
+		config.Tolerance = DefaultSignatureConfig.Tolerance
+	}
+	if config.Canonicalize == nil {
+		config.Canonicalize = func(c *doris.Context, timestamp, nonce string, body []byte) string {
+			return SignatureCanonical(c.Request.Method, c.Request.URL.RequestURI(), timestamp, nonce, body)
+		}
+	}
+
+	return func(c *doris.Context) error {
+		if config.Skipper(c) {
+			c.Next()
+			return nil
+		}
+
+		if err := config.verify(c); err != nil {
+			c.ErrorJson(http.StatusUnauthorized, http.StatusUnauthorized, err.Error())
+			c.Abort()
+			return err
+		}
+
+		c.Next()
+		return nil
+	}
+}
+
+// verify checks the signature, the timestamp and the nonce of the request.
+func (config *SignatureConfig) verify(c *doris.Context) error {
+	header := c.Request.Header
+	signature := header.Get(config.SignatureHeader)
+	timestamp := header.Get(config.TimestampHeader)
+	nonce := header.Get(config.NonceHeader)
+	if signature == "" || timestamp == "" || (config.NonceStore != nil && nonce == "") {
+		return doris.SignatureMissingErr
+	}
+	if i := strings.IndexByte(signature, '='); i >= 0 {
+		signature = signature[i+1:]
+	}
+	mac, err := hex.DecodeString(signature)
+	if err != nil {
+		return doris.SignatureInvalidErr
+	}
+
+	// Check the timestamp window
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return doris.SignatureInvalidErr
+	}
+	if d := time.Since(time.Unix(ts, 0)); d > config.Tolerance || d < -config.Tolerance {
+		return doris.SignatureExpiredErr
+	}
+
+	secret := config.Secret
+	if config.SecretLookup != nil {
+		if secret, err = config.SecretLookup(header.Get(config.KeyIDHeader), c); err != nil || secret == nil {
+			return doris.SignatureInvalidErr
+		}
+	}
+	body, err := c.BodyBytes()
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal(mac, config.mac(secret, config.Canonicalize(c, timestamp, nonce, body))) {
+		return doris.SignatureInvalidErr
+	}
+
+	// Nonces are checked last so forged requests can't burn them
+	if config.NonceStore != nil {
+		ok, err := config.NonceStore.Add(nonce, 2*config.Tolerance)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return doris.SignatureReplayErr
+		}
+	}
+	return nil
+}
+
+// SignatureCanonical returns the default signed string of a request:
+// the method, the request uri, the timestamp, the nonce and the body
+// joined by newlines.
+func SignatureCanonical(method, requestURI, timestamp, nonce string, body []byte) string {
+	return strings.Join([]string{method, requestURI, timestamp, nonce, string(body)}, "\n")
+}
+
+// mac returns the HMAC of the canonical string built with config.Hash.
+func (config *SignatureConfig) mac(secret []byte, canonical string) []byte {
+	hash := config.Hash
+	if hash == nil {
+		hash = DefaultSignatureConfig.Hash
+	}
+	h := hmac.New(hash, secret)
+	h.Write([]byte(canonical))
+	return h.Sum(nil)
+}
+
+// Sign returns the hex encoded HMAC signature of the canonical string built
+// with config.Hash, for clients of a middleware created with config.
+func (config *SignatureConfig) Sign(secret []byte, canonical string) string {
+	return hex.EncodeToString(config.mac(secret, canonical))
+}
+
+// Sign returns the hex encoded HMAC-SHA256 signature of the canonical string,
+// for clients sending signed requests. Use `SignatureConfig.Sign` with a
+// non-default Hash.
+func Sign(secret []byte, canonical string) string {
+	config := SignatureConfig{Hash: sha256.New}
+	return config.Sign(secret, canonical)
+}
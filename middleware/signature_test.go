@@ -0,0 +1,125 @@
+// request signature test file
+package middleware
+
+import (
+	"crypto/sha512"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leaderwolfpipi/doris"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignature(t *testing.T) {
+	secret := []byte("secret")
+	serve := func(config SignatureConfig, req *http.Request) (int, string) {
+		var body string
+		d := doris.New()
+		d.POST("/hook", SignatureWithConfig(config), func(c *doris.Context) error {
+			b, _ := ioutil.ReadAll(c.Request.Body)
+			body = string(b)
+			c.String(http.StatusOK, "ok")
+			return nil
+		})
+		res := httptest.NewRecorder()
+		d.ServeHTTP(res, req)
+		return res.Code, body
+	}
+	signed := func(key []byte, ts time.Time, nonce, body string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/hook?a=1", strings.NewReader(body))
+		timestamp := strconv.FormatInt(ts.Unix(), 10)
+		req.Header.Set("X-Timestamp", timestamp)
+		req.Header.Set("X-Nonce", nonce)
+		req.Header.Set("X-Signature", "sha256="+Sign(key, SignatureCanonical(http.MethodPost, "/hook?a=1", timestamp, nonce, []byte(body))))
+		return req
+	}
+
+	config := SignatureConfig{Secret: secret, NonceStore: NewMemoryNonceStore()}
+	code, body := serve(config, signed(secret, time.Now(), "n1", `{"id":1}`))
+	assert.Equal(t, http.StatusOK, code)
+	// body is still readable by the handler
+	assert.Equal(t, `{"id":1}`, body)
+
+	// replay
+	code, _ = serve(config, signed(secret, time.Now(), "n1", `{"id":1}`))
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	// wrong secret, stale timestamp, tampered body, missing headers
+	code, _ = serve(config, signed([]byte("other"), time.Now(), "n2", `{"id":1}`))
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _ = serve(config, signed(secret, time.Now().Add(-time.Hour), "n3", `{"id":1}`))
+	assert.Equal(t, http.StatusUnauthorized, code)
+	req := signed(secret, time.Now(), "n4", `{"id":1}`)
+	req.Body = ioutil.NopCloser(strings.NewReader(`{"id":2}`))
+	code, _ = serve(config, req)
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _ = serve(config, httptest.NewRequest(http.MethodPost, "/hook", nil))
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	// per partner secrets
+	lookup := SignatureConfig{SecretLookup: func(keyID string, c *doris.Context) ([]byte, error) {
+		if keyID == "partner" {
+			return []byte("partner-secret"), nil
+		}
+		return nil, errors.New("unknown key")
+	}}
+	req = signed([]byte("partner-secret"), time.Now(), "", "")
+	req.Header.Set("X-Key-Id", "partner")
+	code, _ = serve(lookup, req)
+	assert.Equal(t, http.StatusOK, code)
+	req = signed([]byte("partner-secret"), time.Now(), "", "")
+	req.Header.Set("X-Key-Id", "unknown")
+	code, _ = serve(lookup, req)
+	assert.Equal(t, http.StatusUnauthorized, code)
+}
+
+func TestSignatureHash(t *testing.T) {
+	config := SignatureConfig{Secret: []byte("secret"), Hash: sha512.New}
+	d := doris.New()
+	d.POST("/hook", SignatureWithConfig(config), func(c *doris.Context) error {
+		c.String(http.StatusOK, "ok")
+		return nil
+	})
+	serve := func(signature string) int {
+		req := httptest.NewRequest(http.MethodPost, "/hook", strings.NewReader("{}"))
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set("X-Timestamp", timestamp)
+		canonical := SignatureCanonical(http.MethodPost, "/hook", timestamp, "", []byte("{}"))
+		if signature == "sha512" {
+			req.Header.Set("X-Signature", "sha512="+config.Sign(config.Secret, canonical))
+		} else {
+			req.Header.Set("X-Signature", "sha256="+Sign(config.Secret, canonical))
+		}
+		res := httptest.NewRecorder()
+		d.ServeHTTP(res, req)
+		return res.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve("sha512"))
+	assert.Equal(t, http.StatusUnauthorized, serve("sha256"))
+}
+
+func TestMemoryNonceStore(t *testing.T) {
+	store := NewMemoryNonceStore()
+	ok, _ := store.Add("n1", time.Hour)
+	assert.True(t, ok)
+	ok, _ = store.Add("n1", time.Hour)
+	assert.False(t, ok)
+
+	// expired nonces may be reused before they are swept
+	ok, _ = store.Add("n2", -time.Second)
+	assert.True(t, ok)
+	ok, _ = store.Add("n2", time.Hour)
+	assert.True(t, ok)
+
+	for i := 0; i < 100; i++ {
+		store.Add(strconv.Itoa(i), -time.Second)
+	}
+	assert.True(t, len(store.nonces) < 100)
+}
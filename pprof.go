@@ -0,0 +1,31 @@
+// 性能分析接口
+package doris
+
+import "net/http/pprof"
+
+// pprof接口的默认路径前缀
+const DefaultPprofPrefix = "/debug/pprof"
+
+// 通过doris路由挂载net/http/pprof的性能分析接口，无需单独启动http服务
+// prefix为空时使用/debug/pprof，handlers为接口的中间件，生产环境应使用BasicAuth等进行保护：
+//
+//	d.EnablePprof("", middleware.BasicAuth(validator))
+//
+// 返回接口所在的路由组
+func (doris *Doris) EnablePprof(prefix string, handlers ...HandlerFunc) *RouteGroup {
+	if prefix == "" {
+		prefix = DefaultPprofPrefix
+	}
+	group := doris.Group(prefix, handlers...)
+	group.GET("/", WrapF(pprof.Index))
+	group.GET("/cmdline", WrapF(pprof.Cmdline))
+	group.GET("/profile", WrapF(pprof.Profile))
+	group.GET("/symbol", WrapF(pprof.Symbol))
+	group.POST("/symbol", WrapF(pprof.Symbol))
+	group.GET("/trace", WrapF(pprof.Trace))
+	// pprof.Index只识别/debug/pprof/前缀，命名的profile需要单独注册
+	for _, name := range []string{"allocs", "block", "goroutine", "heap", "mutex", "threadcreate"} {
+		group.GET("/"+name, WrapH(pprof.Handler(name)))
+	}
+	return group
+}
@@ -0,0 +1,43 @@
+package doris
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnablePprof(t *testing.T) {
+	authorized := false
+	d := New()
+	d.EnablePprof("", func(c *Context) error {
+		if !authorized {
+			return NewHTTPError(http.StatusUnauthorized)
+		}
+		c.Next()
+		return nil
+	})
+	serve := func(path string) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		d.ServeHTTP(res, httptest.NewRequest(http.MethodGet, path, nil))
+		return res
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, serve("/debug/pprof/").Code)
+
+	authorized = true
+	res := serve("/debug/pprof/")
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Contains(t, res.Body.String(), "goroutine")
+
+	res = serve("/debug/pprof/goroutine?debug=1")
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.True(t, strings.HasPrefix(res.Body.String(), "goroutine profile:"))
+
+	d = New()
+	d.EnablePprof("/admin/pprof")
+	assert.Equal(t, http.StatusOK, serve("/admin/pprof/heap?debug=1").Code)
+	assert.Equal(t, http.StatusOK, serve("/admin/pprof/cmdline").Code)
+}
@@ -0,0 +1,182 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+// +build linux darwin freebsd netbsd openbsd dragonfly
+
+package doris
+
+import (
+	"context"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// 标记当前进程为prefork模式的工作进程
+const preforkChildEnv = "DORIS_PREFORK_CHILD"
+
+// 工作进程异常退出后重启前的等待时间，避免启动即崩溃时频繁重启
+const preforkRestartDelay = time.Second
+
+// 以prefork多进程模式启动http服务
+// 主进程启动PreforkProcesses个（默认CPU核数）工作进程，各工作进程通过SO_REUSEPORT
+// 监听同一地址并由内核分发连接，工作进程异常退出时由主进程重新拉起
+// 主进程收到SIGINT或SIGTERM时通知所有工作进程优雅关闭，全部退出后返回
+// 启动钩子和关闭钩子在每个工作进程中执行
+func (doris *Doris) RunPrefork(addr ...string) (err error) {
+	address := ResolveAddress(addr)
+	if os.Getenv(preforkChildEnv) == "1" {
+		return doris.runPreforkChild(address)
+	}
+
+	// 判断是否展示banner
+	if doris.ShowBanner {
+		doris.printBanner()
+	}
+
+	n := doris.PreforkProcesses
+	if n <= 0 {
+		n = runtime.NumCPU()
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	var (
+		lock     sync.Mutex
+		wg       sync.WaitGroup
+		stopping bool
+		workers  = make(map[int]*exec.Cmd, n)
+	)
+	// 启动工作进程，异常退出时重新拉起
+	var spawn func(id int) error
+	spawn = func(id int) error {
+		lock.Lock()
+		defer lock.Unlock()
+		// 开始关闭后不再拉起工作进程，否则其收不到SIGTERM且会阻塞wg.Wait
+		if stopping {
+			return nil
+		}
+		cmd, err := startPreforkChild()
+		if err != nil {
+			return err
+		}
+		workers[id] = cmd
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := cmd.Wait()
+			lock.Lock()
+			restart := !stopping
+			lock.Unlock()
+			if !restart {
+				return
+			}
+			doris.Logger.Error("prefork: worker exited, restarting", F("pid", cmd.Process.Pid), F("error", err))
+			time.Sleep(preforkRestartDelay)
+			if err := spawn(id); err != nil {
+				doris.Logger.Error("prefork: restart worker: " + err.Error())
+			}
+		}()
+		return nil
+	}
+
+	for id := 0; id < n; id++ {
+		if err = spawn(id); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		doris.startupf("⇨ http server started on %s (prefork, %d workers)\n\n", doris.colorize(colorGreen, address), n)
+		<-signals
+	}
+
+	// 通知所有工作进程优雅关闭
+	lock.Lock()
+	stopping = true
+	for _, cmd := range workers {
+		cmd.Process.Signal(syscall.SIGTERM)
+	}
+	lock.Unlock()
+	wg.Wait()
+
+	return err
+}
+
+// 以相同的参数和环境变量启动工作进程
+func startPreforkChild() (*exec.Cmd, error) {
+	path, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(path, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), preforkChildEnv+"=1")
+	return cmd, cmd.Start()
+}
+
+// 工作进程：通过SO_REUSEPORT监听并处理请求，收到SIGINT、SIGTERM或主进程退出时优雅关闭
+func (doris *Doris) runPreforkChild(addr string) (err error) {
+	// 每个工作进程只使用一个CPU，避免进程间的调度竞争
+	runtime.GOMAXPROCS(1)
+	config := net.ListenConfig{Control: reusePort}
+	listener, err := config.Listen(context.Background(), "tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	// 执行启动钩子，banner已由主进程打印
+	server := doris.newServer(addr, doris.handler())
+	showBanner := doris.ShowBanner
+	doris.ShowBanner = false
+	err = doris.launch(server)
+	doris.ShowBanner = showBanner
+	if err != nil {
+		listener.Close()
+		return err
+	}
+	doris.serve(func() error {
+		return server.Serve(listener)
+	})
+	errs := make(chan error, 1)
+	go func() {
+		errs <- doris.wait()
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	// 主进程退出后工作进程会被其他进程收养，此时随之退出
+	ppid := os.Getppid()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case err = <-errs:
+			return err
+		case <-ticker.C:
+			if os.Getppid() == ppid {
+				continue
+			}
+		case <-signals:
+		}
+		return doris.stop()
+	}
+}
+
+// 为监听socket设置SO_REUSEPORT，使多个进程可以监听同一地址
+func reusePort(network, address string, conn syscall.RawConn) error {
+	var err error
+	e := conn.Control(func(fd uintptr) {
+		err = syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, soReusePort, 1)
+	})
+	if e != nil {
+		return e
+	}
+	return err
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly
+
+package doris
+
+// 当前平台不支持SO_REUSEPORT，退化为单进程的RunGraceful
+func (doris *Doris) RunPrefork(addr ...string) error {
+	return doris.RunGraceful(addr...)
+}
@@ -0,0 +1,99 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+// +build linux darwin freebsd netbsd openbsd dragonfly
+
+package doris
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunPrefork(t *testing.T) {
+	d := New()
+	d.GET("/pid", func(c *Context) error {
+		c.String(http.StatusOK, "%d", os.Getpid())
+		return nil
+	})
+	// 工作进程重新执行本测试，直接进入RunPrefork
+	if os.Getenv(preforkChildEnv) == "1" {
+		assert.Nil(t, d.RunPrefork(os.Getenv("DORIS_TEST_PREFORK_ADDR")))
+		return
+	}
+
+	addr := freeAddr(t)
+	os.Setenv("DORIS_TEST_PREFORK_ADDR", addr)
+	defer os.Unsetenv("DORIS_TEST_PREFORK_ADDR")
+	args := os.Args
+	os.Args = []string{args[0], "-test.run=^TestRunPrefork$"}
+	defer func() { os.Args = args }()
+
+	d.PreforkProcesses = 2
+	result := make(chan error, 1)
+	go func() {
+		result <- d.RunPrefork(addr)
+	}()
+	waitListening(t, addr)
+
+	res, err := http.Get("http://" + addr + "/pid")
+	assert.Nil(t, err)
+	body, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	assert.NotEqual(t, "", string(body))
+	assert.NotEqual(t, strconv.Itoa(os.Getpid()), string(body))
+
+	assert.Nil(t, syscall.Kill(os.Getpid(), syscall.SIGTERM))
+	assert.Nil(t, <-result)
+}
+
+func TestRunPreforkStopDuringRestart(t *testing.T) {
+	d := New()
+	d.GET("/pid", func(c *Context) error {
+		c.String(http.StatusOK, "%d", os.Getpid())
+		return nil
+	})
+	if os.Getenv(preforkChildEnv) == "1" {
+		assert.Nil(t, d.RunPrefork(os.Getenv("DORIS_TEST_PREFORK_ADDR")))
+		return
+	}
+
+	addr := freeAddr(t)
+	os.Setenv("DORIS_TEST_PREFORK_ADDR", addr)
+	defer os.Unsetenv("DORIS_TEST_PREFORK_ADDR")
+	args := os.Args
+	os.Args = []string{args[0], "-test.run=^TestRunPreforkStopDuringRestart$"}
+	defer func() { os.Args = args }()
+
+	d.PreforkProcesses = 1
+	result := make(chan error, 1)
+	go func() {
+		result <- d.RunPrefork(addr)
+	}()
+	waitListening(t, addr)
+
+	res, err := http.Get("http://" + addr + "/pid")
+	if !assert.Nil(t, err) {
+		return
+	}
+	body, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	pid, err := strconv.Atoi(string(body))
+	assert.Nil(t, err)
+
+	// 工作进程崩溃后在重启等待期间收到SIGTERM，不再拉起新的工作进程
+	assert.Nil(t, syscall.Kill(pid, syscall.SIGKILL))
+	time.Sleep(preforkRestartDelay / 4)
+	assert.Nil(t, syscall.Kill(os.Getpid(), syscall.SIGTERM))
+	select {
+	case err := <-result:
+		assert.Nil(t, err)
+	case <-time.After(5 * preforkRestartDelay):
+		t.Fatal("RunPrefork didn't return")
+	}
+}
@@ -0,0 +1,67 @@
+package doris
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// RFC 7807问题详情（application/problem+json）
+type Problem struct {
+	Type       string                 // 问题类型URI，默认about:blank
+	Title      string                 // 问题类型的简短描述，默认为状态码描述
+	Status     int                    // HTTP状态码，默认500
+	Detail     string                 // 本次问题的具体说明
+	Instance   string                 // 本次问题的标识，如请求ID
+	Extensions map[string]interface{} // 扩展字段，与标准字段平铺输出
+}
+
+// 按RFC 7807输出，扩展字段与标准字段位于同一层级
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	obj := make(map[string]interface{}, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		obj[k] = v
+	}
+	obj["type"] = p.Type
+	obj["title"] = p.Title
+	obj["status"] = p.Status
+	if p.Detail != "" {
+		obj["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		obj["instance"] = p.Instance
+	}
+	return json.Marshal(obj)
+}
+
+// problem+json渲染结构
+type problemJson struct {
+	Data *Problem
+}
+
+func (r problemJson) Render(w http.ResponseWriter) error {
+	jsonBytes, err := json.Marshal(r.Data)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(jsonBytes)
+	return err
+}
+
+func (r problemJson) WriteContentType(w http.ResponseWriter) {
+	writeContentType(w, MIMEApplicationProblemJSON)
+}
+
+// 输出RFC 7807格式的错误响应
+// 未设置的Status、Title、Type分别默认为500、状态码描述和about:blank
+func (c *Context) Problem(p *Problem) {
+	if p.Status == 0 {
+		p.Status = http.StatusInternalServerError
+	}
+	if p.Title == "" {
+		p.Title = StatusMessage(p.Status)
+	}
+	if p.Type == "" {
+		p.Type = "about:blank"
+	}
+	c.render(p.Status, problemJson{Data: p})
+}
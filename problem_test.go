@@ -0,0 +1,36 @@
+package doris
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProblem(t *testing.T) {
+	d := New()
+	d.GET("/", func(c *Context) error {
+		c.Problem(&Problem{
+			Status:     http.StatusConflict,
+			Detail:     "order 7 is already paid",
+			Extensions: map[string]interface{}{"order": 7, "status": "ignored"},
+		})
+		return nil
+	})
+	res := httptest.NewRecorder()
+	d.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusConflict, res.Code)
+	assert.Equal(t, MIMEApplicationProblemJSON, res.Header().Get(HeaderContentType))
+	var body map[string]interface{}
+	assert.Nil(t, json.Unmarshal(res.Body.Bytes(), &body))
+	assert.Equal(t, map[string]interface{}{
+		"type":   "about:blank",
+		"title":  "Conflict",
+		"status": float64(409),
+		"detail": "order 7 is already paid",
+		"order":  float64(7),
+	}, body)
+}
@@ -0,0 +1,93 @@
+package doris
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+)
+
+type (
+	// HTTP/3（QUIC）服务，quic-go的http3.Server实现了该接口
+	// 由dorisquic包注册实现，核心模块因此不依赖quic-go
+	QUICServer interface {
+		// 在UDP连接上处理HTTP/3请求，被关闭时返回http.ErrServerClosed
+		Serve(conn net.PacketConn) error
+		// 添加宣告HTTP/3服务的Alt-Svc响应头
+		SetQUICHeaders(header http.Header) error
+		// 优雅关闭，ctx到期时强制关闭剩余连接
+		Shutdown(ctx context.Context) error
+		// 立即关闭
+		Close() error
+	}
+
+	// 按http服务的地址、处理器、tls配置和限制创建HTTP/3服务
+	QUICServerFunc func(server *http.Server) QUICServer
+)
+
+// 注册的HTTP/3服务实现
+var newQUICServer QUICServerFunc
+
+// 注册HTTP/3服务的实现，由dorisquic包在导入时调用：
+//
+//	import _ "github.com/leaderwolfpipi/doris/dorisquic"
+func RegisterQUIC(fn QUICServerFunc) {
+	newQUICServer = fn
+}
+
+// 实验性支持：同时启动HTTP/3（QUIC）和TLS服务
+// 两者监听同一地址（分别为UDP和TCP），TLS服务的响应会携带Alt-Svc头，
+// 告知客户端后续请求可以切换到HTTP/3
+// 需要导入dorisquic包，未导入时返回QUICUnavailableErr
+func (doris *Doris) RunQUIC(addr, certFile, keyFile string) (err error) {
+	if newQUICServer == nil {
+		return QUICUnavailableErr
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	config := doris.tlsConfig()
+	config.Certificates = []tls.Certificate{cert}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		listener.Close()
+		return err
+	}
+	quic := doris.newServer(addr, doris)
+	quic.TLSConfig = config
+	quicServer := newQUICServer(quic)
+	server := doris.newServer(addr, altSvcHandler(quicServer, doris))
+	server.TLSConfig = config
+	if err = doris.launch(server, quicServer); err != nil {
+		listener.Close()
+		conn.Close()
+		return err
+	}
+
+	doris.listening(listener)
+	doris.serve(func() error {
+		return server.ServeTLS(listener, "", "")
+	})
+	doris.serve(func() error {
+		// 关闭HTTP/3服务不会关闭传入的UDP连接
+		defer conn.Close()
+		return quicServer.Serve(conn)
+	})
+	doris.startupf("⇨ http3 server started on %s (udp and tcp)\n\n", doris.colorize(colorGreen, listener.Addr().String()))
+
+	return doris.wait()
+}
+
+// 为TLS服务的响应添加Alt-Svc头
+func altSvcHandler(quicServer QUICServer, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		quicServer.SetQUICHeaders(w.Header())
+		next.ServeHTTP(w, r)
+	})
+}
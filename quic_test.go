@@ -0,0 +1,141 @@
+package doris
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 在临时目录中生成自签名证书
+func writeTestCert(t *testing.T) (dir, certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	assert.Nil(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.Nil(t, err)
+
+	dir, err = ioutil.TempDir("", "doris-cert")
+	assert.Nil(t, err)
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	assert.Nil(t, ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	assert.Nil(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+	return dir, certFile, keyFile
+}
+
+// 记录调用的HTTP/3服务
+type fakeQUICServer struct {
+	server *http.Server
+	conns  chan net.PacketConn
+	closed chan struct{}
+}
+
+func (s *fakeQUICServer) Serve(conn net.PacketConn) error {
+	s.conns <- conn
+	<-s.closed
+	return http.ErrServerClosed
+}
+
+func (s *fakeQUICServer) SetQUICHeaders(header http.Header) error {
+	header.Set("Alt-Svc", `h3=":443"; ma=2592000`)
+	return nil
+}
+
+func (s *fakeQUICServer) Shutdown(ctx context.Context) error {
+	return s.Close()
+}
+
+func (s *fakeQUICServer) Close() error {
+	close(s.closed)
+	return nil
+}
+
+func TestRunQUIC(t *testing.T) {
+	dir, certFile, keyFile := writeTestCert(t)
+	defer os.RemoveAll(dir)
+
+	d := New()
+	d.GET("/", func(c *Context) error {
+		c.String(http.StatusOK, "ok")
+		return nil
+	})
+	assert.Equal(t, QUICUnavailableErr, d.RunQUIC("127.0.0.1:0", certFile, keyFile))
+
+	quic := &fakeQUICServer{conns: make(chan net.PacketConn, 1), closed: make(chan struct{})}
+	RegisterQUIC(func(server *http.Server) QUICServer {
+		quic.server = server
+		return quic
+	})
+	defer RegisterQUIC(nil)
+
+	result := make(chan error, 1)
+	go func() {
+		result <- d.RunQUIC("127.0.0.1:0", certFile, keyFile)
+	}()
+	<-d.Started()
+	conn := <-quic.conns
+	assert.Equal(t, "udp", conn.LocalAddr().Network())
+	// HTTP/3服务直接使用Doris处理请求，并按TLSConfig配置证书
+	assert.Equal(t, d, quic.server.Handler)
+	assert.Len(t, quic.server.TLSConfig.Certificates, 1)
+
+	// TLS服务的响应携带Alt-Svc头
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	res, err := client.Get("https://" + d.Addr().String() + "/")
+	if assert.Nil(t, err) {
+		res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, `h3=":443"; ma=2592000`, res.Header.Get("Alt-Svc"))
+	}
+
+	// Stop同时关闭两个服务，并关闭UDP连接
+	assert.Nil(t, d.Stop(context.Background()))
+	assert.Nil(t, <-result)
+	_, _, err = conn.ReadFrom(make([]byte, 1))
+	assert.NotNil(t, err)
+}
+
+func TestRunQUICStartHookError(t *testing.T) {
+	dir, certFile, keyFile := writeTestCert(t)
+	defer os.RemoveAll(dir)
+	RegisterQUIC(func(server *http.Server) QUICServer {
+		return &fakeQUICServer{conns: make(chan net.PacketConn, 1), closed: make(chan struct{})}
+	})
+	defer RegisterQUIC(nil)
+
+	addr := freeAddr(t)
+	hookErr := errors.New("warm up failed")
+	d := New()
+	d.OnStart(func() error {
+		return hookErr
+	})
+	assert.Equal(t, hookErr, d.RunQUIC(addr, certFile, keyFile))
+	// 启动失败时释放监听的地址
+	listener, err := net.Listen("tcp", addr)
+	if assert.Nil(t, err) {
+		listener.Close()
+	}
+}
@@ -0,0 +1,171 @@
+// 使用缓冲池的内置渲染结构
+// 序列化结果先写入对象池中的缓冲区再一次性写入响应，减少高负载下的内存分配
+package doris
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// 放回对象池的缓冲区的最大容量，避免偶发的大响应长期占用内存
+const maxPooledBufferSize = 64 << 10
+
+// 渲染使用的缓冲区对象池
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// 从对象池获取缓冲区
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+// 将缓冲区放回对象池
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBufferSize {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
+// 绑定了缓冲区的json编码器
+type jsonEncoder struct {
+	buf bytes.Buffer
+	enc *json.Encoder
+}
+
+// json编码器对象池
+var jsonEncoderPool = sync.Pool{
+	New: func() interface{} {
+		e := new(jsonEncoder)
+		e.enc = json.NewEncoder(&e.buf)
+		return e
+	},
+}
+
+// 从对象池获取json编码器
+func getJsonEncoder(escapeHTML bool) *jsonEncoder {
+	e := jsonEncoderPool.Get().(*jsonEncoder)
+	e.enc.SetEscapeHTML(escapeHTML)
+	return e
+}
+
+// 将json编码器放回对象池
+func putJsonEncoder(e *jsonEncoder) {
+	if e.buf.Cap() > maxPooledBufferSize {
+		return
+	}
+	e.buf.Reset()
+	jsonEncoderPool.Put(e)
+}
+
+// 编码结果，与json.Marshal的输出保持一致，去掉Encode追加的换行
+func (e *jsonEncoder) bytes() []byte {
+	return bytes.TrimSuffix(e.buf.Bytes(), []byte("\n"))
+}
+
+// 绑定了缓冲区的xml编码器
+type xmlEncoder struct {
+	buf bytes.Buffer
+	enc *xml.Encoder
+}
+
+// xml编码器对象池
+var xmlEncoderPool = sync.Pool{
+	New: func() interface{} {
+		e := new(xmlEncoder)
+		e.enc = xml.NewEncoder(&e.buf)
+		return e
+	},
+}
+
+// 预先生成的Content-Type头部值，避免每次响应都分配新的切片
+var (
+	jsonContentType   = []string{"application/json; charset=utf-8"}
+	xmlContentType    = []string{"application/xml; charset=utf-8"}
+	stringContentType = []string{"text/plain; charset=utf-8"}
+)
+
+// 写入预先生成的Content-Type头部，已设置时不覆盖
+func writeHeaderValue(w http.ResponseWriter, value []string) {
+	header := w.Header()
+	if header.Get(HeaderContentType) == "" {
+		header[HeaderContentType] = value
+	}
+}
+
+type (
+	// json渲染结构，escapeHTML为false时不转义html字符（PureJson）
+	jsonRender struct {
+		Data       interface{}
+		escapeHTML bool
+	}
+
+	// xml渲染结构
+	xmlRender struct {
+		Data interface{}
+	}
+
+	// 字符串渲染结构
+	stringRender struct {
+		Format string
+		Data   []interface{}
+	}
+)
+
+func (r jsonRender) Render(w http.ResponseWriter) error {
+	e := getJsonEncoder(r.escapeHTML)
+	defer putJsonEncoder(e)
+	if err := e.enc.Encode(r.Data); err != nil {
+		return err
+	}
+	_, err := w.Write(e.bytes())
+	return err
+}
+
+func (r jsonRender) WriteContentType(w http.ResponseWriter) {
+	writeHeaderValue(w, jsonContentType)
+}
+
+func (r xmlRender) Render(w http.ResponseWriter) error {
+	e := xmlEncoderPool.Get().(*xmlEncoder)
+	if err := e.enc.Encode(r.Data); err != nil {
+		// 编码失败时编码器内部状态不确定，不再放回对象池
+		return err
+	}
+	_, err := w.Write(e.buf.Bytes())
+	if e.buf.Cap() <= maxPooledBufferSize {
+		e.buf.Reset()
+		xmlEncoderPool.Put(e)
+	}
+	return err
+}
+
+func (r xmlRender) WriteContentType(w http.ResponseWriter) {
+	writeHeaderValue(w, xmlContentType)
+}
+
+func (r stringRender) Render(w http.ResponseWriter) error {
+	// 没有参数时直接输出，不经过fmt格式化
+	if len(r.Data) == 0 {
+		_, err := io.WriteString(w, r.Format)
+		return err
+	}
+	buf := getBuffer()
+	defer putBuffer(buf)
+	fmt.Fprintf(buf, r.Format, r.Data...)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func (r stringRender) WriteContentType(w http.ResponseWriter) {
+	writeHeaderValue(w, stringContentType)
+}
@@ -0,0 +1,113 @@
+package doris
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type renderUser struct {
+	ID    int      `json:"id" xml:"id"`
+	Name  string   `json:"name" xml:"name"`
+	Email string   `json:"email" xml:"email"`
+	Tags  []string `json:"tags" xml:"tags"`
+}
+
+var renderFixture = renderUser{ID: 42, Name: "<alice>", Email: "alice@example.com", Tags: []string{"admin", "ops"}}
+
+func TestPooledRenderers(t *testing.T) {
+	res := httptest.NewRecorder()
+	assert.Nil(t, jsonRender{Data: renderFixture, escapeHTML: true}.Render(res))
+	assert.Equal(t, `{"id":42,"name":"\u003calice\u003e","email":"alice@example.com","tags":["admin","ops"]}`, res.Body.String())
+
+	res = httptest.NewRecorder()
+	assert.Nil(t, jsonRender{Data: renderFixture}.Render(res))
+	assert.Contains(t, res.Body.String(), `"name":"<alice>"`)
+
+	res = httptest.NewRecorder()
+	assert.Nil(t, xmlRender{Data: renderFixture}.Render(res))
+	assert.Equal(t, `<renderUser><id>42</id><name>&lt;alice&gt;</name><email>alice@example.com</email><tags>admin</tags><tags>ops</tags></renderUser>`, res.Body.String())
+
+	res = httptest.NewRecorder()
+	assert.Nil(t, stringRender{Format: "100%"}.Render(res))
+	assert.Equal(t, "100%", res.Body.String())
+
+	res = httptest.NewRecorder()
+	assert.Nil(t, stringRender{Format: "hello %s", Data: []interface{}{"doris"}}.Render(res))
+	assert.Equal(t, "hello doris", res.Body.String())
+
+	// 编码失败时不写入任何内容
+	res = httptest.NewRecorder()
+	assert.NotNil(t, jsonRender{Data: make(chan int)}.Render(res))
+	assert.Equal(t, 0, res.Body.Len())
+}
+
+func TestPutBufferDropsLargeBuffers(t *testing.T) {
+	buf := getBuffer()
+	buf.WriteString(strings.Repeat("x", maxPooledBufferSize+1))
+	putBuffer(buf)
+	assert.True(t, getBuffer().Cap() <= maxPooledBufferSize)
+}
+
+// 渲染基准测试，用于发现内存分配的回退：go test -run XXX -bench Render -benchmem
+func BenchmarkRenderJson(b *testing.B) {
+	benchmarkRender(b, func(c *Context) error {
+		c.Json(http.StatusOK, renderFixture)
+		return nil
+	})
+}
+
+func BenchmarkRenderPureJson(b *testing.B) {
+	benchmarkRender(b, func(c *Context) error {
+		c.PureJson(http.StatusOK, renderFixture)
+		return nil
+	})
+}
+
+func BenchmarkRenderXml(b *testing.B) {
+	benchmarkRender(b, func(c *Context) error {
+		c.Xml(http.StatusOK, renderFixture)
+		return nil
+	})
+}
+
+func BenchmarkRenderString(b *testing.B) {
+	benchmarkRender(b, func(c *Context) error {
+		c.String(http.StatusOK, "hello %s, you are user %d", renderFixture.Name, renderFixture.ID)
+		return nil
+	})
+}
+
+func BenchmarkRenderStringPlain(b *testing.B) {
+	benchmarkRender(b, func(c *Context) error {
+		c.String(http.StatusOK, "pong")
+		return nil
+	})
+}
+
+func benchmarkRender(b *testing.B, h HandlerFunc) {
+	d := New()
+	d.GET("/", h)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := &discardWriter{header: http.Header{}}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for k := range w.header {
+			delete(w.header, k)
+		}
+		d.ServeHTTP(w, req)
+	}
+}
+
+// 丢弃响应内容的ResponseWriter
+type discardWriter struct {
+	header http.Header
+}
+
+func (w *discardWriter) Header() http.Header         { return w.header }
+func (w *discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *discardWriter) WriteHeader(int)             {}
@@ -44,9 +44,11 @@ type ResponseWriter interface {
 }
 
 type Response struct {
-	size   int
-	status int
-	Writer http.ResponseWriter
+	size        int
+	status      int
+	Writer      http.ResponseWriter
+	beforeFuncs []func() // 响应头写入前执行的回调
+	afterFuncs  []func() // 响应头写入后执行的回调
 }
 
 // Response结构实现了上述接口
@@ -56,6 +58,20 @@ func (w *Response) reset(writer http.ResponseWriter) {
 	w.Writer = writer
 	w.size = noWritten
 	w.status = defaultStatus
+	w.beforeFuncs = nil
+	w.afterFuncs = nil
+}
+
+// 注册响应头写入前的回调
+// 可用于即时注入响应头（ETag、Server-Timing等）
+func (w *Response) Before(fn func()) {
+	w.beforeFuncs = append(w.beforeFuncs, fn)
+}
+
+// 注册响应头写入后的回调
+// 此时的状态码即为最终发送给客户端的状态码
+func (w *Response) After(fn func()) {
+	w.afterFuncs = append(w.afterFuncs, fn)
 }
 
 // 将code值写入w中后面调用WriteHeaderNow再发送
@@ -68,11 +84,24 @@ func (w *Response) WriteHeader(code int) {
 	}
 }
 
-// 本接口暂时用不上
+// 立即发送响应头，仅在第一次调用时生效
+// 发送前后分别执行Before和After注册的回调
 func (w *Response) WriteHeaderNow() {
 	if !w.Written() {
+		// 回调中写入响应会再次调用WriteHeaderNow，先取出回调避免递归
+		beforeFuncs := w.beforeFuncs
+		w.beforeFuncs = nil
+		for _, fn := range beforeFuncs {
+			fn()
+		}
+		if w.Written() {
+			return
+		}
 		w.size = 0
 		w.Writer.WriteHeader(w.status)
+		for _, fn := range w.afterFuncs {
+			fn()
+		}
 	}
 }
 
@@ -129,6 +158,30 @@ func (w *Response) Pusher() (pusher http.Pusher) {
 }
 
 func (w *Response) Header() http.Header {
-	debugPrintMessage("reponse: Header方法", "__print__", true)
-	return nil
+	return w.Writer.Header()
 }
+
+// 上下文快照使用的只读ResponseWriter
+// 保留复制时的响应头，丢弃任何写入操作
+type snapshotWriter struct {
+	header http.Header
+}
+
+// 复制响应头创建快照
+func newSnapshotWriter(header http.Header) *snapshotWriter {
+	h := make(http.Header, len(header))
+	for k, v := range header {
+		h[k] = append([]string(nil), v...)
+	}
+	return &snapshotWriter{header: h}
+}
+
+func (w *snapshotWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *snapshotWriter) Write(data []byte) (int, error) {
+	return 0, SnapshotWriteErr
+}
+
+func (w *snapshotWriter) WriteHeader(code int) {}
@@ -0,0 +1,90 @@
+package doris
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseHooks(t *testing.T) {
+	var calls []string
+	var status int
+	d := New()
+	d.Use(func(c *Context) error {
+		if c.QueryParam("hooks") != "" {
+			c.Response.Before(func() {
+				calls = append(calls, "before")
+				// 响应头发送前仍可修改
+				c.SetResponseHeader("Server-Timing", "app;dur=1")
+			})
+			c.Response.After(func() {
+				calls = append(calls, "after")
+				status = c.Response.Status()
+			})
+		}
+		c.Next()
+		return nil
+	})
+	d.GET("/", func(c *Context) error {
+		c.String(http.StatusCreated, "a")
+		c.Response.Write([]byte("b"))
+		c.Response.WriteHeaderNow()
+		return nil
+	})
+	d.GET("/empty", func(c *Context) error {
+		c.Response.WriteHeader(http.StatusAccepted)
+		return nil
+	})
+	serve := func(path string) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		d.ServeHTTP(res, httptest.NewRequest(http.MethodGet, path, nil))
+		return res
+	}
+
+	// 多次写入时回调只执行一次
+	res := serve("/?hooks=1")
+	assert.Equal(t, []string{"before", "after"}, calls)
+	assert.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, "app;dur=1", res.Header().Get("Server-Timing"))
+	assert.Equal(t, "ab", res.Body.String())
+
+	// 处理链未写入时在发送响应头时执行，状态码为最终状态码
+	calls = nil
+	res = serve("/empty?hooks=1")
+	assert.Equal(t, []string{"before", "after"}, calls)
+	assert.Equal(t, http.StatusAccepted, status)
+	assert.Equal(t, http.StatusAccepted, res.Code)
+
+	// 回调不会保留到复用的响应对象
+	calls = nil
+	res = serve("/")
+	assert.Nil(t, calls)
+	assert.Equal(t, "", res.Header().Get("Server-Timing"))
+}
+
+func TestResponseBeforeHookWrites(t *testing.T) {
+	var calls []string
+	d := New()
+	d.GET("/", func(c *Context) error {
+		c.Response.Before(func() {
+			calls = append(calls, "before")
+			c.Response.WriteHeader(http.StatusTeapot)
+			c.Response.Write([]byte("hook,"))
+			c.Response.Flush()
+		})
+		c.Response.After(func() {
+			calls = append(calls, "after")
+		})
+		c.Response.Write([]byte("body"))
+		return nil
+	})
+	res := httptest.NewRecorder()
+	d.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	// 回调中写入响应不会递归执行回调，响应头只发送一次
+	assert.Equal(t, []string{"before", "after"}, calls)
+	assert.Equal(t, http.StatusTeapot, res.Code)
+	assert.Equal(t, "hook,body", res.Body.String())
+}
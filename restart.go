@@ -0,0 +1,104 @@
+//go:build !windows
+// +build !windows
+
+package doris
+
+import (
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+)
+
+// 标记当前进程由平滑重启启动，监听socket通过fd 3传入
+const gracefulRestartEnv = "DORIS_GRACEFUL_RESTART"
+
+// 启动支持零停机重启的http服务
+// 收到SIGHUP时以相同参数启动新进程并传递监听socket，新进程开始接受连接后
+// 通知旧进程（SIGTERM），旧进程停止接受连接并等待处理中的请求完成后退出
+// 收到SIGINT或SIGTERM时优雅关闭，正常关闭时返回nil
+// 注意：新进程的PID与旧进程不同，由systemd等进程管理器托管时需要相应配置
+func (doris *Doris) RunGraceful(addr ...string) (err error) {
+	address := ResolveAddress(addr)
+	listener, inherited, err := gracefulListener(address)
+	if err != nil {
+		return err
+	}
+
+	server := doris.newServer(address, doris.handler())
+	if err = doris.launch(server); err != nil {
+		listener.Close()
+		return err
+	}
+	doris.listening(listener)
+	doris.serve(func() error {
+		return server.Serve(listener)
+	})
+	doris.startupf("⇨ http server started on %s (pid %d)\n\n", doris.colorize(colorGreen, listener.Addr().String()), os.Getpid())
+
+	// 新进程已开始接受连接，通知旧进程退出
+	if inherited {
+		syscall.Kill(os.Getppid(), syscall.SIGTERM)
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- doris.wait()
+	}()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	for {
+		select {
+		case err = <-errs:
+			return err
+		case sig := <-signals:
+			if sig != syscall.SIGHUP {
+				return doris.stop()
+			}
+			// 启动新进程，等待其通知后再退出
+			if err := restartProcess(listener); err != nil {
+				doris.Logger.Error("graceful restart: " + err.Error())
+			}
+		}
+	}
+}
+
+// 获取监听socket：平滑重启时使用旧进程传入的socket，否则新建
+func gracefulListener(addr string) (listener net.Listener, inherited bool, err error) {
+	if os.Getenv(gracefulRestartEnv) != "1" {
+		listener, err = net.Listen("tcp", addr)
+		return listener, false, err
+	}
+	os.Unsetenv(gracefulRestartEnv)
+	f := os.NewFile(3, "doris-listener")
+	defer f.Close()
+	listener, err = net.FileListener(f)
+	return listener, err == nil, err
+}
+
+// 以相同的参数和环境变量启动新进程，并通过fd 3传递监听socket
+func restartProcess(listener net.Listener) error {
+	l, ok := listener.(*net.TCPListener)
+	if !ok {
+		return GracefulListenerErr
+	}
+	f, err := l.File()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	path, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(path, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), gracefulRestartEnv+"=1")
+	cmd.ExtraFiles = []*os.File{f}
+	return cmd.Start()
+}
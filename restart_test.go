@@ -0,0 +1,28 @@
+//go:build !windows
+// +build !windows
+
+package doris
+
+import (
+	"net"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunGracefulShutdown(t *testing.T) {
+	addr := freeAddr(t)
+	d := New()
+	result := make(chan error, 1)
+	go func() {
+		result <- d.RunGraceful(addr)
+	}()
+	waitListening(t, addr)
+
+	assert.Nil(t, syscall.Kill(os.Getpid(), syscall.SIGTERM))
+	assert.Nil(t, <-result)
+	_, err := net.Dial("tcp", addr)
+	assert.NotNil(t, err)
+}
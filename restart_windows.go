@@ -0,0 +1,24 @@
+package doris
+
+import (
+	"context"
+	"os"
+	"os/signal"
+)
+
+// windows不支持传递监听socket，退化为收到中断信号时优雅关闭的http服务
+func (doris *Doris) RunGraceful(addr ...string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	defer signal.Stop(signals)
+	go func() {
+		select {
+		case <-signals:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return doris.RunWithContext(ctx, addr...)
+}
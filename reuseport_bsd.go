@@ -0,0 +1,8 @@
+//go:build darwin || freebsd || netbsd || openbsd || dragonfly
+// +build darwin freebsd netbsd openbsd dragonfly
+
+package doris
+
+import "syscall"
+
+const soReusePort = syscall.SO_REUSEPORT
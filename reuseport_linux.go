@@ -0,0 +1,7 @@
+//go:build linux && !mips && !mipsle && !mips64 && !mips64le
+// +build linux,!mips,!mipsle,!mips64,!mips64le
+
+package doris
+
+// syscall包在linux下未定义SO_REUSEPORT
+const soReusePort = 0xf
@@ -0,0 +1,8 @@
+//go:build linux && (mips || mipsle || mips64 || mips64le)
+// +build linux
+// +build mips mipsle mips64 mips64le
+
+package doris
+
+// mips架构下SO_REUSEPORT的取值与其他架构不同
+const soReusePort = 0x200
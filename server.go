@@ -0,0 +1,91 @@
+package doris
+
+import (
+	"context"
+	"net"
+	"net/http"
+)
+
+// 监听端点配置
+type Endpoint struct {
+	Addr     string       // 监听地址
+	Handler  http.Handler // 处理器，默认为当前Doris；可以是另一个Doris实例（如管理端口的独立路由树）
+	CertFile string       // 证书文件，与KeyFile同时设置时启动https服务
+	KeyFile  string       // 私钥文件
+}
+
+// 同时启动多个监听端点，例如80端口的https重定向加443端口的https服务，
+// 或公共端口加使用独立路由树的管理端口
+// 任意端点绑定失败时直接返回错误；运行出错或ctx取消时优雅关闭所有端点，正常关闭时返回nil
+func (doris *Doris) RunEndpoints(ctx context.Context, endpoints ...Endpoint) (err error) {
+	if len(endpoints) == 0 {
+		return NoEndpointErr
+	}
+
+	// 先绑定全部端点，任意端点绑定失败时不执行启动钩子
+	listeners := make([]net.Listener, 0, len(endpoints))
+	for _, ep := range endpoints {
+		listener, e := net.Listen("tcp", ep.Addr)
+		if e != nil {
+			// 监听失败时关闭已经绑定的端点
+			for _, l := range listeners {
+				l.Close()
+			}
+			return e
+		}
+		listeners = append(listeners, listener)
+	}
+	servers := make([]serverCloser, len(endpoints))
+	for i, ep := range endpoints {
+		handler := ep.Handler
+		if handler == nil {
+			handler = doris.handler()
+		}
+		servers[i] = doris.newServer(ep.Addr, handler)
+	}
+	if err = doris.launch(servers...); err != nil {
+		for _, l := range listeners {
+			l.Close()
+		}
+		return err
+	}
+
+	for i, ep := range endpoints {
+		server, listener := servers[i].(*http.Server), listeners[i]
+		doris.listening(listener)
+		if ep.CertFile != "" && ep.KeyFile != "" {
+			server.TLSConfig = doris.tlsConfig()
+			certFile, keyFile := ep.CertFile, ep.KeyFile
+			doris.serve(func() error {
+				return server.ServeTLS(listener, certFile, keyFile)
+			})
+			doris.startupf("⇨ https server started on %s \n", doris.colorize(colorGreen, listener.Addr().String()))
+		} else {
+			doris.serve(func() error {
+				return server.Serve(listener)
+			})
+			doris.startupf("⇨ http server started on %s \n", doris.colorize(colorGreen, listener.Addr().String()))
+		}
+	}
+
+	return doris.waitContext(ctx)
+}
+
+// 将http请求重定向到https的处理器
+// port为https服务的端口，为空或443时省略；GET和HEAD以外的请求使用308保留请求方法
+func HTTPSRedirect(port string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		code := http.StatusMovedPermanently
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			code = http.StatusPermanentRedirect
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), code)
+	})
+}
@@ -0,0 +1,80 @@
+package doris
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func getBody(t *testing.T, url string) string {
+	res, err := http.Get(url)
+	assert.Nil(t, err)
+	if err != nil {
+		return ""
+	}
+	defer res.Body.Close()
+	b, _ := ioutil.ReadAll(res.Body)
+	return string(b)
+}
+
+func TestRunEndpoints(t *testing.T) {
+	public, admin := freeAddr(t), freeAddr(t)
+	d := New()
+	d.GET("/", func(c *Context) error {
+		c.String(http.StatusOK, "public")
+		return nil
+	})
+	adminRoutes := New()
+	adminRoutes.GET("/", func(c *Context) error {
+		c.String(http.StatusOK, "admin")
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() {
+		result <- d.RunEndpoints(ctx, Endpoint{Addr: public}, Endpoint{Addr: admin, Handler: adminRoutes})
+	}()
+	waitListening(t, public)
+	waitListening(t, admin)
+
+	assert.Equal(t, "public", getBody(t, "http://"+public+"/"))
+	assert.Equal(t, "admin", getBody(t, "http://"+admin+"/"))
+
+	cancel()
+	assert.Nil(t, <-result)
+	_, err := net.Dial("tcp", admin)
+	assert.NotNil(t, err)
+}
+
+func TestRunEndpointsStopsAllOnError(t *testing.T) {
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer busy.Close()
+
+	free := freeAddr(t)
+	d := New()
+	err = d.RunEndpoints(context.Background(), Endpoint{Addr: free}, Endpoint{Addr: busy.Addr().String()})
+	assert.NotNil(t, err)
+	_, err = net.Dial("tcp", free)
+	assert.NotNil(t, err)
+
+	assert.Equal(t, NoEndpointErr, d.RunEndpoints(context.Background()))
+}
+
+func TestHTTPSRedirect(t *testing.T) {
+	res := httptest.NewRecorder()
+	HTTPSRedirect("").ServeHTTP(res, httptest.NewRequest(http.MethodGet, "http://example.com:80/a?b=1", nil))
+	assert.Equal(t, http.StatusMovedPermanently, res.Code)
+	assert.Equal(t, "https://example.com/a?b=1", res.Header().Get("Location"))
+
+	res = httptest.NewRecorder()
+	HTTPSRedirect("8443").ServeHTTP(res, httptest.NewRequest(http.MethodPost, "http://example.com/form", nil))
+	assert.Equal(t, http.StatusPermanentRedirect, res.Code)
+	assert.Equal(t, "https://example.com:8443/form", res.Header().Get("Location"))
+}
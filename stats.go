@@ -0,0 +1,161 @@
+// 运行时统计
+package doris
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+)
+
+// 计算每秒请求数的时间窗口（秒）
+const statsWindow = 10
+
+type (
+	// 运行时统计信息
+	Stats struct {
+		Uptime         string           `json:"uptime"`           // 开启统计以来的运行时间
+		Goroutines     int              `json:"goroutines"`       // 当前协程数
+		GC             GCStats          `json:"gc"`               // 垃圾回收统计
+		OpenConns      int              `json:"open_conns"`       // 当前打开的连接数
+		IdleConns      int              `json:"idle_conns"`       // 当前空闲的keep-alive连接数
+		ActiveRequests int              `json:"active_requests"`  // 正在处理的请求数
+		Requests       int64            `json:"requests"`         // 请求总数
+		RequestsPerSec float64          `json:"requests_per_sec"` // 最近10秒的平均每秒请求数
+		Routes         map[string]int64 `json:"routes"`           // 各路由的请求数，键为"方法 路由模板"
+	}
+
+	// 垃圾回收统计
+	GCStats struct {
+		NumGC      int64  `json:"num_gc"`      // 垃圾回收次数
+		PauseTotal string `json:"pause_total"` // 累计暂停时间
+		LastGC     string `json:"last_gc"`     // 最近一次垃圾回收的时间
+	}
+
+	// 请求计数器，处理请求时只做原子操作
+	stats struct {
+		start    time.Time
+		requests int64
+		routes   map[string]map[string]*int64 // 各路由的请求数，按方法和路由模板索引，只在注册路由时写入
+		buckets  [statsWindow]uint64          // 按秒统计的请求数，高32位为开启统计以来的秒数，低32位为请求数
+	}
+)
+
+// 开启请求统计并在path上注册统计接口，输出expvar的全部变量和doris的统计信息
+// path为空时使用/debug/vars，handlers为接口的中间件
+// 与注册路由相同，需要在启动服务前调用
+func (doris *Doris) EnableStats(path string, handlers ...HandlerFunc) {
+	if path == "" {
+		path = "/debug/vars"
+	}
+	if doris.stats == nil {
+		st := &stats{start: time.Now(), routes: make(map[string]map[string]*int64)}
+		for _, route := range doris.routes {
+			st.addRoute(route.Method, route.Path)
+		}
+		doris.stats = st
+	}
+	doris.GET(path, append(handlers, doris.statsHandler)...)
+}
+
+// 获取运行时统计信息，未开启统计时请求总数、每秒请求数和路由计数为零值
+func (doris *Doris) Stats() Stats {
+	var gc debug.GCStats
+	debug.ReadGCStats(&gc)
+	s := Stats{
+		Goroutines: runtime.NumGoroutine(),
+		GC: GCStats{
+			NumGC:      gc.NumGC,
+			PauseTotal: gc.PauseTotal.String(),
+			LastGC:     gc.LastGC.Format(time.RFC3339),
+		},
+		OpenConns:      doris.OpenConns(),
+		IdleConns:      doris.IdleConns(),
+		ActiveRequests: doris.ActiveRequests(),
+		Routes:         map[string]int64{},
+	}
+	if st := doris.stats; st != nil {
+		s.Uptime = time.Since(st.start).Round(time.Second).String()
+		s.Requests = atomic.LoadInt64(&st.requests)
+		s.RequestsPerSec = st.rate(st.second(time.Now()))
+		for method, routes := range st.routes {
+			for route, n := range routes {
+				if hits := atomic.LoadInt64(n); hits > 0 {
+					s.Routes[method+" "+route] = hits
+				}
+			}
+		}
+	}
+	return s
+}
+
+// 统计接口处理函数
+func (doris *Doris) statsHandler(c *Context) error {
+	vars := make(map[string]json.RawMessage)
+	expvar.Do(func(kv expvar.KeyValue) {
+		vars[kv.Key] = json.RawMessage(kv.Value.String())
+	})
+	data, err := json.Marshal(doris.Stats())
+	if err != nil {
+		return err
+	}
+	vars["doris"] = data
+	c.Json(http.StatusOK, vars)
+	return nil
+}
+
+// 为路由创建计数器，注册路由时调用
+func (st *stats) addRoute(method, path string) {
+	if st.routes[method] == nil {
+		st.routes[method] = make(map[string]*int64)
+	}
+	if st.routes[method][path] == nil {
+		st.routes[method][path] = new(int64)
+	}
+}
+
+// 记录一次请求，route为路由模板，未匹配路由时为空
+func (st *stats) record(method, route string) {
+	atomic.AddInt64(&st.requests, 1)
+	if n := st.routes[method][route]; n != nil {
+		atomic.AddInt64(n, 1)
+	}
+	sec := st.second(time.Now())
+	b := &st.buckets[sec%statsWindow]
+	for {
+		old := atomic.LoadUint64(b)
+		// 桶中是更早的某一秒时重新计数
+		n := statsBucket(sec, 1)
+		if old>>32 == sec {
+			n = old + 1
+		}
+		if atomic.CompareAndSwapUint64(b, old, n) {
+			return
+		}
+	}
+}
+
+// 开启统计以来的秒数
+func (st *stats) second(t time.Time) uint64 {
+	return uint64(t.Sub(st.start) / time.Second)
+}
+
+// 某一秒的请求数对应的桶
+func statsBucket(sec, n uint64) uint64 {
+	return sec<<32 | n
+}
+
+// 计算最近统计窗口内已结束的各秒的平均每秒请求数，now为开启统计以来的秒数
+func (st *stats) rate(now uint64) float64 {
+	var total uint64
+	for i := range st.buckets {
+		b := atomic.LoadUint64(&st.buckets[i])
+		if sec := b >> 32; sec < now && sec+statsWindow >= now {
+			total += b & (1<<32 - 1)
+		}
+	}
+	return float64(total) / statsWindow
+}
@@ -0,0 +1,94 @@
+package doris
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnableStats(t *testing.T) {
+	d := New()
+	d.GET("/users/:id", func(c *Context) error {
+		c.String(http.StatusOK, "ok")
+		return nil
+	})
+	serve := func(path string) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		d.ServeHTTP(res, httptest.NewRequest(http.MethodGet, path, nil))
+		return res
+	}
+
+	// 未开启统计时不计数
+	serve("/users/1")
+	assert.Equal(t, int64(0), d.Stats().Requests)
+
+	d.EnableStats("")
+	serve("/users/1")
+	serve("/users/2")
+	serve("/missing")
+	stats := d.Stats()
+	assert.Equal(t, int64(3), stats.Requests)
+	assert.Equal(t, map[string]int64{"GET /users/:id": 2}, stats.Routes)
+	assert.True(t, stats.Goroutines > 0)
+
+	res := serve("/debug/vars")
+	assert.Equal(t, http.StatusOK, res.Code)
+	var vars struct {
+		Memstats json.RawMessage
+		Doris    Stats
+	}
+	assert.Nil(t, json.Unmarshal(res.Body.Bytes(), &vars))
+	assert.NotEmpty(t, vars.Memstats)
+	assert.Equal(t, int64(3), vars.Doris.Requests)
+
+	// 开启统计后注册的路由同样计数
+	d.POST("/users", func(c *Context) error {
+		return nil
+	})
+	d.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/users", nil))
+	assert.Equal(t, map[string]int64{"GET /users/:id": 2, "GET /debug/vars": 1, "POST /users": 1}, d.Stats().Routes)
+}
+
+func TestStatsRate(t *testing.T) {
+	st := &stats{start: time.Now()}
+	now := uint64(100)
+	st.buckets[(now-1)%statsWindow] = statsBucket(now-1, 30)
+	st.buckets[(now-2)%statsWindow] = statsBucket(now-2, 10)
+	// 超出窗口和当前未结束的一秒不参与计算
+	st.buckets[(now-statsWindow-3)%statsWindow] = statsBucket(now-statsWindow-3, 1000)
+	st.buckets[now%statsWindow] = statsBucket(now, 1000)
+	assert.Equal(t, 4.0, st.rate(now))
+}
+
+func TestStatsRecord(t *testing.T) {
+	st := &stats{start: time.Now().Add(-15 * time.Second), routes: map[string]map[string]*int64{}}
+	st.addRoute(http.MethodGet, "/users/:id")
+	// 桶中残留的更早一秒的计数被重置
+	st.buckets[5] = statsBucket(5, 1000)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				st.record(http.MethodGet, "/users/:id")
+				st.record(http.MethodGet, "")
+				st.record(http.MethodPost, "/users/:id")
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(2400), st.requests)
+	assert.Equal(t, int64(800), *st.routes[http.MethodGet]["/users/:id"])
+	var n uint64
+	for _, b := range st.buckets {
+		n += b & (1<<32 - 1)
+	}
+	assert.Equal(t, uint64(2400), n)
+}
@@ -0,0 +1,60 @@
+package doris
+
+import (
+	"net"
+	"os"
+	"strconv"
+)
+
+// systemd传递的第一个文件描述符，之前的0、1、2为标准输入输出
+const listenFdsStart = 3
+
+// 获取systemd socket激活（LISTEN_FDS）传入的监听socket
+// 仅当LISTEN_PID为当前进程时生效，读取后清除相关环境变量以免被子进程误用
+// 未通过socket激活启动时返回空列表
+func SystemdListeners() ([]net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		return nil, nil
+	}
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	listeners := make([]net.Listener, 0, n)
+	for fd := listenFdsStart; fd < listenFdsStart+n; fd++ {
+		f := os.NewFile(uintptr(fd), "systemd-listener-"+strconv.Itoa(fd))
+		// FileListener会复制描述符，原文件可直接关闭
+		listener, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, err
+		}
+		listeners = append(listeners, listener)
+	}
+	return listeners, nil
+}
+
+// 在systemd socket激活传入的socket上启动http服务
+// 由systemd绑定特权端口，服务本身无需root权限；传入多个socket时使用第一个
+func (doris *Doris) RunSystemd() (err error) {
+	listeners, err := SystemdListeners()
+	if err != nil {
+		return err
+	}
+	if len(listeners) == 0 {
+		return SystemdListenerErr
+	}
+	for _, l := range listeners[1:] {
+		l.Close()
+	}
+	defer listeners[0].Close()
+	return doris.Serve(listeners[0])
+}
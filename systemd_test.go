@@ -0,0 +1,27 @@
+package doris
+
+import (
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSystemdListenersIgnoresOtherPid(t *testing.T) {
+	os.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+	os.Setenv("LISTEN_FDS", "1")
+	defer os.Unsetenv("LISTEN_PID")
+	defer os.Unsetenv("LISTEN_FDS")
+
+	listeners, err := SystemdListeners()
+	assert.Nil(t, err)
+	assert.Empty(t, listeners)
+	// 不属于当前进程的变量保留给实际的接收者
+	assert.Equal(t, "1", os.Getenv("LISTEN_FDS"))
+}
+
+func TestRunSystemdWithoutSocket(t *testing.T) {
+	d := New()
+	assert.Equal(t, SystemdListenerErr, d.RunSystemd())
+}
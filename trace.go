@@ -0,0 +1,167 @@
+// W3C Trace Context和Baggage的传播
+// 参见：https://www.w3.org/TR/trace-context/ 和 https://www.w3.org/TR/baggage/
+package doris
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// 链路追踪请求头
+const (
+	HeaderTraceparent = "traceparent"
+	HeaderTracestate  = "tracestate"
+	HeaderBaggage     = "baggage"
+)
+
+// 采样标志
+const TraceFlagSampled byte = 0x01
+
+type (
+	// W3C Trace Context
+	TraceContext struct {
+		TraceID  string // 32位十六进制的链路ID
+		ParentID string // 16位十六进制的上游span ID
+		Flags    byte   // 追踪标志，最低位为采样标志
+		State    string // tracestate，各追踪系统的附加信息，原样传递
+	}
+
+	// 注入链路追踪请求头的Transport
+	traceTransport struct {
+		base    http.RoundTripper
+		trace   TraceContext
+		baggage string
+	}
+)
+
+// 解析traceparent请求头，格式为"00-{trace-id}-{parent-id}-{flags}"
+func ParseTraceparent(traceparent string) (TraceContext, bool) {
+	var tc TraceContext
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return tc, false
+	}
+	// 版本00必须恰好4段，更高的版本允许附加字段
+	if parts[0] == "00" && len(parts) != 4 {
+		return tc, false
+	}
+	if !isTraceID(parts[1], 32) || !isTraceID(parts[2], 16) || len(parts[3]) != 2 {
+		return tc, false
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return tc, false
+	}
+	tc.TraceID, tc.ParentID, tc.Flags = parts[1], parts[2], flags[0]
+	return tc, true
+}
+
+// 判断是否为指定长度的小写十六进制ID，全0的ID无效
+func isTraceID(id string, length int) bool {
+	if len(id) != length || strings.Trim(id, "0") == "" {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if c := id[i]; !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// 生成指定字节数的随机十六进制ID
+func newTraceID(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// 判断是否被采样
+func (tc TraceContext) Sampled() bool {
+	return tc.Flags&TraceFlagSampled != 0
+}
+
+// 生成下游请求的traceparent，沿用链路ID并为本次调用生成新的span ID
+func (tc TraceContext) Traceparent() string {
+	return fmt.Sprintf("00-%s-%s-%02x", tc.TraceID, newTraceID(8), tc.Flags)
+}
+
+// 获取当前请求的链路上下文
+// 请求未携带有效的traceparent时开启新的链路，同一请求的下游调用共享链路ID
+func (c *Context) TraceContext() TraceContext {
+	if c.trace == nil {
+		tc, ok := ParseTraceparent(c.Request.Header.Get(HeaderTraceparent))
+		if ok {
+			tc.State = c.Request.Header.Get(HeaderTracestate)
+		} else {
+			tc = TraceContext{TraceID: newTraceID(16), Flags: TraceFlagSampled}
+		}
+		c.trace = &tc
+	}
+	return *c.trace
+}
+
+// 获取请求携带的baggage，值已进行url解码
+func (c *Context) Baggage() map[string]string {
+	baggage := make(map[string]string)
+	for _, header := range c.Request.Header[http.CanonicalHeaderKey(HeaderBaggage)] {
+		for _, member := range strings.Split(header, ",") {
+			// 忽略";"之后的属性
+			member = strings.SplitN(member, ";", 2)[0]
+			kv := strings.SplitN(member, "=", 2)
+			if len(kv) != 2 {
+				continue
+			}
+			key := strings.TrimSpace(kv[0])
+			value, err := url.PathUnescape(strings.TrimSpace(kv[1]))
+			if key == "" || err != nil {
+				continue
+			}
+			baggage[key] = value
+		}
+	}
+	return baggage
+}
+
+// 将链路追踪请求头写入下游请求的请求头
+func (c *Context) InjectTrace(header http.Header) {
+	injectTrace(header, c.TraceContext(), strings.Join(c.Request.Header[http.CanonicalHeaderKey(HeaderBaggage)], ","))
+}
+
+// 获取自动注入链路追踪请求头的http客户端
+// 链路信息在调用时确定，客户端可以在处理函数返回后继续使用
+// 示例：resp, err := c.HTTPClient().Get("http://inventory/items")
+func (c *Context) HTTPClient() *http.Client {
+	return &http.Client{Transport: &traceTransport{
+		base:    http.DefaultTransport,
+		trace:   c.TraceContext(),
+		baggage: strings.Join(c.Request.Header[http.CanonicalHeaderKey(HeaderBaggage)], ","),
+	}}
+}
+
+// 实现http.RoundTripper接口
+func (t *traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTripper不得修改原始请求
+	req = req.Clone(req.Context())
+	injectTrace(req.Header, t.trace, t.baggage)
+	return t.base.RoundTrip(req)
+}
+
+// 写入链路追踪请求头，已存在的请求头不会被覆盖
+func injectTrace(header http.Header, tc TraceContext, baggage string) {
+	if header.Get(HeaderTraceparent) == "" {
+		header.Set(HeaderTraceparent, tc.Traceparent())
+		if tc.State != "" {
+			header.Set(HeaderTracestate, tc.State)
+		}
+	}
+	if baggage != "" && header.Get(HeaderBaggage) == "" {
+		header.Set(HeaderBaggage, baggage)
+	}
+}
@@ -0,0 +1,84 @@
+package doris
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tc, ok := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	assert.True(t, ok)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", tc.TraceID)
+	assert.Equal(t, "00f067aa0ba902b7", tc.ParentID)
+	assert.True(t, tc.Sampled())
+
+	for _, invalid := range []string{
+		"",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	} {
+		_, ok := ParseTraceparent(invalid)
+		assert.False(t, ok, invalid)
+	}
+	_, ok = ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra")
+	assert.True(t, ok)
+}
+
+func TestTracePropagation(t *testing.T) {
+	var got http.Header
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header
+	}))
+	defer downstream.Close()
+
+	var baggage map[string]string
+	d := New()
+	d.GET("/", func(c *Context) error {
+		baggage = c.Baggage()
+		resp, err := c.HTTPClient().Get(downstream.URL)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		return nil
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderTraceparent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.Header.Set(HeaderTracestate, "congo=t61rcWkgMzE")
+	req.Header.Set(HeaderBaggage, "userId=alice, region=us%20east;ttl=60")
+	d.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, map[string]string{"userId": "alice", "region": "us east"}, baggage)
+	tc, ok := ParseTraceparent(got.Get(HeaderTraceparent))
+	assert.True(t, ok)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", tc.TraceID)
+	assert.NotEqual(t, "00f067aa0ba902b7", tc.ParentID)
+	assert.Equal(t, "congo=t61rcWkgMzE", got.Get(HeaderTracestate))
+	assert.Equal(t, "userId=alice, region=us%20east;ttl=60", got.Get(HeaderBaggage))
+}
+
+func TestTraceContextNewTrace(t *testing.T) {
+	d := New()
+	var first, second TraceContext
+	header := http.Header{}
+	d.GET("/", func(c *Context) error {
+		first, second = c.TraceContext(), c.TraceContext()
+		c.InjectTrace(header)
+		return nil
+	})
+	d.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Len(t, first.TraceID, 32)
+	assert.Equal(t, first.TraceID, second.TraceID)
+	tc, ok := ParseTraceparent(header.Get(HeaderTraceparent))
+	assert.True(t, ok)
+	assert.Equal(t, first.TraceID, tc.TraceID)
+	assert.Empty(t, header.Get(HeaderBaggage))
+}
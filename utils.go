@@ -5,9 +5,12 @@ package doris
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"path"
 	"reflect"
+	"runtime"
+	"strings"
 )
 
 // 连接路径公用方法
@@ -109,3 +112,21 @@ func SubString(str string, start int, end int) string {
 	// rs[开始索引:结束索引]
 	return string(rs[start:end])
 }
+
+// 获取函数的名称
+func nameOfFunction(f interface{}) string {
+	return runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+}
+
+// 生成Content-Disposition头
+// 非ASCII文件名使用RFC 5987的filename*参数
+func contentDisposition(dispositionType, name string) string {
+	quoted := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(name)
+	for i := 0; i < len(name); i++ {
+		if name[i] >= 0x80 {
+			return dispositionType + `; filename="` + quoted + `"; filename*=UTF-8''` +
+				strings.Replace(url.QueryEscape(name), "+", "%20", -1)
+		}
+	}
+	return dispositionType + `; filename="` + quoted + `"`
+}
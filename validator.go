@@ -0,0 +1,310 @@
+// 结构体校验
+// 通过validate标签声明校验规则，例如：
+//
+//	type SignUp struct {
+//		Name  string `json:"name" validate:"required,min=2,max=20"`
+//		Email string `json:"email" validate:"required,email"`
+//		Role  string `json:"role" validate:"oneof=admin user"`
+//	}
+//
+// 支持的规则：required、min、max、len、email、oneof
+// 非required的字段为零值时跳过其余规则
+// 未知规则或无法应用于字段类型的规则视为未通过，返回对应的字段错误
+package doris
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// 校验规则标签
+const validateTag = "validate"
+
+type (
+	// 结构体校验器，可替换为go-playground/validator等实现
+	// 返回ValidationErrors时由HTTPErrorHandler输出字段级的错误信息
+	Validator interface {
+		Validate(obj interface{}) error
+	}
+
+	// 单个字段的校验错误
+	FieldError struct {
+		Field   string `json:"field"`   // 字段名称，优先使用json标签，其次为param标签
+		Rule    string `json:"rule"`    // 未通过的规则
+		Param   string `json:"-"`       // 规则参数，如min=2中的2
+		Message string `json:"message"` // 错误信息
+	}
+
+	// 校验错误列表
+	ValidationErrors []FieldError
+
+	// 基于validate标签的默认校验器
+	defaultValidator struct{}
+)
+
+// 校验规则的默认错误信息，{field}和{param}分别替换为字段名称和规则参数
+var defaultValidationMessages = map[string]string{
+	"required": "{field} is required",
+	"min":      "{field} must be at least {param}",
+	"max":      "{field} must be at most {param}",
+	"len":      "{field} must have length {param}",
+	"email":    "{field} must be a valid email address",
+	"oneof":    "{field} must be one of [{param}]",
+	"type":     "{field} has an invalid type",
+}
+
+// 按语言保存的校验错误信息
+var (
+	validationMessages     = make(map[string]map[string]string)
+	validationMessagesLock sync.RWMutex
+)
+
+// 注册指定语言的校验错误信息，key为规则名称，与已有的注册合并
+// 例如：doris.RegisterValidationMessages("zh-CN", map[string]string{"required": "{field}不能为空"})
+func RegisterValidationMessages(lang string, messages map[string]string) {
+	lang = strings.ToLower(lang)
+	validationMessagesLock.Lock()
+	defer validationMessagesLock.Unlock()
+	catalog, ok := validationMessages[lang]
+	if !ok {
+		catalog = make(map[string]string, len(messages))
+		validationMessages[lang] = catalog
+	}
+	for rule, message := range messages {
+		catalog[rule] = message
+	}
+	// 使该语言可以通过Accept-Language匹配
+	errorMessagesLock.Lock()
+	if _, ok := errorMessages[lang]; !ok {
+		errorMessages[lang] = make(map[int]string)
+	}
+	errorMessagesLock.Unlock()
+}
+
+// 获取字段错误在指定语言下的错误信息
+func (fe FieldError) localize(lang string) string {
+	validationMessagesLock.RLock()
+	format, ok := validationMessages[strings.ToLower(lang)][fe.Rule]
+	validationMessagesLock.RUnlock()
+	if !ok {
+		return fe.Message
+	}
+	return strings.NewReplacer("{field}", fe.Field, "{param}", fe.Param).Replace(format)
+}
+
+// 实现error接口
+func (ve ValidationErrors) Error() string {
+	messages := make([]string, len(ve))
+	for i, fe := range ve {
+		messages[i] = fe.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// 按请求语言本地化全部字段错误
+func (ve ValidationErrors) localize(lang string) ValidationErrors {
+	localized := make(ValidationErrors, len(ve))
+	for i, fe := range ve {
+		localized[i] = fe
+		localized[i].Message = fe.localize(lang)
+	}
+	return localized
+}
+
+// 创建字段错误，使用规则的默认错误信息
+func newFieldError(field, rule, param string) FieldError {
+	message := defaultValidationMessages[rule]
+	if message == "" {
+		message = "{field} is invalid"
+	}
+	return FieldError{
+		Field:   field,
+		Rule:    rule,
+		Param:   param,
+		Message: strings.NewReplacer("{field}", field, "{param}", param).Replace(message),
+	}
+}
+
+// 使用Doris.Validator校验结构体，未配置时使用基于validate标签的默认校验器
+func (c *Context) Validate(obj interface{}) error {
+	if c.Doris != nil && c.Doris.Validator != nil {
+		return c.Doris.Validator.Validate(obj)
+	}
+	return defaultValidator{}.Validate(obj)
+}
+
+// 绑定请求参数并校验
+// 字段类型不匹配或校验失败时返回ValidationErrors，由HTTPErrorHandler输出422和字段级的错误列表
+// 请求体过大或不支持的Content-Type返回对应的*CodeError，其他绑定错误返回400
+func (c *Context) BindAndValidate(obj interface{}) error {
+	if err := c.Bind(obj); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &typeErr) && typeErr.Field != "":
+			return ValidationErrors{newFieldError(typeErr.Field, "type", typeErr.Type.String())}
+		case errors.Is(err, UnsupportedMediaTypeErr), errors.Is(err, BodyTooLargeErr):
+			return err
+		default:
+			return NewHTTPError(http.StatusBadRequest).SetInternal(err)
+		}
+	}
+	return c.Validate(obj)
+}
+
+// 校验结构体，返回全部未通过的字段
+func (defaultValidator) Validate(obj interface{}) error {
+	v := reflect.ValueOf(obj)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	var errs ValidationErrors
+	validateStruct(v, "", &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// 校验结构体的各个字段，prefix为嵌套结构体的字段名前缀
+func validateStruct(v reflect.Value, prefix string, errs *ValidationErrors) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue
+		}
+		field := v.Field(i)
+		name := prefix + fieldName(sf)
+		if rules := sf.Tag.Get(validateTag); rules != "" && rules != "-" {
+			validateField(field, name, rules, errs)
+		}
+
+		// 校验嵌套结构体
+		for field.Kind() == reflect.Ptr && !field.IsNil() {
+			field = field.Elem()
+		}
+		if field.Kind() == reflect.Struct && field.Type() != timeType {
+			if sf.Anonymous {
+				validateStruct(field, prefix, errs)
+			} else {
+				validateStruct(field, name+".", errs)
+			}
+		}
+	}
+}
+
+// 获取字段在错误信息中的名称
+func fieldName(sf reflect.StructField) string {
+	for _, tag := range []string{"json", formTag} {
+		if name := strings.Split(sf.Tag.Get(tag), ",")[0]; name != "" && name != "-" {
+			return name
+		}
+	}
+	return sf.Name
+}
+
+// 按规则校验单个字段，每个字段只记录第一个未通过的规则
+func validateField(v reflect.Value, name, rules string, errs *ValidationErrors) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			break
+		}
+		v = v.Elem()
+	}
+	zero := isZeroValue(v)
+	for _, rule := range strings.Split(rules, ",") {
+		rule, param := strings.TrimSpace(rule), ""
+		if i := strings.IndexByte(rule, '='); i >= 0 {
+			rule, param = rule[:i], rule[i+1:]
+		}
+		if rule == "required" {
+			if zero {
+				*errs = append(*errs, newFieldError(name, rule, param))
+				return
+			}
+			continue
+		}
+		// 非必填的字段为空时跳过其余规则
+		if zero {
+			return
+		}
+		if !checkRule(v, rule, param) {
+			*errs = append(*errs, newFieldError(name, rule, param))
+			return
+		}
+	}
+}
+
+// 判断是否为零值，nil指针视为零值
+func isZeroValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	case reflect.Slice, reflect.Map, reflect.Array, reflect.String:
+		return v.Len() == 0
+	}
+	return reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface())
+}
+
+// 检查单条规则，未知规则或无法应用于字段类型的规则视为未通过
+func checkRule(v reflect.Value, rule, param string) bool {
+	switch rule {
+	case "min", "max", "len":
+		n, ok := measure(v)
+		limit, err := strconv.ParseFloat(param, 64)
+		if !ok || err != nil {
+			return false
+		}
+		switch rule {
+		case "min":
+			return n >= limit
+		case "max":
+			return n <= limit
+		}
+		return n == limit
+	case "email":
+		s := fmt.Sprint(v.Interface())
+		addr, err := mail.ParseAddress(s)
+		return err == nil && addr.Address == s
+	case "oneof":
+		s := fmt.Sprint(v.Interface())
+		for _, option := range strings.Fields(param) {
+			if s == option {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+// 获取用于min、max、len比较的量：字符串为字符数，切片和map为长度，数值为其值
+func measure(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), true
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(v.Len()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
@@ -0,0 +1,114 @@
+package doris
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type signUpAddress struct {
+	City string `json:"city" validate:"required"`
+}
+
+type signUp struct {
+	Name    string         `json:"name" validate:"required,min=2,max=5"`
+	Email   string         `json:"email" validate:"required,email"`
+	Role    string         `json:"role" validate:"oneof=admin user"`
+	Age     int            `json:"age" validate:"min=18"`
+	Address *signUpAddress `json:"address"`
+}
+
+func TestValidate(t *testing.T) {
+	err := defaultValidator{}.Validate(&signUp{Name: "ab", Email: "a@b.com"})
+	assert.Nil(t, err)
+
+	err = defaultValidator{}.Validate(&signUp{
+		Name:    "abcdefg",
+		Email:   "nope",
+		Role:    "root",
+		Age:     3,
+		Address: &signUpAddress{},
+	})
+	ve, ok := err.(ValidationErrors)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"name", "email", "role", "age", "address.city"}, fields(ve))
+	assert.Equal(t, "max", ve[0].Rule)
+	assert.Equal(t, "name must be at most 5", ve[0].Message)
+	assert.Equal(t, "oneof", ve[2].Rule)
+
+	err = defaultValidator{}.Validate(&signUp{})
+	assert.Equal(t, []string{"name", "email"}, fields(err.(ValidationErrors)))
+}
+
+func TestValidateInapplicableRules(t *testing.T) {
+	type form struct {
+		Code    string        `json:"code" validate:"(required,letter)|digit"`
+		Address signUpAddress `json:"address" validate:"min=1"`
+		Age     int           `json:"age" validate:"max=abc"`
+	}
+	err := defaultValidator{}.Validate(&form{Code: "x", Address: signUpAddress{City: "a"}, Age: 1})
+	ve, ok := err.(ValidationErrors)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"code", "address", "age"}, fields(ve))
+	assert.Equal(t, "code is invalid", ve[0].Message)
+	assert.Equal(t, "min", ve[1].Rule)
+	assert.Equal(t, "max", ve[2].Rule)
+}
+
+func TestBindAndValidate(t *testing.T) {
+	RegisterValidationMessages("zz", map[string]string{"required": "{field} zz-required"})
+	d := New()
+	d.POST("/signup", func(c *Context) error {
+		var form signUp
+		if err := c.BindAndValidate(&form); err != nil {
+			return err
+		}
+		c.String(http.StatusOK, form.Name)
+		return nil
+	})
+	post := func(body, lang string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/signup", strings.NewReader(body))
+		req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+		if lang != "" {
+			req.Header.Set(HeaderAcceptLanguage, lang)
+		}
+		res := httptest.NewRecorder()
+		d.ServeHTTP(res, req)
+		return res
+	}
+
+	res := post(`{"name":"bob","email":"bob@example.com"}`, "")
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "bob", res.Body.String())
+
+	var body struct {
+		Code   int          `json:"code"`
+		Errors []FieldError `json:"data"`
+	}
+	res = post(`{"email":"bob@example.com"}`, "zz")
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Code)
+	assert.Nil(t, json.Unmarshal(res.Body.Bytes(), &body))
+	assert.Equal(t, http.StatusUnprocessableEntity, body.Code)
+	assert.Equal(t, []FieldError{{Field: "name", Rule: "required", Message: "name zz-required"}}, body.Errors)
+
+	res = post(`{"name":"bob","email":"bob@example.com","age":"old"}`, "")
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Code)
+	assert.Nil(t, json.Unmarshal(res.Body.Bytes(), &body))
+	assert.Equal(t, "age", body.Errors[0].Field)
+	assert.Equal(t, "type", body.Errors[0].Rule)
+
+	res = post(`{"name":`, "")
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+}
+
+func fields(ve ValidationErrors) []string {
+	names := make([]string, len(ve))
+	for i, fe := range ve {
+		names[i] = fe.Field
+	}
+	return names
+}
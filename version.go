@@ -0,0 +1,49 @@
+// 版本信息
+package doris
+
+import (
+	"net/http"
+	"runtime"
+	"runtime/debug"
+)
+
+// 服务的版本信息，通常在编译时通过-ldflags "-X main.commit=..."注入
+type Info struct {
+	Name      string `json:"name,omitempty"`       // 服务名称
+	Version   string `json:"version"`              // 服务版本，为空时使用模块版本
+	Commit    string `json:"commit,omitempty"`     // 代码提交
+	BuildDate string `json:"build_date,omitempty"` // 编译时间
+	GoVersion string `json:"go_version"`           // Go版本，自动填充
+	Platform  string `json:"platform"`             // 运行平台GOOS/GOARCH，自动填充
+	Doris     string `json:"doris"`                // 框架版本，自动填充
+}
+
+// 设置服务的版本信息并注册GET /version接口
+// 开启ServerHeader时在Server响应头中输出"名称/版本"
+// 示例：d.VersionInfo(doris.Info{Name: "orders", Version: version, Commit: commit, BuildDate: date})
+func (doris *Doris) VersionInfo(v Info, handlers ...HandlerFunc) {
+	if v.Version == "" {
+		if bi, ok := debug.ReadBuildInfo(); ok {
+			v.Version = bi.Main.Version
+		}
+	}
+	v.GoVersion = runtime.Version()
+	v.Platform = runtime.GOOS + "/" + runtime.GOARCH
+	v.Doris = Version
+	doris.version = &v
+	server := v.Name
+	if v.Version != "" {
+		server += "/" + v.Version
+	}
+	// 直接写入规范化的响应头，避免每个请求调用Header.Set
+	doris.serverHeader = server
+	doris.GET("/version", append(handlers, func(c *Context) error {
+		c.Json(http.StatusOK, doris.version)
+		return nil
+	})...)
+}
+
+// 获取VersionInfo设置的版本信息，未设置时返回nil
+func (doris *Doris) Info() *Info {
+	return doris.version
+}
@@ -0,0 +1,46 @@
+package doris
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionInfo(t *testing.T) {
+	d := New()
+	assert.Nil(t, d.Info())
+	d.VersionInfo(Info{Name: "orders", Version: "v2.3.1", Commit: "4f2a9c1", BuildDate: "2026-10-01T08:00:00Z"})
+	d.GET("/", func(c *Context) error { return nil })
+	d.GET("/patched", func(c *Context) error {
+		c.Response.Header()[HeaderServer][0] = "patched"
+		return nil
+	})
+	serve := func(path string) *httptest.ResponseRecorder {
+		res := httptest.NewRecorder()
+		d.ServeHTTP(res, httptest.NewRequest(http.MethodGet, path, nil))
+		return res
+	}
+
+	res := serve("/version")
+	assert.Equal(t, http.StatusOK, res.Code)
+	var info Info
+	assert.Nil(t, json.Unmarshal(res.Body.Bytes(), &info))
+	assert.Equal(t, "v2.3.1", info.Version)
+	assert.Equal(t, "4f2a9c1", info.Commit)
+	assert.Equal(t, runtime.Version(), info.GoVersion)
+	assert.Equal(t, runtime.GOOS+"/"+runtime.GOARCH, info.Platform)
+	assert.Equal(t, Version, info.Doris)
+	assert.Equal(t, "", res.Header().Get(HeaderServer))
+
+	d.ServerHeader = true
+	assert.Equal(t, "orders/v2.3.1", serve("/").Header().Get(HeaderServer))
+	assert.Equal(t, "orders/v2.3.1", serve("/missing").Header().Get(HeaderServer))
+
+	// 修改响应头不影响后续请求
+	assert.Equal(t, "patched", serve("/patched").Header().Get(HeaderServer))
+	assert.Equal(t, "orders/v2.3.1", serve("/").Header().Get(HeaderServer))
+}